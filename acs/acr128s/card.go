@@ -1,6 +1,9 @@
 package acr128s
 
 import (
+	"context"
+	"time"
+
 	"github.com/dumacp/smartcard"
 	"github.com/dumacp/smartcard/nxp/mifare"
 )
@@ -18,6 +21,29 @@ func (c *Card) Apdu(apdu []byte) ([]byte, error) {
 	return c.reader.Transmit(apdu)
 }
 
+func (c *Card) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	return c.reader.TransmitContext(ctx, apdu)
+}
+
+// ApduTimed send apdu to the card and return the round trip time of the
+// frames in the serial device
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	return c.reader.transmitTimed(context.Background(), apdu)
+}
+
+// BeginExclusive lock the serial device to the card, the cards of the other
+// slots of the device wait until EndExclusive
+func (c *Card) BeginExclusive() error {
+	c.reader.dev.lockExclusive(c.reader)
+	return nil
+}
+
+// EndExclusive unlock the serial device
+func (c *Card) EndExclusive() error {
+	c.reader.dev.unlockExclusive(c.reader)
+	return nil
+}
+
 func (c *Card) ATR() ([]byte, error) {
 
 	return c.atr, nil
@@ -0,0 +1,42 @@
+package acr128s
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("acr128s", Open)
+}
+
+// Open open the reader of the URI acr128s://<port>?baud=115200&timeout=1s&slot=picc
+// (slot: picc, icc or sam)
+func Open(u *smartcard.URI) (smartcard.IReader, error) {
+	baud, err := u.Int("baud", 115200)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := u.Duration("timeout", 1*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var slot Slot
+	switch u.Option("slot", "picc") {
+	case "picc":
+		slot = SLOT_PICC
+	case "icc":
+		slot = SLOT_ICC
+	case "sam":
+		slot = SLOT_SAM
+	default:
+		return nil, fmt.Errorf("option \"slot\" = %q, %w", u.Option("slot", ""), smartcard.ErrURI)
+	}
+
+	dev, err := NewDevice(u.Device, baud, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(dev, u.Option("name", u.Device), slot), nil
+}
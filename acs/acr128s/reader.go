@@ -1,6 +1,7 @@
 package acr128s
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -27,6 +28,18 @@ func NewReader(dev *Device, readerName string, slot Slot) *Reader {
 
 // Transmit Primitive function transceive to send apdu
 func (r *Reader) Transmit(apdu []byte) ([]byte, error) {
+	return r.TransmitContext(context.Background(), apdu)
+}
+
+// TransmitContext Primitive function transceive to send apdu with ctx cancellation
+func (r *Reader) TransmitContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	dataResponse, _, err := r.transmitTimed(ctx, apdu)
+	return dataResponse, err
+}
+
+// transmitTimed TransmitContext that returns the round trip time of the
+// frames in the serial device
+func (r *Reader) transmitTimed(ctx context.Context, apdu []byte) ([]byte, time.Duration, error) {
 
 	// fmt.Printf("APDU: % 02X\n", apdu)
 
@@ -36,33 +49,36 @@ func (r *Reader) Transmit(apdu []byte) ([]byte, error) {
 
 	if err != nil {
 		// fmt.Printf("errorTransmit BuildFrame: % X\n", data)
-		return nil, err
+		return nil, 0, err
 	}
 	r.seq += 1
 
 	// fmt.Printf("Transmit: % X\n", data)
-	response, err := r.dev.SendRecv(data, 3000*time.Millisecond)
+	defer r.dev.waitExclusive(r)()
+	response, elapsed, err := r.dev.sendRecvTimed(ctx, data, 3000*time.Millisecond)
 	if err != nil {
 		// fmt.Printf("errorTransmit response: % X\n", response)
-		return nil, err
+		return nil, 0, err
 	}
 	if len(response) <= 4 {
 
 		if err := VerifyStatusReponse(response); err != nil {
 			// fmt.Printf("errorTransmit response: % X\n", response)
-			return nil, err
+			return nil, 0, err
 		}
-		response, err = r.dev.SendRecv(FRAME_NACK, 1200*time.Millisecond)
+		var elapsedNack time.Duration
+		response, elapsedNack, err = r.dev.sendRecvTimed(ctx, FRAME_NACK, 1200*time.Millisecond)
 		if err != nil {
 			// fmt.Printf("errorTransmit response: % X\n", response)
-			return nil, err
+			return nil, 0, err
 		}
+		elapsed += elapsedNack
 	}
 
 	dataResponse, err := GetResponse__RDR_to_PC_DataBlock(response)
 	if err != nil {
 		// fmt.Printf("errorTransmit response: % X\n", response)
-		return nil, err
+		return nil, 0, err
 	}
 	// if len(dataResponse) < 2 {
 	// 	fmt.Printf("errorTransmit response: % X, (% X)\n", response, data)
@@ -72,7 +88,7 @@ func (r *Reader) Transmit(apdu []byte) ([]byte, error) {
 
 	// fmt.Printf("APDU Response: % 02X\n", dataResponse)
 
-	return dataResponse, nil
+	return dataResponse, elapsed, nil
 }
 
 // EscapeCommand Primitive function to send Control commands
@@ -85,6 +101,7 @@ func (r *Reader) EscapeCommand(apdu []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, 100*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -123,6 +140,7 @@ func (r *Reader) IccEspecial() ([]byte, error) {
 	}
 	r.seq += 1
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, 100*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -243,6 +261,7 @@ func (r *Reader) IccPowerOff() ([]byte, error) {
 	}
 	r.seq += 1
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, 100*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -280,6 +299,7 @@ func (r *Reader) IccPowerOn() ([]byte, error) {
 	}
 	r.seq += 1
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, 100*time.Millisecond)
 	if err != nil {
 		return nil, err
@@ -303,6 +323,26 @@ func (r *Reader) IccPowerOn() ([]byte, error) {
 	return dataResponse, nil
 }
 
+// Poll detect a card in the field with the polling escape command and read
+// the UID (PC/SC GET DATA), without the activation of the card (IccPowerOn).
+func (r *Reader) Poll() ([]byte, error) {
+	respEscape, err := r.EscapeCommand([]byte{0xE0, 0, 0, 0x25, 0})
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrComm)
+	}
+	if len(respEscape) <= 0 || respEscape[len(respEscape)-1] == 0 {
+		return nil, fmt.Errorf("without card detect, %w", smartcard.ErrNoSmartcard)
+	}
+	respGetData, err := r.Transmit([]byte{0xFF, 0xCA, 0, 0, 0})
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	if len(respGetData) < 2 || respGetData[len(respGetData)-2] != 0x90 {
+		return nil, fmt.Errorf("poll err = [% X], %w", respGetData, smartcard.ErrNoSmartcard)
+	}
+	return respGetData[:len(respGetData)-2], nil
+}
+
 // ConnectCard Create New Card interface with T=1
 func (r *Reader) ConnectCard() (smartcard.ICard, error) {
 	// defer time.Sleep(1 * time.Second)
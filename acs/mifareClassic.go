@@ -65,7 +65,6 @@ func (mc *mifareClassic) valueop(valOp, bNr byte, value []byte) error {
 
 	aid := []byte{0xFF, 0xD7, 0x00, byte(bNr), 0x05, byte(valOp)}
 	aid = append(aid, dataInv...)
-	fmt.Printf("apdu: %X, %02X\n", aid, dataInv)
 	response, err := mc.Card.Apdu(aid)
 	if err != nil {
 		return err
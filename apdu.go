@@ -0,0 +1,258 @@
+package smartcard
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxShortNc max length of command data in a short APDU
+	MaxShortNc = 255
+	// MaxShortNe max length of expected response data in a short APDU
+	MaxShortNe = 256
+	// MaxExtendedNc max length of command data in an extended APDU
+	MaxExtendedNc = 65535
+	// MaxExtendedNe max length of expected response data in an extended APDU
+	MaxExtendedNe = 65536
+)
+
+// ErrApduLength the length of the APDU or of its fields is wrong
+var ErrApduLength = errors.New("error apdu length")
+
+// Case ISO 7816-4 command case
+type Case int
+
+const (
+	// Case1 no command data, no response data
+	Case1 Case = iota + 1
+	// Case2 no command data, expected response data
+	Case2
+	// Case3 command data, no response data
+	Case3
+	// Case4 command data, expected response data
+	Case4
+)
+
+func (c Case) String() string {
+	switch c {
+	case Case1:
+		return "case 1"
+	case Case2:
+		return "case 2"
+	case Case3:
+		return "case 3"
+	case Case4:
+		return "case 4"
+	}
+	return fmt.Sprintf("case %d", int(c))
+}
+
+// Command APDU command ISO 7816-4. Ne is the max number of bytes expected in
+// the response data (0: without Le field). The extended length is used when
+// Data or Ne don't fit in a short APDU or Extended is true.
+type Command struct {
+	CLA      byte
+	INS      byte
+	P1       byte
+	P2       byte
+	Data     []byte
+	Ne       int
+	Extended bool
+}
+
+// NewCommand create a Command from the header in cmd. When cmd.Le is true and
+// ne is 0, the command is built with Le = 0x00 (256 bytes in short APDU).
+func (cmd *ISO7816cmd) NewCommand(data []byte, ne int) *Command {
+	c := &Command{
+		CLA:  cmd.CLA,
+		INS:  cmd.INS,
+		P1:   cmd.P1,
+		P2:   cmd.P2,
+		Data: data,
+		Ne:   ne,
+	}
+	if cmd.Le && ne == 0 {
+		c.Ne = MaxShortNe
+	}
+	return c
+}
+
+// Case ISO 7816-4 case of the command
+func (c *Command) Case() Case {
+	switch {
+	case len(c.Data) == 0 && c.Ne == 0:
+		return Case1
+	case len(c.Data) == 0:
+		return Case2
+	case c.Ne == 0:
+		return Case3
+	}
+	return Case4
+}
+
+// IsExtended the command is encoded with extended length fields
+func (c *Command) IsExtended() bool {
+	return c.Extended || len(c.Data) > MaxShortNc || c.Ne > MaxShortNe
+}
+
+// Validate verify the length of the fields
+func (c *Command) Validate() error {
+	if len(c.Data) > MaxExtendedNc {
+		return fmt.Errorf("%w: data length %d > %d", ErrApduLength, len(c.Data), MaxExtendedNc)
+	}
+	if c.Ne < 0 || c.Ne > MaxExtendedNe {
+		return fmt.Errorf("%w: Ne %d out of range [0, %d]", ErrApduLength, c.Ne, MaxExtendedNe)
+	}
+	return nil
+}
+
+// Bytes encode the command
+func (c *Command) Bytes() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	nc := len(c.Data)
+	ext := c.IsExtended()
+
+	apdu := make([]byte, 0, 4+3+nc+3)
+	apdu = append(apdu, c.CLA, c.INS, c.P1, c.P2)
+	if nc > 0 {
+		if ext {
+			apdu = append(apdu, 0x00, byte(nc>>8), byte(nc))
+		} else {
+			apdu = append(apdu, byte(nc))
+		}
+		apdu = append(apdu, c.Data...)
+	}
+	if c.Ne > 0 {
+		// Le = 0x00 (0x0000) codes the max value (256 or 65536)
+		switch {
+		case ext && nc > 0:
+			apdu = append(apdu, byte(c.Ne>>8), byte(c.Ne))
+		case ext:
+			apdu = append(apdu, 0x00, byte(c.Ne>>8), byte(c.Ne))
+		default:
+			apdu = append(apdu, byte(c.Ne))
+		}
+	}
+	return apdu, nil
+}
+
+// ParseCommand decode an APDU command in any of the ISO 7816-4 cases
+func ParseCommand(apdu []byte) (*Command, error) {
+	if len(apdu) < 4 {
+		return nil, fmt.Errorf("%w: command length %d < 4", ErrApduLength, len(apdu))
+	}
+	c := &Command{
+		CLA: apdu[0],
+		INS: apdu[1],
+		P1:  apdu[2],
+		P2:  apdu[3],
+	}
+	body := apdu[4:]
+	switch {
+	case len(body) == 0:
+		// case 1
+	case len(body) == 1:
+		// case 2 short
+		c.Ne = leToNe(int(body[0]), false)
+	case body[0] != 0x00 || len(body) < 3:
+		// case 3 or 4 short
+		nc := int(body[0])
+		if nc == 0 {
+			return nil, fmt.Errorf("%w: short command with Lc = 0", ErrApduLength)
+		}
+		switch len(body) {
+		case 1 + nc:
+		case 2 + nc:
+			c.Ne = leToNe(int(body[1+nc]), false)
+		default:
+			return nil, fmt.Errorf("%w: short command, Lc = %d, body length %d",
+				ErrApduLength, nc, len(body))
+		}
+		c.Data = append([]byte{}, body[1:1+nc]...)
+	default:
+		c.Extended = true
+		if len(body) == 3 {
+			// case 2 extended
+			c.Ne = leToNe(int(body[1])<<8|int(body[2]), true)
+			break
+		}
+		// case 3 or 4 extended
+		nc := int(body[1])<<8 | int(body[2])
+		if nc == 0 {
+			return nil, fmt.Errorf("%w: extended command with Lc = 0", ErrApduLength)
+		}
+		switch len(body) {
+		case 3 + nc:
+		case 5 + nc:
+			c.Ne = leToNe(int(body[3+nc])<<8|int(body[4+nc]), true)
+		default:
+			return nil, fmt.Errorf("%w: extended command, Lc = %d, body length %d",
+				ErrApduLength, nc, len(body))
+		}
+		c.Data = append([]byte{}, body[3:3+nc]...)
+	}
+	return c, nil
+}
+
+func leToNe(le int, extended bool) int {
+	if le != 0 {
+		return le
+	}
+	if extended {
+		return MaxExtendedNe
+	}
+	return MaxShortNe
+}
+
+// Response APDU response ISO 7816-4
+type Response struct {
+	Data []byte
+	SW1  byte
+	SW2  byte
+}
+
+// ParseResponse decode an APDU response (data + SW1 SW2)
+func ParseResponse(resp []byte) (*Response, error) {
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("%w: response length %d < 2", ErrApduLength, len(resp))
+	}
+	r := &Response{
+		Data: append([]byte{}, resp[:len(resp)-2]...),
+		SW1:  resp[len(resp)-2],
+		SW2:  resp[len(resp)-1],
+	}
+	return r, nil
+}
+
+// SW status word
+func (r *Response) SW() uint16 {
+	return uint16(r.SW1)<<8 | uint16(r.SW2)
+}
+
+// IsOK status word is 0x9000
+func (r *Response) IsOK() bool {
+	return r.SW1 == 0x90 && r.SW2 == 0x00
+}
+
+// Bytes encode the response
+func (r *Response) Bytes() []byte {
+	resp := make([]byte, 0, len(r.Data)+2)
+	resp = append(resp, r.Data...)
+	resp = append(resp, r.SW1, r.SW2)
+	return resp
+}
+
+// Transmit encode and send the command to the card and decode the response
+func Transmit(card ICard, cmd *Command) (*Response, error) {
+	apdu, err := cmd.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := card.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(resp)
+}
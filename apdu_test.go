@@ -0,0 +1,198 @@
+package smartcard
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommand_Bytes(t *testing.T) {
+	data300 := bytes.Repeat([]byte{0xAA}, 300)
+	tests := []struct {
+		name     string
+		cmd      *Command
+		want     []byte
+		wantCase Case
+		wantErr  bool
+	}{
+		{
+			name:     "case 1",
+			cmd:      &Command{CLA: 0x00, INS: 0x70, P1: 0x00, P2: 0x00},
+			want:     []byte{0x00, 0x70, 0x00, 0x00},
+			wantCase: Case1,
+		},
+		{
+			name:     "case 2 short Ne 256",
+			cmd:      &Command{CLA: 0x00, INS: 0xB0, Ne: 256},
+			want:     []byte{0x00, 0xB0, 0x00, 0x00, 0x00},
+			wantCase: Case2,
+		},
+		{
+			name:     "case 3 short",
+			cmd:      &Command{CLA: 0x00, INS: 0xA4, P2: 0x0C, Data: []byte{0x3F, 0x00}},
+			want:     []byte{0x00, 0xA4, 0x00, 0x0C, 0x02, 0x3F, 0x00},
+			wantCase: Case3,
+		},
+		{
+			name:     "case 4 short",
+			cmd:      &Command{CLA: 0x90, INS: 0x5A, Data: []byte{0x01, 0x02, 0x03}, Ne: 256},
+			want:     []byte{0x90, 0x5A, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03, 0x00},
+			wantCase: Case4,
+		},
+		{
+			name:     "case 2 extended",
+			cmd:      &Command{CLA: 0x00, INS: 0xB0, Ne: 65536},
+			want:     []byte{0x00, 0xB0, 0x00, 0x00, 0x00, 0x00, 0x00},
+			wantCase: Case2,
+		},
+		{
+			name:     "case 4 extended forced",
+			cmd:      &Command{CLA: 0x00, INS: 0x88, Data: []byte{0x01}, Ne: 16, Extended: true},
+			want:     []byte{0x00, 0x88, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x10},
+			wantCase: Case4,
+		},
+		{
+			name: "case 3 extended",
+			cmd:  &Command{CLA: 0x00, INS: 0xD6, Data: data300},
+			want: append([]byte{0x00, 0xD6, 0x00, 0x00, 0x00, 0x01, 0x2C},
+				data300...),
+			wantCase: Case3,
+		},
+		{
+			name:    "Ne out of range",
+			cmd:     &Command{CLA: 0x00, INS: 0xB0, Ne: 65537},
+			wantErr: true,
+		},
+		{
+			name:    "data too long",
+			cmd:     &Command{CLA: 0x00, INS: 0xD6, Data: make([]byte, 65536)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cmd.Bytes()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Bytes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, ErrApduLength) {
+					t.Errorf("Bytes() error = %v, want %v", err, ErrApduLength)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bytes() = [% X], want [% X]", got, tt.want)
+			}
+			if tt.cmd.Case() != tt.wantCase {
+				t.Errorf("Case() = %v, want %v", tt.cmd.Case(), tt.wantCase)
+			}
+			parsed, err := ParseCommand(got)
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			again, _ := parsed.Bytes()
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("ParseCommand().Bytes() = [% X], want [% X]", again, got)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		apdu    []byte
+		want    *Command
+		wantErr bool
+	}{
+		{
+			name: "case 4 short",
+			apdu: []byte{0x00, 0xA4, 0x04, 0x00, 0x02, 0xA0, 0x00, 0x10},
+			want: &Command{CLA: 0x00, INS: 0xA4, P1: 0x04, Data: []byte{0xA0, 0x00}, Ne: 16},
+		},
+		{
+			name: "case 2 short Le 00",
+			apdu: []byte{0x00, 0xC0, 0x00, 0x00, 0x00},
+			want: &Command{CLA: 0x00, INS: 0xC0, Ne: 256},
+		},
+		{
+			name:    "header too short",
+			apdu:    []byte{0x00, 0xA4, 0x04},
+			wantErr: true,
+		},
+		{
+			name:    "Lc doesn't match",
+			apdu:    []byte{0x00, 0xA4, 0x04, 0x00, 0x05, 0xA0, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "extended Lc doesn't match",
+			apdu:    []byte{0x00, 0xD6, 0x00, 0x00, 0x00, 0x00, 0x04, 0x01, 0x02},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCommand(tt.apdu)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCommand() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    *Response
+		wantSW  uint16
+		wantErr bool
+	}{
+		{
+			name:   "data and 9000",
+			resp:   []byte{0x01, 0x02, 0x90, 0x00},
+			want:   &Response{Data: []byte{0x01, 0x02}, SW1: 0x90, SW2: 0x00},
+			wantSW: 0x9000,
+		},
+		{
+			name:   "only SW",
+			resp:   []byte{0x6A, 0x82},
+			want:   &Response{Data: []byte{}, SW1: 0x6A, SW2: 0x82},
+			wantSW: 0x6A82,
+		},
+		{
+			name:    "too short",
+			resp:    []byte{0x90},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResponse(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseResponse() = %+v, want %+v", got, tt.want)
+			}
+			if got.SW() != tt.wantSW {
+				t.Errorf("SW() = %04X, want %04X", got.SW(), tt.wantSW)
+			}
+			if !reflect.DeepEqual(got.Bytes(), tt.resp) {
+				t.Errorf("Bytes() = [% X], want [% X]", got.Bytes(), tt.resp)
+			}
+		})
+	}
+}
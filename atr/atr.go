@@ -0,0 +1,392 @@
+/*
+package to parse the ATR (Answer To Reset, ISO 7816-3) returned by the cards,
+including the ATR built by the PC/SC readers to contactless cards (PC/SC Part 3).
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package atr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dumacp/smartcard/tlv"
+)
+
+// ErrBadATR the ATR is malformed
+var ErrBadATR = errors.New("bad ATR")
+
+const (
+	// TSDirect initial character of direct convention
+	TSDirect byte = 0x3B
+	// TSInverse initial character of inverse convention
+	TSInverse byte = 0x3F
+)
+
+// Protocol transmission protocol (T=0 ... T=15)
+type Protocol int
+
+const (
+	T0  Protocol = 0
+	T1  Protocol = 1
+	T15 Protocol = 15
+)
+
+func (p Protocol) String() string {
+	return fmt.Sprintf("T=%d", int(p))
+}
+
+// InterfaceGroup interface bytes TAi, TBi, TCi and TDi of the group i
+type InterfaceGroup struct {
+	TA, TB, TC, TD             byte
+	HasTA, HasTB, HasTC, HasTD bool
+}
+
+// ATR Answer To Reset
+type ATR struct {
+	Raw        []byte
+	TS         byte
+	T0         byte
+	Interface  []InterfaceGroup
+	Historical []byte
+	TCK        byte
+	HasTCK     bool
+}
+
+// Parse decode the ATR and verify TCK
+func Parse(data []byte) (*ATR, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: length %d", ErrBadATR, len(data))
+	}
+	a := &ATR{
+		Raw: append([]byte{}, data...),
+		TS:  data[0],
+		T0:  data[1],
+	}
+	if a.TS != TSDirect && a.TS != TSInverse {
+		return nil, fmt.Errorf("%w: TS = %02X", ErrBadATR, a.TS)
+	}
+
+	idx := 2
+	y := a.T0 >> 4
+	next := func(name string, i int) (byte, error) {
+		if idx >= len(data) {
+			return 0, fmt.Errorf("%w: missing %s%d", ErrBadATR, name, i)
+		}
+		b := data[idx]
+		idx++
+		return b, nil
+	}
+	needTCK := false
+	for i := 1; ; i++ {
+		g := InterfaceGroup{}
+		var err error
+		if y&0x01 != 0 {
+			if g.TA, err = next("TA", i); err != nil {
+				return nil, err
+			}
+			g.HasTA = true
+		}
+		if y&0x02 != 0 {
+			if g.TB, err = next("TB", i); err != nil {
+				return nil, err
+			}
+			g.HasTB = true
+		}
+		if y&0x04 != 0 {
+			if g.TC, err = next("TC", i); err != nil {
+				return nil, err
+			}
+			g.HasTC = true
+		}
+		if y&0x08 != 0 {
+			if g.TD, err = next("TD", i); err != nil {
+				return nil, err
+			}
+			g.HasTD = true
+			if g.TD&0x0F != 0 {
+				needTCK = true
+			}
+		}
+		a.Interface = append(a.Interface, g)
+		if !g.HasTD {
+			break
+		}
+		y = g.TD >> 4
+	}
+
+	k := int(a.T0 & 0x0F)
+	if idx+k > len(data) {
+		return nil, fmt.Errorf("%w: historical bytes, want %d, got %d", ErrBadATR, k, len(data)-idx)
+	}
+	a.Historical = append([]byte{}, data[idx:idx+k]...)
+	idx += k
+
+	if needTCK {
+		if idx >= len(data) {
+			return nil, fmt.Errorf("%w: missing TCK", ErrBadATR)
+		}
+		a.TCK = data[idx]
+		a.HasTCK = true
+		idx++
+		check := byte(0)
+		for _, v := range data[1:idx] {
+			check ^= v
+		}
+		if check != 0 {
+			return nil, fmt.Errorf("%w: TCK = %02X, checksum error", ErrBadATR, a.TCK)
+		}
+	}
+	if idx != len(data) {
+		return nil, fmt.Errorf("%w: %d extra bytes", ErrBadATR, len(data)-idx)
+	}
+	return a, nil
+}
+
+// Protocols protocols indicated in TDi (T=0 if there is no TD1)
+func (a *ATR) Protocols() []Protocol {
+	result := make([]Protocol, 0)
+	for _, g := range a.Interface {
+		if !g.HasTD {
+			continue
+		}
+		p := Protocol(g.TD & 0x0F)
+		found := false
+		for _, v := range result {
+			if v == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, p)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, T0)
+	}
+	return result
+}
+
+// Supports the protocol is indicated in the ATR
+func (a *ATR) Supports(p Protocol) bool {
+	for _, v := range a.Protocols() {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// TA get TAi (i >= 1)
+func (a *ATR) TA(i int) (byte, bool) {
+	if i < 1 || i > len(a.Interface) {
+		return 0, false
+	}
+	return a.Interface[i-1].TA, a.Interface[i-1].HasTA
+}
+
+// TB get TBi (i >= 1)
+func (a *ATR) TB(i int) (byte, bool) {
+	if i < 1 || i > len(a.Interface) {
+		return 0, false
+	}
+	return a.Interface[i-1].TB, a.Interface[i-1].HasTB
+}
+
+// TC get TCi (i >= 1)
+func (a *ATR) TC(i int) (byte, bool) {
+	if i < 1 || i > len(a.Interface) {
+		return 0, false
+	}
+	return a.Interface[i-1].TC, a.Interface[i-1].HasTC
+}
+
+// TD get TDi (i >= 1)
+func (a *ATR) TD(i int) (byte, bool) {
+	if i < 1 || i > len(a.Interface) {
+		return 0, false
+	}
+	return a.Interface[i-1].TD, a.Interface[i-1].HasTD
+}
+
+var tableFi = [16]int{372, 372, 558, 744, 1116, 1488, 1860, 0, 0, 512, 768, 1024, 1536, 2048, 0, 0}
+var tableDi = [16]int{0, 1, 2, 4, 8, 16, 32, 64, 12, 20, 0, 0, 0, 0, 0, 0}
+
+// FiDi clock rate conversion integer and baud rate adjustment integer from
+// TA1 (default Fi = 372, Di = 1). 0 is a RFU value.
+func (a *ATR) FiDi() (fi, di int) {
+	ta1, ok := a.TA(1)
+	if !ok {
+		return 372, 1
+	}
+	return tableFi[ta1>>4], tableDi[ta1&0x0F]
+}
+
+// Contactless information of the ATR built by a PC/SC reader to a
+// contactless storage card (PC/SC Part 3, 3.1.3.2.3)
+type Contactless struct {
+	RID      []byte
+	Standard byte
+	CardName uint16
+}
+
+// RIDPCSC registered application provider identifier of PC/SC workgroup
+var RIDPCSC = []byte{0xA0, 0x00, 0x00, 0x03, 0x06}
+
+// Standard byte (SS) in PC/SC Part 3
+const (
+	StandardISO14443A3 byte = 0x03
+	StandardISO14443B3 byte = 0x07
+	StandardISO15693_3 byte = 0x0B
+	StandardFelica     byte = 0x11
+)
+
+// Card name bytes (NN) in PC/SC Part 3
+const (
+	NameMifareClassic1K   uint16 = 0x0001
+	NameMifareClassic4K   uint16 = 0x0002
+	NameMifareUltralight  uint16 = 0x0003
+	NameMifareMini        uint16 = 0x0026
+	NameMifareUltralightC uint16 = 0x003A
+	NameMifarePlusSL1_2K  uint16 = 0x0036
+	NameMifarePlusSL1_4K  uint16 = 0x0037
+	NameMifarePlusSL2_2K  uint16 = 0x0038
+	NameMifarePlusSL2_4K  uint16 = 0x0039
+	NameTopaz             uint16 = 0xF004
+	NameFelica212K        uint16 = 0xF011
+	NameFelica424K        uint16 = 0xF012
+)
+
+// HistoricalObjects decode the compact-TLV data objects of the historical
+// bytes (category indicator 80 or 00)
+func (a *ATR) HistoricalObjects() ([]tlv.CompactTLV, error) {
+	return tlv.DecodeHistorical(a.Historical)
+}
+
+// Contactless decode the historical bytes of a PC/SC Part 3 ATR
+// (80 4F 0C RID SS NN NN 00 00 00 00)
+func (a *ATR) Contactless() (*Contactless, bool) {
+	h := a.Historical
+	if len(h) < 11 || h[0] != 0x80 || h[1] != 0x4F {
+		return nil, false
+	}
+	l := int(h[2])
+	if l < 8 || len(h) < 3+l {
+		return nil, false
+	}
+	aid := h[3 : 3+l]
+	c := &Contactless{
+		RID:      append([]byte{}, aid[:5]...),
+		Standard: aid[5],
+		CardName: uint16(aid[6])<<8 | uint16(aid[7]),
+	}
+	if !bytes.Equal(c.RID, RIDPCSC) {
+		return nil, false
+	}
+	return c, true
+}
+
+// CardType family of card identified from the ATR
+type CardType int
+
+const (
+	Unknown CardType = iota
+	MifareClassic1K
+	MifareClassic4K
+	MifareMini
+	MifareUltralight
+	MifareUltralightC
+	MifarePlusSL1
+	MifarePlusSL2
+	MifarePlus
+	MifareDesfire
+	Felica
+	ISO14443_4
+	SAM
+)
+
+func (t CardType) String() string {
+	switch t {
+	case MifareClassic1K:
+		return "MIFARE Classic 1K"
+	case MifareClassic4K:
+		return "MIFARE Classic 4K"
+	case MifareMini:
+		return "MIFARE Mini"
+	case MifareUltralight:
+		return "MIFARE Ultralight"
+	case MifareUltralightC:
+		return "MIFARE Ultralight C"
+	case MifarePlusSL1:
+		return "MIFARE Plus SL1"
+	case MifarePlusSL2:
+		return "MIFARE Plus SL2"
+	case MifarePlus:
+		return "MIFARE Plus"
+	case MifareDesfire:
+		return "MIFARE DESFire"
+	case Felica:
+		return "FeliCa"
+	case ISO14443_4:
+		return "ISO 14443-4"
+	case SAM:
+		return "SAM"
+	}
+	return "unknown"
+}
+
+// Identify family of the card from the ATR alone. The cards ISO 14443-4
+// are identified by the historical bytes of the ATS, mapped in the ATR
+// by the PC/SC readers.
+func (a *ATR) Identify() CardType {
+	if c, ok := a.Contactless(); ok {
+		switch c.CardName {
+		case NameMifareClassic1K:
+			return MifareClassic1K
+		case NameMifareClassic4K:
+			return MifareClassic4K
+		case NameMifareMini:
+			return MifareMini
+		case NameMifareUltralight:
+			return MifareUltralight
+		case NameMifareUltralightC:
+			return MifareUltralightC
+		case NameMifarePlusSL1_2K, NameMifarePlusSL1_4K:
+			return MifarePlusSL1
+		case NameMifarePlusSL2_2K, NameMifarePlusSL2_4K:
+			return MifarePlusSL2
+		case NameFelica212K, NameFelica424K:
+			return Felica
+		}
+		return Unknown
+	}
+
+	h := a.Historical
+	switch {
+	case bytes.Contains(h, []byte("SAM")):
+		return SAM
+	// ATR to contactless ISO 14443-4 card: 3B 8n 80 01 <historical ATS>
+	case !a.isContactless4():
+		return Unknown
+	case bytes.Equal(h, []byte{0x80}):
+		return MifareDesfire
+	case bytes.HasPrefix(h, []byte{0xC1, 0x05, 0x2F, 0x2F}),
+		bytes.HasPrefix(h, []byte{0xC1, 0x05, 0x21, 0x30}):
+		return MifarePlus
+	}
+	return ISO14443_4
+}
+
+// isContactless4 ATR built by a PC/SC reader to an ISO 14443-4 card
+func (a *ATR) isContactless4() bool {
+	if a.TS != TSDirect || a.T0&0xF0 != 0x80 || len(a.Interface) < 2 {
+		return false
+	}
+	td1, _ := a.TD(1)
+	td2, _ := a.TD(2)
+	return td1 == 0x80 && td2 == 0x01
+}
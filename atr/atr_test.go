@@ -0,0 +1,144 @@
+package atr
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		atr           string
+		wantProtocols []Protocol
+		wantHist      string
+		wantType      CardType
+		wantErr       bool
+	}{
+		{
+			name:          "mifare classic 1K",
+			atr:           "3B 8F 80 01 80 4F 0C A0 00 00 03 06 03 00 01 00 00 00 00 6A",
+			wantProtocols: []Protocol{T0, T1},
+			wantHist:      "80 4F 0C A0 00 00 03 06 03 00 01 00 00 00 00",
+			wantType:      MifareClassic1K,
+		},
+		{
+			name:          "mifare classic 4K",
+			atr:           "3B 8F 80 01 80 4F 0C A0 00 00 03 06 03 00 02 00 00 00 00 69",
+			wantProtocols: []Protocol{T0, T1},
+			wantHist:      "80 4F 0C A0 00 00 03 06 03 00 02 00 00 00 00",
+			wantType:      MifareClassic4K,
+		},
+		{
+			name:          "mifare plus SL2",
+			atr:           "3B 8F 80 01 80 4F 0C A0 00 00 03 06 03 00 38 00 00 00 00 53",
+			wantProtocols: []Protocol{T0, T1},
+			wantHist:      "80 4F 0C A0 00 00 03 06 03 00 38 00 00 00 00",
+			wantType:      MifarePlusSL2,
+		},
+		{
+			name:          "desfire",
+			atr:           "3B 81 80 01 80 80",
+			wantProtocols: []Protocol{T0, T1},
+			wantHist:      "80",
+			wantType:      MifareDesfire,
+		},
+		{
+			name:          "mifare plus SL3",
+			atr:           "3B 87 80 01 C1 05 2F 2F 01 BC D6 A9",
+			wantProtocols: []Protocol{T0, T1},
+			wantHist:      "C1 05 2F 2F 01 BC D6",
+			wantType:      MifarePlus,
+		},
+		{
+			name: "sam av2",
+			atr: "3B DF 18 FF 81 F1 FE 43 00 3F 03 83 4D 49 46 41 52 45 20 50 " +
+				"6C 75 73 20 53 41 4D 3B",
+			wantProtocols: []Protocol{T1, T15},
+			wantHist:      "4D 49 46 41 52 45 20 50 6C 75 73 20 53 41 4D",
+			wantType:      SAM,
+		},
+		{
+			name:          "T=0 without TCK",
+			atr:           "3B 02 14 50",
+			wantProtocols: []Protocol{T0},
+			wantHist:      "14 50",
+			wantType:      Unknown,
+		},
+		{
+			name:    "bad TCK",
+			atr:     "3B 81 80 01 80 81",
+			wantErr: true,
+		},
+		{
+			name:    "missing historical bytes",
+			atr:     "3B 8F 80 01 80 4F",
+			wantErr: true,
+		},
+		{
+			name:    "bad TS",
+			atr:     "3A 00",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(decodeHex(t, tt.atr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, ErrBadATR) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrBadATR)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got.Protocols(), tt.wantProtocols) {
+				t.Errorf("Protocols() = %v, want %v", got.Protocols(), tt.wantProtocols)
+			}
+			if !reflect.DeepEqual(got.Historical, decodeHex(t, tt.wantHist)) {
+				t.Errorf("Historical = [% X], want [%s]", got.Historical, tt.wantHist)
+			}
+			if got.Identify() != tt.wantType {
+				t.Errorf("Identify() = %v, want %v", got.Identify(), tt.wantType)
+			}
+		})
+	}
+}
+
+func TestATR_FiDi(t *testing.T) {
+	a, err := Parse(decodeHex(t, "3B DF 18 FF 81 F1 FE 43 00 3F 03 83 4D 49 46 41 52 45 20 50 "+
+		"6C 75 73 20 53 41 4D 3B"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, di := a.FiDi(); fi != 372 || di != 12 {
+		t.Errorf("FiDi() = %d, %d, want 372, 12", fi, di)
+	}
+	if tc1, ok := a.TC(1); !ok || tc1 != 0xFF {
+		t.Errorf("TC(1) = %02X, %v, want FF, true", tc1, ok)
+	}
+}
+
+func TestATR_HistoricalObjects(t *testing.T) {
+	a := &ATR{Historical: decodeHex(t, "80 31 80 73 80 21 40")}
+	got, err := a.HistoricalObjects()
+	if err != nil {
+		t.Fatalf("HistoricalObjects() error = %v", err)
+	}
+	if len(got) != 2 || got[1].Tag != 0x7 || !reflect.DeepEqual(got[1].Value, decodeHex(t, "80 21 40")) {
+		t.Errorf("HistoricalObjects() = %v", got)
+	}
+}
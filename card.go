@@ -9,7 +9,9 @@ projects on which it is based:
 package smartcard
 
 import (
+	"context"
 	"errors"
+	"fmt"
 )
 
 // ICard Interface
@@ -27,6 +29,44 @@ type ICard interface {
 	EndTransactionResetCard() error
 }
 
+// ICardContext Interface to cards with cancelable APDU commands
+type ICardContext interface {
+	ICard
+	ApduContext(ctx context.Context, apdu []byte) ([]byte, error)
+}
+
+// ApduContext send the command to the card with the ctx deadline and
+// cancellation. If the card doesn't implement ICardContext, the function
+// returns when ctx is done but the command is left running in the background.
+func ApduContext(ctx context.Context, card ICard, apdu []byte) ([]byte, error) {
+	if c, ok := card.(ICardContext); ok {
+		return c.ApduContext(ctx, apdu)
+	}
+	if ctx.Done() == nil {
+		return card.Apdu(apdu)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("apdu canceled, %w, %w", err, ErrComm)
+	}
+
+	type result struct {
+		resp []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := card.Apdu(apdu)
+		ch <- result{resp, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.resp, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("apdu canceled, %w, %w", ctx.Err(), ErrComm)
+	}
+}
+
 var ErrComm = Error(errors.New("error communication"))
 var ErrSecurity = Error(errors.New("error security"))
 var ErrNoSmartcard = Error(errors.New("error no smartcard"))
@@ -10,9 +10,11 @@ projects on which it is based:
 package smartcard
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestError(t *testing.T) {
@@ -30,3 +32,31 @@ func TestError(t *testing.T) {
 		}
 	}
 }
+
+type slowCard struct {
+	ICard
+	delay time.Duration
+}
+
+func (c *slowCard) Apdu(apdu []byte) ([]byte, error) {
+	time.Sleep(c.delay)
+	return []byte{0x90, 0x00}, nil
+}
+
+func TestApduContext(t *testing.T) {
+	card := &slowCard{delay: 200 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := ApduContext(ctx, card, []byte{0x00}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ApduContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	resp, err := ApduContext(context.Background(), card, []byte{0x00})
+	if err != nil {
+		t.Fatalf("ApduContext() error = %v", err)
+	}
+	if len(resp) != 2 || resp[0] != 0x90 {
+		t.Errorf("ApduContext() = [% X], want [90 00]", resp)
+	}
+}
@@ -3,6 +3,7 @@ package clrc633
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"github.com/dumacp/smartcard"
 )
@@ -39,20 +40,25 @@ func (c *Card) blockNumber() byte {
 }
 
 func (c *Card) Apdu(apdu []byte) ([]byte, error) {
+	response, _, err := c.ApduTimed(apdu)
+	return response, err
+}
+
+// ApduTimed send apdu to the card and return the round trip time of the
+// frames in the device
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
 	if c.typeTag == TAG_TCL {
 		cmd := make([]byte, 0)
 		cmd = append(cmd, c.blockNumber())
 
 		cmd = append(cmd, apdu...)
 
-		fmt.Printf("APDU: [% X]\n", apdu)
-		response, err := c.reader.Transceive(cmd)
+		response, elapsed, err := c.reader.transceiveTimed(cmd)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		fmt.Printf("RESPONSE: [% X]\n", response)
 		if response == nil || len(response) < 1 {
-			return nil, smartcard.Error(fmt.Errorf("respuesta con error: [% X] ", response))
+			return nil, 0, smartcard.Error(fmt.Errorf("respuesta con error: [% X] ", response))
 		}
 
 		if (response[0] & 0x10) == 0x10 {
@@ -61,19 +67,34 @@ func (c *Card) Apdu(apdu []byte) ([]byte, error) {
 			for (response[0] & 0x10) == 0x10 {
 				c.blocknumber = response[0]
 				frame := []byte{byte(0xA0 + c.blockNumber())}
-				response, err = c.reader.Transceive(frame)
+				var elapsedFrame time.Duration
+				response, elapsedFrame, err = c.reader.transceiveTimed(frame)
 				if err != nil {
-					return nil, err
+					return nil, 0, err
 				}
+				elapsed += elapsedFrame
 				listResponse = append(listResponse, response[2:]...)
 			}
-			return listResponse, nil
+			return listResponse, elapsed, nil
 		}
 		c.blocknumber = response[0]
 
-		return response[1:], nil
+		return response[1:], elapsed, nil
 	}
-	return c.reader.Transceive(apdu)
+	return c.reader.transceiveTimed(apdu)
+}
+
+// BeginExclusive lock the serial device to the card, the cards of the other
+// slots of the device wait until EndExclusive
+func (c *Card) BeginExclusive() error {
+	c.reader.dev.lockExclusive(c.reader)
+	return nil
+}
+
+// EndExclusive unlock the serial device
+func (c *Card) EndExclusive() error {
+	c.reader.dev.unlockExclusive(c.reader)
+	return nil
 }
 
 func (c *Card) ApduWithoutResponse(apdu []byte) ([]byte, error) {
@@ -2,6 +2,8 @@ package clrc633
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"periph.io/x/conn/v3/spi"
@@ -10,6 +12,10 @@ import (
 type Device struct {
 	portcloser spi.PortCloser
 	conn       spi.Conn
+	// excl exclusive access of a slot to the device (BeginExclusive), owner
+	// is the reader of the slot
+	owner atomic.Pointer[Reader]
+	excl  sync.Mutex
 }
 
 func NewDevice(path string) (*Device, error) {
@@ -45,6 +51,30 @@ func (d *Device) Close() error {
 	return d.portcloser.Close()
 }
 
+// lockExclusive start the exclusive access of the reader r to the device
+func (d *Device) lockExclusive(r *Reader) {
+	d.excl.Lock()
+	d.owner.Store(r)
+}
+
+// unlockExclusive end the exclusive access of the reader r to the device
+func (d *Device) unlockExclusive(r *Reader) {
+	if d.owner.CompareAndSwap(r, nil) {
+		d.excl.Unlock()
+	}
+}
+
+// waitExclusive wait until the exclusive access of the other readers ends
+// (EndExclusive) to send the frames of r, the device is released with the
+// returned function
+func (d *Device) waitExclusive(r *Reader) func() {
+	if d.owner.Load() == r {
+		return func() {}
+	}
+	d.excl.Lock()
+	return d.excl.Unlock
+}
+
 func (d *Device) Request(tagType byte, timeout time.Duration) (byte, error) {
 	return request(d.conn, tagType, timeout)
 }
@@ -66,13 +96,21 @@ func (d *Device) Select2(data []byte, timeout time.Duration) (byte, error) {
 }
 
 func (d *Device) Transceive(data []byte, timeout time.Duration) ([]byte, error) {
+	resp, _, err := d.transceiveTimed(data, timeout)
+	return resp, err
+}
+
+// transceiveTimed Transceive that returns the time from the write of the
+// frame to the read of the response
+func (d *Device) transceiveTimed(data []byte, timeout time.Duration) ([]byte, time.Duration, error) {
 	// fmt.Printf("send apdu: [% X]\n", data)
+	t0 := time.Now()
 	resp, err := sendApdu(d.conn, data, timeout)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	// fmt.Printf("response sw: [% X]\n", resp)
-	return resp, nil
+	return resp, time.Since(t0), nil
 }
 
 func (d *Device) LoadKey(key []byte, timeout time.Duration) error {
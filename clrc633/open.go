@@ -0,0 +1,19 @@
+package clrc633
+
+import (
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("clrc663", Open)
+	smartcard.Register("clrc633", Open)
+}
+
+// Open open the reader of the URI clrc663://<SPI port> (SPI0.0)
+func Open(u *smartcard.URI) (smartcard.IReader, error) {
+	dev, err := NewDevice(u.Device)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(dev, u.Option("name", u.Device)), nil
+}
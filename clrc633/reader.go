@@ -24,45 +24,62 @@ func NewReader(dev *Device, readerName string) *Reader {
 
 func (r *Reader) Transceive(apdu []byte) ([]byte, error) {
 
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Transceive(apdu, 300*time.Millisecond)
 }
 
+// transceiveTimed Transceive that returns the round trip time of the frame
+// in the device
+func (r *Reader) transceiveTimed(apdu []byte) ([]byte, time.Duration, error) {
+	defer r.dev.waitExclusive(r)()
+	return r.dev.transceiveTimed(apdu, 300*time.Millisecond)
+}
+
 func (r *Reader) Transmit(apdu []byte) ([]byte, error) {
 
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Transceive(apdu, 0)
 }
 
 func (r *Reader) Request() (byte, error) {
 
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Request(0x52, 60*time.Millisecond)
 }
 
 func (r *Reader) Anticoll() ([]byte, error) {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Anticoll(30 * time.Millisecond)
 }
 
 func (r *Reader) Anticoll2() ([]byte, error) {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Anticoll2(30 * time.Millisecond)
 }
 
 func (r *Reader) Select(data []byte) (byte, error) {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Select(data, 300*time.Millisecond)
 }
 
 func (r *Reader) Select2(data []byte) (byte, error) {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Select2(data, 30*time.Millisecond)
 }
 
 func (r *Reader) LoadKey(key []byte) error {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.LoadKey(key, 30*time.Millisecond)
 }
 
 func (r *Reader) Auth(keyType, block int, uid []byte) error {
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Auth(keyType, block, uid, 120*time.Millisecond)
 }
 
 func (r *Reader) RATS() ([]byte, error) {
 	apdu := []byte{0xE0, 0x80}
+	defer r.dev.waitExclusive(r)()
 	return r.dev.Transceive(apdu, 100*time.Millisecond)
 }
 
@@ -85,6 +102,39 @@ func (r *Reader) ConnectCard() (smartcard.ICard, error) {
 	return c, nil
 }
 
+// Poll detect a typeA card in the field with request and anticollision,
+// without RATS. Only the double size UIDs (cascade tag 0x88) are selected to
+// complete the UID.
+func (r *Reader) Poll() ([]byte, error) {
+	if _, err := r.Request(); err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	respAnticoll, err := r.Anticoll()
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	if len(respAnticoll) < 5 {
+		return nil, fmt.Errorf("poll err = bad anticollision [% X], %w", respAnticoll, smartcard.ErrNoSmartcard)
+	}
+	uid := make([]byte, 0)
+	uid = append(uid, respAnticoll[:len(respAnticoll)-1]...)
+	if respAnticoll[0] != 0x88 {
+		return uid, nil
+	}
+	if _, err := r.Select(respAnticoll); err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	respAnticoll, err = r.Anticoll2()
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	if len(respAnticoll) < 5 {
+		return nil, fmt.Errorf("poll err = bad anticollision [% X], %w", respAnticoll, smartcard.ErrNoSmartcard)
+	}
+	uid = append(uid[1:], respAnticoll[:len(respAnticoll)-1]...)
+	return uid, nil
+}
+
 // Create New Card typeA interface
 func (r *Reader) ConnectLegacyCard() (*Card, error) {
 
@@ -0,0 +1,359 @@
+/*
+package to detect the family of a card from SAK, ATS, ATR and, where needed,
+the response to GET VERSION.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package detect
+
+import (
+	"bytes"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/atr"
+)
+
+// Family card family
+type Family int
+
+const (
+	Unknown Family = iota
+	MifareClassic1K
+	MifareClassic4K
+	MifareMini
+	MifarePlus
+	MifarePlusS
+	MifarePlusX
+	MifarePlusEV1
+	MifarePlusEV2
+	MifareDesfire
+	MifareDesfireEV1
+	MifareDesfireEV2
+	MifareDesfireEV3
+	UltralightNTAG
+	SamAV2
+	SamAV3
+	ISO14443_4
+)
+
+func (f Family) String() string {
+	switch f {
+	case MifareClassic1K:
+		return "MIFARE Classic 1K"
+	case MifareClassic4K:
+		return "MIFARE Classic 4K"
+	case MifareMini:
+		return "MIFARE Mini"
+	case MifarePlus:
+		return "MIFARE Plus"
+	case MifarePlusS:
+		return "MIFARE Plus S"
+	case MifarePlusX:
+		return "MIFARE Plus X"
+	case MifarePlusEV1:
+		return "MIFARE Plus EV1"
+	case MifarePlusEV2:
+		return "MIFARE Plus EV2"
+	case MifareDesfire:
+		return "MIFARE DESFire"
+	case MifareDesfireEV1:
+		return "MIFARE DESFire EV1"
+	case MifareDesfireEV2:
+		return "MIFARE DESFire EV2"
+	case MifareDesfireEV3:
+		return "MIFARE DESFire EV3"
+	case UltralightNTAG:
+		return "MIFARE Ultralight/NTAG"
+	case SamAV2:
+		return "SAM AV2"
+	case SamAV3:
+		return "SAM AV3"
+	case ISO14443_4:
+		return "ISO 14443-4"
+	}
+	return "unknown"
+}
+
+// IsPlus the family is MIFARE Plus
+func (f Family) IsPlus() bool {
+	return f >= MifarePlus && f <= MifarePlusEV2
+}
+
+// IsDesfire the family is MIFARE DESFire
+func (f Family) IsDesfire() bool {
+	return f >= MifareDesfire && f <= MifareDesfireEV3
+}
+
+// SecurityLevel security level of MIFARE Plus cards
+type SecurityLevel int
+
+const (
+	// SLUnknown security level unknown or not applicable
+	SLUnknown SecurityLevel = -1
+	SL0       SecurityLevel = 0
+	SL1       SecurityLevel = 1
+	SL2       SecurityLevel = 2
+	SL3       SecurityLevel = 3
+)
+
+// Info result of the detection
+type Info struct {
+	Family Family
+	// SL security level (MIFARE Plus only)
+	SL SecurityLevel
+	// Version hardware version of the GET VERSION response (if it was sent)
+	Version []byte
+}
+
+// Detect detect the family of the card. The MIFARE Plus, DESFire and SAM
+// are probed with GET VERSION (wrapped in ISO 7816-4 if the card is
+// detected from the PC/SC ATR, and the MIFARE Plus in SL0/SL3 with a first
+// authenticate part 1 too, aborted with RESET AUTH), the other families are detected from the SAK,
+// ATS and ATR without commands to the card. An error is only returned if
+// the communication with the card fails.
+func Detect(card smartcard.ICard) (*Info, error) {
+	info := &Info{
+		Family: Unknown,
+		SL:     SLUnknown,
+	}
+
+	var parsedATR *atr.ATR
+	if data, err := card.ATR(); err == nil && len(data) > 0 {
+		parsedATR, _ = atr.Parse(data)
+	}
+	if parsedATR != nil && parsedATR.Identify() == atr.SAM {
+		return detectSam(card, info)
+	}
+
+	sak := card.SAK()
+	if sak == 0xFF && parsedATR != nil {
+		switch parsedATR.Identify() {
+		case atr.MifareClassic1K:
+			info.Family = MifareClassic1K
+		case atr.MifareClassic4K:
+			info.Family = MifareClassic4K
+		case atr.MifareMini:
+			info.Family = MifareMini
+		case atr.MifareUltralight, atr.MifareUltralightC:
+			info.Family = UltralightNTAG
+		case atr.MifarePlusSL1:
+			info.Family = MifarePlus
+			info.SL = SL1
+		case atr.MifarePlusSL2:
+			info.Family = MifarePlus
+			info.SL = SL2
+		case atr.MifareDesfire, atr.MifarePlus, atr.ISO14443_4:
+			return detectISO14443_4(card, info, parsedATR.Historical, true)
+		}
+		return info, nil
+	}
+
+	switch sak {
+	case 0x00:
+		info.Family = UltralightNTAG
+	case 0x09:
+		info.Family = MifareMini
+	case 0x08, 0x88:
+		info.Family = MifareClassic1K
+	case 0x18:
+		info.Family = MifareClassic4K
+	case 0x10, 0x11:
+		info.Family = MifarePlus
+		info.SL = SL2
+	default:
+		if sak&0x20 == 0 {
+			return info, nil
+		}
+		historical := atsHistorical(card)
+		if len(historical) <= 0 && parsedATR != nil {
+			historical = parsedATR.Historical
+		}
+		if sak&0x08 != 0 {
+			// ISO 14443-4 with MIFARE Classic emulation (MIFARE Plus in SL1)
+			if isPlusHistorical(historical) {
+				info.SL = SL1
+				return detectPlus(card, info, historical, false, false)
+			}
+			if sak&0x10 != 0 {
+				info.Family = MifareClassic4K
+			} else {
+				info.Family = MifareClassic1K
+			}
+			return info, nil
+		}
+		return detectISO14443_4(card, info, historical, false)
+	}
+	return info, nil
+}
+
+// atsHistorical historical bytes of the ATS. The readers return the complete
+// ATS or only the historical bytes (PC/SC GET DATA), some with SW1 SW2.
+func atsHistorical(card smartcard.ICard) []byte {
+	ats, err := card.ATS()
+	if err != nil || len(ats) <= 0 {
+		return nil
+	}
+	if len(ats) >= 2 && ats[len(ats)-2] == 0x90 && ats[len(ats)-1] == 0x00 {
+		ats = ats[:len(ats)-2]
+	}
+	if len(ats) < 2 || int(ats[0]) != len(ats) {
+		return ats
+	}
+	// TL T0 [TA] [TB] [TC] historical
+	t0 := ats[1]
+	idx := 2
+	for _, bit := range []byte{0x10, 0x20, 0x40} {
+		if t0&bit != 0 {
+			idx++
+		}
+	}
+	if idx > len(ats) {
+		return nil
+	}
+	return ats[idx:]
+}
+
+func isPlusHistorical(historical []byte) bool {
+	return bytes.HasPrefix(historical, []byte{0xC1, 0x05, 0x2F, 0x2F}) ||
+		bytes.HasPrefix(historical, []byte{0xC1, 0x05, 0x21, 0x30})
+}
+
+func detectISO14443_4(card smartcard.ICard, info *Info, historical []byte, wrapped bool) (*Info, error) {
+	switch {
+	case isPlusHistorical(historical):
+		return detectPlus(card, info, historical, true, wrapped)
+	case bytes.Equal(historical, []byte{0x80}):
+		return detectDesfire(card, info, wrapped)
+	}
+	info.Family = ISO14443_4
+	return info, nil
+}
+
+// getVersion send the native command GET VERSION (0x60) and read the rest of
+// the frames (0xAF) to leave the card in a known state. It returns the
+// hardware version or nil if the card doesn't support the command. With
+// wrapped (PC/SC readers) the commands are wrapped in ISO 7816-4.
+func getVersion(card smartcard.ICard, wrapped bool) ([]byte, error) {
+	send := func(cmd byte) ([]byte, error) {
+		if !wrapped {
+			return card.Apdu([]byte{cmd})
+		}
+		resp, err := card.Apdu([]byte{0x90, cmd, 0x00, 0x00, 0x00})
+		if err != nil {
+			return nil, err
+		}
+		// Data || 91 Status -> Status || Data
+		if len(resp) < 2 || resp[len(resp)-2] != 0x91 {
+			return nil, nil
+		}
+		return append([]byte{resp[len(resp)-1]}, resp[:len(resp)-2]...), nil
+	}
+
+	resp, err := send(0x60)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 8 || resp[0] != 0xAF {
+		return nil, nil
+	}
+	hw := append([]byte{}, resp[1:8]...)
+	for i := 0; i < 2 && len(resp) > 0 && resp[0] == 0xAF; i++ {
+		resp, err = send(0xAF)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hw, nil
+}
+
+func detectDesfire(card smartcard.ICard, info *Info, wrapped bool) (*Info, error) {
+	info.Family = MifareDesfire
+	hw, err := getVersion(card, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if hw == nil {
+		return info, nil
+	}
+	info.Version = hw
+	// vendor, type, subtype, major, minor, storage, protocol
+	if hw[1] != 0x01 {
+		return info, nil
+	}
+	switch hw[3] {
+	case 0x01:
+		info.Family = MifareDesfireEV1
+	case 0x12, 0x22:
+		info.Family = MifareDesfireEV2
+	case 0x33:
+		info.Family = MifareDesfireEV3
+	}
+	return info, nil
+}
+
+func detectPlus(card smartcard.ICard, info *Info, historical []byte, probeSL, wrapped bool) (*Info, error) {
+	info.Family = MifarePlus
+	// C1 05 2F 2F 0x: x = 0 MIFARE Plus S, x = 1 MIFARE Plus X
+	if bytes.HasPrefix(historical, []byte{0xC1, 0x05, 0x2F, 0x2F}) && len(historical) > 4 {
+		switch historical[4] & 0x0F {
+		case 0x00:
+			info.Family = MifarePlusS
+		case 0x01:
+			info.Family = MifarePlusX
+		}
+	}
+	if bytes.HasPrefix(historical, []byte{0xC1, 0x05, 0x21, 0x30}) {
+		hw, err := getVersion(card, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if hw != nil {
+			info.Version = hw
+			if hw[1] == 0x02 {
+				switch hw[3] {
+				case 0x11:
+					info.Family = MifarePlusEV1
+				case 0x22:
+					info.Family = MifarePlusEV2
+				}
+			}
+		}
+	}
+	if !probeSL {
+		return info, nil
+	}
+	// in SL0 the card only accepts WritePerso and CommitPerso
+	resp, err := card.Apdu([]byte{0x70, 0x00, 0x90, 0x00})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) <= 0 || resp[0] != 0x90 {
+		info.SL = SL0
+		return info, nil
+	}
+	info.SL = SL3
+	// abort the first authenticate started in SL3 (RESET AUTH)
+	if _, err := card.Apdu([]byte{0x78}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func detectSam(card smartcard.ICard, info *Info) (*Info, error) {
+	resp, err := card.Apdu([]byte{0x80, 0x60, 0x00, 0x00, 0x00})
+	if err != nil {
+		return nil, err
+	}
+	info.Family = SamAV2
+	if len(resp) < 33 || resp[len(resp)-2] != 0x90 || resp[len(resp)-1] != 0x00 {
+		return info, nil
+	}
+	info.Version = append([]byte{}, resp[:7]...)
+	// last byte of the version: mode of the SAM (0xA3: AV3)
+	if resp[len(resp)-3] == 0xA3 {
+		info.Family = SamAV3
+	}
+	return info, nil
+}
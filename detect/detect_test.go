@@ -0,0 +1,135 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+func TestDetect(t *testing.T) {
+	samVersion := append([]byte{0x04, 0x01, 0x01, 0x03, 0x04, 0x1A, 0x01,
+		0x04, 0x01, 0x01, 0x03, 0x04, 0x1A, 0x01,
+		0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xA3}, 0x90, 0x00)
+	tests := []struct {
+		name   string
+		card   func() *sim.Card
+		want   Family
+		wantSL SecurityLevel
+	}{
+		{
+			name: "classic 1K",
+			card: func() *sim.Card { return sim.NewCard(nil, nil, nil, 0x08) },
+			want: MifareClassic1K, wantSL: SLUnknown,
+		},
+		{
+			name: "classic 4K",
+			card: func() *sim.Card { return sim.NewCard(nil, nil, nil, 0x18) },
+			want: MifareClassic4K, wantSL: SLUnknown,
+		},
+		{
+			name: "ultralight",
+			card: func() *sim.Card { return sim.NewCard(nil, nil, nil, 0x00) },
+			want: UltralightNTAG, wantSL: SLUnknown,
+		},
+		{
+			name: "classic 4K from PC/SC ATR",
+			card: func() *sim.Card {
+				return sim.NewCard([]byte{0x3B, 0x8F, 0x80, 0x01, 0x80, 0x4F, 0x0C, 0xA0, 0x00, 0x00,
+					0x03, 0x06, 0x03, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x69}, nil, nil, 0xFF)
+			},
+			want: MifareClassic4K, wantSL: SLUnknown,
+		},
+		{
+			name: "desfire EV2",
+			card: func() *sim.Card {
+				c := sim.NewCard(nil, nil, []byte{0x06, 0x75, 0x77, 0x81, 0x02, 0x80}, 0x20)
+				c.Expect([]byte{0x60}, []byte{0xAF, 0x04, 0x01, 0x01, 0x12, 0x00, 0x1A, 0x05})
+				c.Expect([]byte{0xAF}, []byte{0xAF, 0x04, 0x01, 0x01, 0x02, 0x01, 0x1A, 0x05})
+				c.Expect([]byte{0xAF}, []byte{0x00, 0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66,
+					0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+				return c
+			},
+			want: MifareDesfireEV2, wantSL: SLUnknown,
+		},
+		{
+			name: "desfire EV3 from PC/SC ATR",
+			card: func() *sim.Card {
+				c := sim.NewCard([]byte{0x3B, 0x81, 0x80, 0x01, 0x80, 0x80}, nil, nil, 0xFF)
+				c.Expect([]byte{0x90, 0x60, 0x00, 0x00, 0x00}, []byte{0x04, 0x01, 0x01, 0x33, 0x00, 0x1A, 0x05, 0x91, 0xAF})
+				c.Expect([]byte{0x90, 0xAF, 0x00, 0x00, 0x00}, []byte{0x04, 0x01, 0x01, 0x03, 0x00, 0x1A, 0x05, 0x91, 0xAF})
+				c.Expect([]byte{0x90, 0xAF, 0x00, 0x00, 0x00}, []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66,
+					0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x91, 0x00})
+				return c
+			},
+			want: MifareDesfireEV3, wantSL: SLUnknown,
+		},
+		{
+			name: "plus X SL3",
+			card: func() *sim.Card {
+				c := sim.NewCard(nil, nil, []byte{0x0C, 0x75, 0x77, 0x80, 0x02,
+					0xC1, 0x05, 0x2F, 0x2F, 0x01, 0xBC, 0xD6}, 0x20)
+				c.Expect([]byte{0x70, 0x00, 0x90, 0x00}, append([]byte{0x90}, make([]byte, 16)...))
+				c.Expect([]byte{0x78}, []byte{0x90})
+				return c
+			},
+			want: MifarePlusX, wantSL: SL3,
+		},
+		{
+			name: "plus EV1 SL0",
+			card: func() *sim.Card {
+				c := sim.NewCard(nil, nil, []byte{0x0C, 0x75, 0x77, 0x80, 0x02,
+					0xC1, 0x05, 0x21, 0x30, 0x00, 0x77, 0xC1}, 0x20)
+				c.Expect([]byte{0x60}, []byte{0xAF, 0x04, 0x02, 0x01, 0x11, 0x00, 0x18, 0x05})
+				c.Expect([]byte{0xAF}, []byte{0xAF, 0x04, 0x02, 0x01, 0x01, 0x00, 0x18, 0x05})
+				c.Expect([]byte{0xAF}, []byte{0x90})
+				c.Expect([]byte{0x70, 0x00, 0x90, 0x00}, []byte{0x0B})
+				return c
+			},
+			want: MifarePlusEV1, wantSL: SL0,
+		},
+		{
+			name: "plus S SL1 with ISO 14443-4",
+			card: func() *sim.Card {
+				return sim.NewCard(nil, nil, []byte{0xC1, 0x05, 0x2F, 0x2F, 0x00, 0x35, 0xC7, 0x90, 0x00}, 0x28)
+			},
+			want: MifarePlusS, wantSL: SL1,
+		},
+		{
+			name: "sam AV3",
+			card: func() *sim.Card {
+				c := sim.NewCard([]byte{0x3B, 0xDF, 0x18, 0xFF, 0x81, 0xF1, 0xFE, 0x43, 0x00, 0x3F,
+					0x03, 0x83, 0x4D, 0x49, 0x46, 0x41, 0x52, 0x45, 0x20, 0x50, 0x6C, 0x75, 0x73,
+					0x20, 0x53, 0x41, 0x4D, 0x3B}, nil, nil, 0xFF)
+				c.Expect([]byte{0x80, 0x60, 0x00, 0x00, 0x00}, samVersion)
+				return c
+			},
+			want: SamAV3, wantSL: SLUnknown,
+		},
+		{
+			name: "generic ISO 14443-4",
+			card: func() *sim.Card {
+				return sim.NewCard(nil, nil, []byte{0x05, 0x78, 0x80, 0x70, 0x02}, 0x20)
+			},
+			want: ISO14443_4, wantSL: SLUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := tt.card()
+			got, err := Detect(card)
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			if got.Family != tt.want {
+				t.Errorf("Detect().Family = %v, want %v", got.Family, tt.want)
+			}
+			if got.SL != tt.wantSL {
+				t.Errorf("Detect().SL = %v, want %v", got.SL, tt.wantSL)
+			}
+			if card.Pending() != 0 {
+				t.Errorf("Pending() = %d, want 0", card.Pending())
+			}
+		})
+	}
+}
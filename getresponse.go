@@ -0,0 +1,133 @@
+package smartcard
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxGetResponse max number of GET RESPONSE commands in a 61xx chain
+const maxGetResponse = 256
+
+// GetResponseCard ICard middleware that follows the 61xx chains with GET
+// RESPONSE and re-issues the commands answered with 6Cxx with the right Le,
+// returning the full payload with the last status word.
+type GetResponseCard struct {
+	ICard
+	// CLA class byte of the GET RESPONSE command (default 0x00)
+	CLA byte
+}
+
+// NewGetResponseCard wrap the card with the GET RESPONSE handling
+func NewGetResponseCard(card ICard) *GetResponseCard {
+	return &GetResponseCard{
+		ICard: card,
+	}
+}
+
+// Apdu send the command and handle the 61xx and 6Cxx status words
+func (c *GetResponseCard) Apdu(apdu []byte) ([]byte, error) {
+	return c.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext send the command with ctx and handle the 61xx and 6Cxx status words
+func (c *GetResponseCard) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	resp, err := c.apduWrongLe(ctx, apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0)
+	for i := 0; ; i++ {
+		if len(resp) < 2 || resp[len(resp)-2] != 0x61 {
+			data = append(data, resp...)
+			return data, nil
+		}
+		if i >= maxGetResponse {
+			return nil, fmt.Errorf("too many GET RESPONSE commands, %w", ErrComm)
+		}
+		data = append(data, resp[:len(resp)-2]...)
+		getResponse := []byte{c.CLA, 0xC0, 0x00, 0x00, resp[len(resp)-1]}
+		resp, err = c.apduWrongLe(ctx, getResponse)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// RedactNext forward the sensitive bytes to the wrapped card
+func (c *GetResponseCard) RedactNext(command, response []Span) {
+	RedactNext(c.ICard, command, response)
+}
+
+// BeginExclusive forward the exclusive access to the wrapped card
+func (c *GetResponseCard) BeginExclusive() error {
+	return BeginExclusive(c.ICard)
+}
+
+// EndExclusive forward the end of the exclusive access to the wrapped card
+func (c *GetResponseCard) EndExclusive() error {
+	return EndExclusive(c.ICard)
+}
+
+// apduWrongLe send the command and re-issue it once if the response is 6Cxx.
+// The 6Cxx response is returned if the command can't be re-issued with the
+// right Le (not an ISO 7816-4 command, e.g. a native DESFire command).
+func (c *GetResponseCard) apduWrongLe(ctx context.Context, apdu []byte) ([]byte, error) {
+	resp, err := ApduContext(ctx, c.ICard, apdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) != 2 || resp[0] != 0x6C {
+		return resp, nil
+	}
+	cmd, err := ParseCommand(apdu)
+	if err != nil {
+		return resp, nil
+	}
+	cmd.Ne = leToNe(int(resp[1]), false)
+	retry, err := cmd.Bytes()
+	if err != nil {
+		return resp, nil
+	}
+	return ApduContext(ctx, c.ICard, retry)
+}
+
+// GetResponseReader IReader middleware that wraps the connected cards with
+// GetResponseCard
+type GetResponseReader struct {
+	IReader
+}
+
+// NewGetResponseReader wrap the reader with the GET RESPONSE handling
+func NewGetResponseReader(r IReader) *GetResponseReader {
+	return &GetResponseReader{
+		IReader: r,
+	}
+}
+
+func wrapGetResponse(c ICard, err error) (ICard, error) {
+	if err != nil {
+		return nil, err
+	}
+	return NewGetResponseCard(c), nil
+}
+
+// ConnectCard connect card and wrap it with GetResponseCard
+func (r *GetResponseReader) ConnectCard() (ICard, error) {
+	return wrapGetResponse(r.IReader.ConnectCard())
+}
+
+// ConnectSamCard connect card and wrap it with GetResponseCard
+func (r *GetResponseReader) ConnectSamCard() (ICard, error) {
+	return wrapGetResponse(r.IReader.ConnectSamCard())
+}
+
+// ConnectSamCard_T0 connect card and wrap it with GetResponseCard
+func (r *GetResponseReader) ConnectSamCard_T0() (ICard, error) {
+	return wrapGetResponse(r.IReader.ConnectSamCard_T0())
+}
+
+// ConnectSamCard_Tany connect card and wrap it with GetResponseCard
+func (r *GetResponseReader) ConnectSamCard_Tany() (ICard, error) {
+	return wrapGetResponse(r.IReader.ConnectSamCard_Tany())
+}
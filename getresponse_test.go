@@ -0,0 +1,112 @@
+package smartcard
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// scriptCard answer the APDUs from a map (hex command -> response)
+type scriptCard struct {
+	ICard
+	responses map[string][]byte
+	sent      [][]byte
+}
+
+func (c *scriptCard) Apdu(apdu []byte) ([]byte, error) {
+	c.sent = append(c.sent, apdu)
+	resp, ok := c.responses[hex.EncodeToString(apdu)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected apdu [% X]", apdu)
+	}
+	return resp, nil
+}
+
+func TestGetResponseCard_Apdu(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string][]byte
+		apdu      []byte
+		want      []byte
+		wantSent  int
+		wantErr   bool
+	}{
+		{
+			name: "without chain",
+			responses: map[string][]byte{
+				"00a4040c02a000": {0x90, 0x00},
+			},
+			apdu:     []byte{0x00, 0xA4, 0x04, 0x0C, 0x02, 0xA0, 0x00},
+			want:     []byte{0x90, 0x00},
+			wantSent: 1,
+		},
+		{
+			name: "61xx chain",
+			responses: map[string][]byte{
+				"00c400000101": {0x61, 0x04},
+				"00c0000004":   {0x01, 0x02, 0x03, 0x04, 0x61, 0x02},
+				"00c0000002":   {0x05, 0x06, 0x90, 0x00},
+			},
+			apdu:     []byte{0x00, 0xC4, 0x00, 0x00, 0x01, 0x01},
+			want:     []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x90, 0x00},
+			wantSent: 3,
+		},
+		{
+			name: "6Cxx wrong Le",
+			responses: map[string][]byte{
+				"00b0000000": {0x6C, 0x03},
+				"00b0000003": {0x0A, 0x0B, 0x0C, 0x90, 0x00},
+			},
+			apdu:     []byte{0x00, 0xB0, 0x00, 0x00, 0x00},
+			want:     []byte{0x0A, 0x0B, 0x0C, 0x90, 0x00},
+			wantSent: 2,
+		},
+		{
+			name: "6Cxx of a native command",
+			responses: map[string][]byte{
+				"5a": {0x6C, 0x03},
+			},
+			apdu:     []byte{0x5A},
+			want:     []byte{0x6C, 0x03},
+			wantSent: 1,
+		},
+		{
+			name: "61xx and 6Cxx in GET RESPONSE",
+			responses: map[string][]byte{
+				"0084000008": {0x61, 0x10},
+				"00c0000010": {0x6C, 0x08},
+				"00c0000008": {1, 2, 3, 4, 5, 6, 7, 8, 0x90, 0x00},
+			},
+			apdu:     []byte{0x00, 0x84, 0x00, 0x00, 0x08},
+			want:     []byte{1, 2, 3, 4, 5, 6, 7, 8, 0x90, 0x00},
+			wantSent: 3,
+		},
+		{
+			name: "error in GET RESPONSE",
+			responses: map[string][]byte{
+				"0084000008": {0x61, 0x08},
+			},
+			apdu:     []byte{0x00, 0x84, 0x00, 0x00, 0x08},
+			wantSent: 2,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := &scriptCard{responses: tt.responses}
+			c := NewGetResponseCard(card)
+			got, err := c.Apdu(tt.apdu)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apdu() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apdu() = [% X], want [% X]", got, tt.want)
+			}
+			if len(card.sent) != tt.wantSent {
+				t.Errorf("sent %d apdus, want %d", len(card.sent), tt.wantSent)
+			}
+		})
+	}
+}
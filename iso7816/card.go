@@ -0,0 +1,298 @@
+/*
+package to use the file system and the security commands of ISO 7816-4
+cards (SELECT, READ/UPDATE BINARY, READ/APPEND/UPDATE RECORD, GET CHALLENGE,
+INTERNAL and EXTERNAL AUTHENTICATE) over any smartcard.ICard.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package iso7816
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dumacp/smartcard"
+)
+
+// SelectResponse data requested in the response to SELECT (P2)
+type SelectResponse byte
+
+const (
+	// ReturnFCI return the file control information (template 6F)
+	ReturnFCI SelectResponse = 0x00
+	// ReturnFCP return the file control parameters (template 62)
+	ReturnFCP SelectResponse = 0x04
+	// ReturnFMD return the file management data (template 64)
+	ReturnFMD SelectResponse = 0x08
+	// ReturnNone no response data
+	ReturnNone SelectResponse = 0x0C
+)
+
+const (
+	// maxOffset max offset of READ/UPDATE BINARY with the offset in P1-P2
+	maxOffset = 0x7FFF
+	// maxSFI max short EF identifier
+	maxSFI = 30
+)
+
+// Card ISO 7816-4 client of the card. The commands are sent with the GET
+// RESPONSE handling (61xx and 6Cxx) of smartcard.GetResponseCard.
+type Card struct {
+	smartcard.ICard
+	// CLA class byte of the commands (default 0x00)
+	CLA byte
+}
+
+// NewCard create the ISO 7816-4 client of card
+func NewCard(card smartcard.ICard) *Card {
+	if _, ok := card.(*smartcard.GetResponseCard); !ok {
+		card = smartcard.NewGetResponseCard(card)
+	}
+	return &Card{
+		ICard: card,
+	}
+}
+
+// Transmit send the command with the CLA of the card. The data of the
+// response is returned with the *nxp.ErrorResponse of the status word if it isn't
+// 9000 (the card can return data with a warning, e.g. 6282).
+func (c *Card) Transmit(cmd *smartcard.Command) ([]byte, error) {
+	cmd.CLA = c.CLA
+	resp, err := smartcard.Transmit(c.ICard, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := NewStatusError(resp.SW()); err != nil {
+		return resp.Data, err
+	}
+	return resp.Data, nil
+}
+
+// Select send SELECT with the selection mode p1 and parse the response (nil
+// FileInfo if the card doesn't return data)
+func (c *Card) Select(p1 byte, data []byte, p2 SelectResponse) (*FileInfo, error) {
+	cmd := &smartcard.Command{INS: 0xA4, P1: p1, P2: byte(p2), Data: data}
+	if p2 != ReturnNone {
+		cmd.Ne = smartcard.MaxShortNe
+	}
+	resp, err := c.Transmit(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) <= 0 {
+		return nil, nil
+	}
+	return ParseFileInfo(resp)
+}
+
+// SelectAID select the application (DF name) aid
+func (c *Card) SelectAID(aid []byte, p2 SelectResponse) (*FileInfo, error) {
+	if len(aid) <= 0 || len(aid) > 16 {
+		return nil, fmt.Errorf("AID length %d, %w", len(aid), ErrInvalidParameter)
+	}
+	return c.Select(0x04, aid, p2)
+}
+
+// SelectFID select the MF, DF or EF with the file identifier fid
+func (c *Card) SelectFID(fid uint16, p2 SelectResponse) (*FileInfo, error) {
+	return c.Select(0x00, []byte{byte(fid >> 8), byte(fid)}, p2)
+}
+
+// SelectMF select the master file (3F00)
+func (c *Card) SelectMF(p2 SelectResponse) (*FileInfo, error) {
+	return c.SelectFID(0x3F00, p2)
+}
+
+// SelectParent select the parent DF of the current DF
+func (c *Card) SelectParent(p2 SelectResponse) (*FileInfo, error) {
+	return c.Select(0x03, nil, p2)
+}
+
+// SelectPath select the file with the path of file identifiers, from the
+// MF (without 3F00) if fromMF or else from the current DF
+func (c *Card) SelectPath(path []uint16, fromMF bool, p2 SelectResponse) (*FileInfo, error) {
+	if len(path) <= 0 {
+		return nil, fmt.Errorf("empty path, %w", ErrInvalidParameter)
+	}
+	data := make([]byte, 0, 2*len(path))
+	for _, fid := range path {
+		data = append(data, byte(fid>>8), byte(fid))
+	}
+	p1 := byte(0x09)
+	if fromMF {
+		p1 = 0x08
+	}
+	return c.Select(p1, data, p2)
+}
+
+// ReadBinary read length bytes of the current EF from offset. If length <= 0
+// the EF is read to the end. If the EF ends before length bytes, the data
+// read is returned with ErrEndOfFile.
+func (c *Card) ReadBinary(offset, length int) ([]byte, error) {
+	if offset < 0 || offset > maxOffset {
+		return nil, fmt.Errorf("offset %d, %w", offset, ErrInvalidParameter)
+	}
+	return c.readBinary(offset, length, func(pos int) (byte, byte, error) {
+		if pos > maxOffset {
+			return 0, 0, fmt.Errorf("offset %d, %w", pos, ErrInvalidParameter)
+		}
+		return byte(pos >> 8), byte(pos), nil
+	})
+}
+
+// ReadBinarySFI select the EF with the short identifier sfi and read it
+// like ReadBinary. The offset of the first command is limited to 255.
+func (c *Card) ReadBinarySFI(sfi byte, offset, length int) ([]byte, error) {
+	if sfi == 0 || sfi > maxSFI {
+		return nil, fmt.Errorf("SFI %d, %w", sfi, ErrInvalidParameter)
+	}
+	if offset < 0 || offset > 0xFF {
+		return nil, fmt.Errorf("offset %d with SFI, %w", offset, ErrInvalidParameter)
+	}
+	return c.readBinary(offset, length, func(pos int) (byte, byte, error) {
+		if pos == offset {
+			return 0x80 | sfi, byte(pos), nil
+		}
+		if pos > maxOffset {
+			return 0, 0, fmt.Errorf("offset %d, %w", pos, ErrInvalidParameter)
+		}
+		return byte(pos >> 8), byte(pos), nil
+	})
+}
+
+func (c *Card) readBinary(offset, length int, p1p2 func(pos int) (byte, byte, error)) ([]byte, error) {
+	data := make([]byte, 0)
+	for length <= 0 || len(data) < length {
+		p1, p2, err := p1p2(offset + len(data))
+		if err != nil {
+			return data, err
+		}
+		ne := smartcard.MaxShortNe
+		if length > 0 && length-len(data) < ne {
+			ne = length - len(data)
+		}
+		resp, err := c.Transmit(&smartcard.Command{INS: 0xB0, P1: p1, P2: p2, Ne: ne})
+		data = append(data, resp...)
+		if err != nil {
+			// the offset is the end of the EF
+			if length <= 0 && (errors.Is(err, ErrEndOfFile) ||
+				(errors.Is(err, ErrWrongParameters) && len(data) > 0)) {
+				return data, nil
+			}
+			return data, err
+		}
+		if len(resp) < ne {
+			if length <= 0 {
+				return data, nil
+			}
+			return data, fmt.Errorf("read %d of %d bytes, %w", len(data), length, ErrEndOfFile)
+		}
+	}
+	return data, nil
+}
+
+// UpdateBinary write data in the current EF from offset, with as many
+// commands as needed
+func (c *Card) UpdateBinary(offset int, data []byte) error {
+	for pos := 0; pos < len(data); pos += smartcard.MaxShortNc {
+		if offset+pos < 0 || offset+pos > maxOffset {
+			return fmt.Errorf("offset %d, %w", offset+pos, ErrInvalidParameter)
+		}
+		end := pos + smartcard.MaxShortNc
+		if end > len(data) {
+			end = len(data)
+		}
+		cmd := &smartcard.Command{
+			INS:  0xD6,
+			P1:   byte((offset + pos) >> 8),
+			P2:   byte(offset + pos),
+			Data: data[pos:end],
+		}
+		if _, err := c.Transmit(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordP2(sfi byte, mode byte) (byte, error) {
+	if sfi > maxSFI {
+		return 0, fmt.Errorf("SFI %d, %w", sfi, ErrInvalidParameter)
+	}
+	return sfi<<3 | mode, nil
+}
+
+func checkRecord(record int) error {
+	if record <= 0 || record > 0xFE {
+		return fmt.Errorf("record number %d, %w", record, ErrInvalidParameter)
+	}
+	return nil
+}
+
+// ReadRecord read the record number record of the EF sfi (0: current EF)
+func (c *Card) ReadRecord(sfi byte, record int) ([]byte, error) {
+	if err := checkRecord(record); err != nil {
+		return nil, err
+	}
+	p2, err := recordP2(sfi, 0x04)
+	if err != nil {
+		return nil, err
+	}
+	return c.Transmit(&smartcard.Command{INS: 0xB2, P1: byte(record), P2: p2, Ne: smartcard.MaxShortNe})
+}
+
+// UpdateRecord overwrite the record number record of the EF sfi (0: current EF)
+func (c *Card) UpdateRecord(sfi byte, record int, data []byte) error {
+	if err := checkRecord(record); err != nil {
+		return err
+	}
+	p2, err := recordP2(sfi, 0x04)
+	if err != nil {
+		return err
+	}
+	_, err = c.Transmit(&smartcard.Command{INS: 0xDC, P1: byte(record), P2: p2, Data: data})
+	return err
+}
+
+// AppendRecord add a record at the end of the EF sfi (0: current EF)
+func (c *Card) AppendRecord(sfi byte, data []byte) error {
+	p2, err := recordP2(sfi, 0x00)
+	if err != nil {
+		return err
+	}
+	_, err = c.Transmit(&smartcard.Command{INS: 0xE2, P1: 0x00, P2: p2, Data: data})
+	return err
+}
+
+// GetChallenge get a challenge of n bytes from the card
+func (c *Card) GetChallenge(n int) ([]byte, error) {
+	if n <= 0 || n > smartcard.MaxShortNe {
+		return nil, fmt.Errorf("challenge length %d, %w", n, ErrInvalidParameter)
+	}
+	resp, err := c.Transmit(&smartcard.Command{INS: 0x84, Ne: n})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) != n {
+		return nil, fmt.Errorf("challenge length %d, want %d, %w", len(resp), n, smartcard.ErrComm)
+	}
+	return resp, nil
+}
+
+// InternalAuthenticate send the challenge to be authenticated by the card
+// with the algorithm alg and the key reference ref, and return the response
+func (c *Card) InternalAuthenticate(alg, ref byte, challenge []byte) ([]byte, error) {
+	cmd := &smartcard.Command{INS: 0x88, P1: alg, P2: ref, Data: challenge, Ne: smartcard.MaxShortNe}
+	return c.Transmit(cmd)
+}
+
+// ExternalAuthenticate send the cryptogram of the challenge of the card,
+// computed with the algorithm alg and the key reference ref. A failed
+// verification has the remaining tries (Retries).
+func (c *Card) ExternalAuthenticate(alg, ref byte, cryptogram []byte) error {
+	cmd := &smartcard.Command{INS: 0x82, P1: alg, P2: ref, Data: cryptogram}
+	_, err := c.Transmit(cmd)
+	return err
+}
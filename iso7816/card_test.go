@@ -0,0 +1,219 @@
+package iso7816
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+func TestCard_Select(t *testing.T) {
+	fcp := []byte{0x62, 0x0F, 0x82, 0x02, 0x01, 0x21, 0x83, 0x02, 0xE1, 0x04,
+		0x80, 0x02, 0x01, 0x00, 0x88, 0x01, 0x20}
+	tests := []struct {
+		name      string
+		exchanges []sim.Exchange
+		selectF   func(c *Card) (*FileInfo, error)
+		wantFID   []byte
+		wantNil   bool
+		wantErr   error
+	}{
+		{
+			name: "aid without response",
+			exchanges: []sim.Exchange{{
+				Command:  []byte{0x00, 0xA4, 0x04, 0x0C, 0x07, 0xD2, 0x76, 0x00, 0x00, 0x85, 0x01, 0x01},
+				Response: []byte{0x90, 0x00},
+			}},
+			selectF: func(c *Card) (*FileInfo, error) {
+				return c.SelectAID([]byte{0xD2, 0x76, 0x00, 0x00, 0x85, 0x01, 0x01}, ReturnNone)
+			},
+			wantNil: true,
+		},
+		{
+			name: "fid with FCP",
+			exchanges: []sim.Exchange{{
+				Command:  []byte{0x00, 0xA4, 0x00, 0x04, 0x02, 0xE1, 0x04, 0x00},
+				Response: append(append([]byte{}, fcp...), 0x90, 0x00),
+			}},
+			selectF: func(c *Card) (*FileInfo, error) { return c.SelectFID(0xE104, ReturnFCP) },
+			wantFID: []byte{0xE1, 0x04},
+		},
+		{
+			name: "path from MF with 61xx",
+			exchanges: []sim.Exchange{
+				{
+					Command:  []byte{0x00, 0xA4, 0x08, 0x04, 0x04, 0x50, 0x00, 0xE1, 0x04, 0x00},
+					Response: []byte{0x61, byte(len(fcp))},
+				},
+				{
+					Command:  []byte{0x00, 0xC0, 0x00, 0x00, byte(len(fcp))},
+					Response: append(append([]byte{}, fcp...), 0x90, 0x00),
+				},
+			},
+			selectF: func(c *Card) (*FileInfo, error) {
+				return c.SelectPath([]uint16{0x5000, 0xE104}, true, ReturnFCP)
+			},
+			wantFID: []byte{0xE1, 0x04},
+		},
+		{
+			name: "file not found",
+			exchanges: []sim.Exchange{{
+				Command:  []byte{0x00, 0xA4, 0x00, 0x0C, 0x02, 0x00, 0x01},
+				Response: []byte{0x6A, 0x82},
+			}},
+			selectF: func(c *Card) (*FileInfo, error) { return c.SelectFID(0x0001, ReturnNone) },
+			wantErr: ErrFileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sim.NewCard(nil, nil, nil, 0x20)
+			s.Script(tt.exchanges...)
+			got, err := tt.selectF(NewCard(s))
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Select() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.Pending() != 0 {
+				t.Errorf("Select() pending exchanges = %d", s.Pending())
+			}
+			if err != nil {
+				return
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("Select() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || !bytes.Equal(got.FileID, tt.wantFID) {
+				t.Errorf("Select() = %+v, want FID [% X]", got, tt.wantFID)
+			}
+		})
+	}
+}
+
+func TestCard_ReadBinary(t *testing.T) {
+	file := make([]byte, 300)
+	for i := range file {
+		file[i] = byte(i)
+	}
+	// transparent EF of 300 bytes, answers 6282 with the data to the end
+	readBinary := func(apdu []byte) ([]byte, error) {
+		offset := int(apdu[2]&0x7F)<<8 | int(apdu[3])
+		if apdu[2]&0x80 != 0 {
+			offset = int(apdu[3])
+		}
+		ne := int(apdu[4])
+		if ne == 0 {
+			ne = 256
+		}
+		if offset >= len(file) {
+			return []byte{0x6B, 0x00}, nil
+		}
+		if offset+ne > len(file) {
+			return append(append([]byte{}, file[offset:]...), 0x62, 0x82), nil
+		}
+		return append(append([]byte{}, file[offset:offset+ne]...), 0x90, 0x00), nil
+	}
+	tests := []struct {
+		name    string
+		read    func(c *Card) ([]byte, error)
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "chunk",
+			read: func(c *Card) ([]byte, error) { return c.ReadBinary(10, 20) },
+			want: file[10:30],
+		},
+		{
+			name: "across commands",
+			read: func(c *Card) ([]byte, error) { return c.ReadBinary(0, 280) },
+			want: file[:280],
+		},
+		{
+			name: "to the end",
+			read: func(c *Card) ([]byte, error) { return c.ReadBinary(0, 0) },
+			want: file,
+		},
+		{
+			name: "to the end with SFI",
+			read: func(c *Card) ([]byte, error) { return c.ReadBinarySFI(0x02, 40, 0) },
+			want: file[40:],
+		},
+		{
+			name:    "past the end",
+			read:    func(c *Card) ([]byte, error) { return c.ReadBinary(290, 20) },
+			want:    file[290:],
+			wantErr: ErrEndOfFile,
+		},
+		{
+			name:    "bad offset",
+			read:    func(c *Card) ([]byte, error) { return c.ReadBinary(0x8000, 1) },
+			wantErr: ErrInvalidParameter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sim.NewCard(nil, nil, nil, 0x20)
+			s.Handle([]byte{0x00, 0xB0}, readBinary)
+			got, err := tt.read(NewCard(s))
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ReadBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadBinary() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCard_Records(t *testing.T) {
+	s := sim.NewCard(nil, nil, nil, 0x20)
+	s.Script(
+		sim.Exchange{Command: []byte{0x00, 0xE2, 0x00, 0x08, 0x02, 0xAA, 0xBB}, Response: []byte{0x90, 0x00}},
+		sim.Exchange{Command: []byte{0x00, 0xB2, 0x01, 0x0C, 0x00}, Response: []byte{0xAA, 0xBB, 0x90, 0x00}},
+		sim.Exchange{Command: []byte{0x00, 0xDC, 0x01, 0x04, 0x02, 0xCC, 0xDD}, Response: []byte{0x90, 0x00}},
+		sim.Exchange{Command: []byte{0x00, 0xB2, 0x02, 0x04, 0x00}, Response: []byte{0x6A, 0x83}},
+	)
+	c := NewCard(s)
+	if err := c.AppendRecord(1, []byte{0xAA, 0xBB}); err != nil {
+		t.Fatalf("AppendRecord() error = %v", err)
+	}
+	if got, err := c.ReadRecord(1, 1); err != nil || !bytes.Equal(got, []byte{0xAA, 0xBB}) {
+		t.Fatalf("ReadRecord() = [% X], %v", got, err)
+	}
+	if err := c.UpdateRecord(0, 1, []byte{0xCC, 0xDD}); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	if _, err := c.ReadRecord(0, 2); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("ReadRecord() error = %v, want %v", err, ErrRecordNotFound)
+	}
+	if _, err := c.ReadRecord(31, 1); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("ReadRecord() error = %v, want %v", err, ErrInvalidParameter)
+	}
+}
+
+func TestCard_Authenticate(t *testing.T) {
+	s := sim.NewCard(nil, nil, nil, 0x20)
+	s.Script(
+		sim.Exchange{Command: []byte{0x80, 0x84, 0x00, 0x00, 0x08},
+			Response: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x90, 0x00}},
+		sim.Exchange{Command: []byte{0x80, 0x82, 0x00, 0x01, 0x02, 0x11, 0x22}, Response: []byte{0x63, 0xC2}},
+		sim.Exchange{Command: []byte{0x80, 0x88, 0x00, 0x01, 0x02, 0x33, 0x44, 0x00},
+			Response: []byte{0x55, 0x66, 0x90, 0x00}},
+	)
+	c := NewCard(s)
+	c.CLA = 0x80
+	if got, err := c.GetChallenge(8); err != nil || len(got) != 8 {
+		t.Fatalf("GetChallenge() = [% X], %v", got, err)
+	}
+	err := c.ExternalAuthenticate(0x00, 0x01, []byte{0x11, 0x22})
+	if !errors.Is(err, ErrVerificationFailed) || Retries(err) != 2 {
+		t.Fatalf("ExternalAuthenticate() error = %v, want %v with 2 retries", err, ErrVerificationFailed)
+	}
+	if got, err := c.InternalAuthenticate(0x00, 0x01, []byte{0x33, 0x44}); err != nil || !bytes.Equal(got, []byte{0x55, 0x66}) {
+		t.Fatalf("InternalAuthenticate() = [% X], %v", got, err)
+	}
+}
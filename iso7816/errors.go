@@ -0,0 +1,91 @@
+package iso7816
+
+import (
+	"errors"
+
+	"github.com/dumacp/smartcard/nxp"
+)
+
+var ErrEndOfFile = errors.New("end of file or record reached before reading Ne bytes")
+var ErrVerificationFailed = errors.New("verification failed")
+var ErrMemoryFailure = errors.New("memory failure")
+var ErrWrongLength = errors.New("wrong length")
+var ErrSecureMessaging = errors.New("secure messaging not supported or data objects incorrect")
+var ErrCommandNotAllowed = errors.New("command not allowed")
+var ErrCommandIncompatible = errors.New("command incompatible with file structure")
+var ErrSecurityStatus = errors.New("security status not satisfied")
+var ErrAuthMethodBlocked = errors.New("authentication method blocked")
+var ErrReferencedDataInvalid = errors.New("referenced data invalidated")
+var ErrConditionsOfUse = errors.New("conditions of use not satisfied")
+var ErrNoCurrentEF = errors.New("command not allowed, no current EF")
+var ErrIncorrectData = errors.New("incorrect parameters in the command data field")
+var ErrFunctionNotSupported = errors.New("function not supported")
+var ErrFileNotFound = errors.New("file or application not found")
+var ErrRecordNotFound = errors.New("record not found")
+var ErrNotEnoughMemory = errors.New("not enough memory space in the file")
+var ErrIncorrectParameters = errors.New("incorrect parameters P1-P2")
+var ErrReferencedDataNotFound = errors.New("referenced data or reference data not found")
+var ErrFileExists = errors.New("file already exists")
+var ErrWrongParameters = errors.New("wrong parameters P1-P2, offset outside the EF")
+var ErrInsNotSupported = errors.New("instruction code not supported")
+var ErrClaNotSupported = errors.New("class not supported")
+var ErrNoPreciseDiagnosis = errors.New("no precise diagnosis")
+
+// ErrUnknownStatus the sentinel of the status words out of Status
+var ErrUnknownStatus = nxp.ErrUnknownStatus
+
+// Status sentinels of the ISO 7816-4 status words
+var Status = map[uint16]error{
+	0x6282: ErrEndOfFile,
+	0x6300: ErrVerificationFailed,
+	0x6581: ErrMemoryFailure,
+	0x6700: ErrWrongLength,
+	0x6882: ErrSecureMessaging,
+	0x6981: ErrCommandIncompatible,
+	0x6982: ErrSecurityStatus,
+	0x6983: ErrAuthMethodBlocked,
+	0x6984: ErrReferencedDataInvalid,
+	0x6985: ErrConditionsOfUse,
+	0x6986: ErrNoCurrentEF,
+	0x6987: ErrSecureMessaging,
+	0x6988: ErrSecureMessaging,
+	0x6A80: ErrIncorrectData,
+	0x6A81: ErrFunctionNotSupported,
+	0x6A82: ErrFileNotFound,
+	0x6A83: ErrRecordNotFound,
+	0x6A84: ErrNotEnoughMemory,
+	0x6A86: ErrIncorrectParameters,
+	0x6A88: ErrReferencedDataNotFound,
+	0x6A89: ErrFileExists,
+	0x6B00: ErrWrongParameters,
+	0x6D00: ErrInsNotSupported,
+	0x6E00: ErrClaNotSupported,
+	0x6F00: ErrNoPreciseDiagnosis,
+}
+
+// Retries remaining tries of a verification failed with 63Cx, the err of
+// the response (-1 if the status word doesn't have the counter)
+func Retries(err error) int {
+	var e *nxp.ErrorResponse
+	if !errors.As(err, &e) || e.SW&0xFFF0 != 0x63C0 {
+		return -1
+	}
+	return int(e.SW & 0x000F)
+}
+
+// NewStatusError error of the status word sw (nil if sw is 9000), a
+// *nxp.ErrorResponse with the sentinel of Status
+func NewStatusError(sw uint16) error {
+	switch {
+	case sw == 0x9000:
+		return nil
+	case sw&0xFFF0 == 0x63C0:
+		return &nxp.ErrorResponse{Err: ErrVerificationFailed, SW: sw}
+	case sw&0xFF00 == 0x6C00, sw&0xFF00 == 0x6700:
+		return &nxp.ErrorResponse{Err: ErrWrongLength, SW: sw}
+	}
+	return nxp.StatusError(sw, Status)
+}
+
+// ErrInvalidParameter the parameter of the command is out of the range of ISO 7816-4
+var ErrInvalidParameter = errors.New("invalid parameter")
@@ -0,0 +1,162 @@
+package iso7816
+
+import (
+	"fmt"
+
+	"github.com/dumacp/smartcard/tlv"
+)
+
+// FileStructure structure of an EF (bits 3-1 of the file descriptor byte)
+type FileStructure byte
+
+const (
+	// NoStructure no information given
+	NoStructure FileStructure = 0x00
+	// Transparent transparent EF
+	Transparent FileStructure = 0x01
+	// LinearFixed linear structure, records of fixed size
+	LinearFixed FileStructure = 0x02
+	// LinearVariable linear structure, records of variable size
+	LinearVariable FileStructure = 0x04
+	// Cyclic cyclic structure, records of fixed size
+	Cyclic FileStructure = 0x06
+)
+
+const (
+	// TemplateFCI file control information template
+	TemplateFCI = 0x6F
+	// TemplateFCP file control parameters template
+	TemplateFCP = 0x62
+	// TemplateFMD file management data template
+	TemplateFMD = 0x64
+)
+
+// FileInfo data of the file returned by SELECT (FCI, FCP or FMD template).
+// The fields absent in the response are zero (nil).
+type FileInfo struct {
+	// Template tag of the template (6F, 62 or 64)
+	Template byte
+	// Size number of data bytes of the file (tag 80)
+	Size int
+	// TotalSize number of bytes of the file, structural information
+	// included (tag 81)
+	TotalSize int
+	// Descriptor file descriptor byte (tag 82)
+	Descriptor byte
+	// DataCoding data coding byte (tag 82)
+	DataCoding byte
+	// MaxRecordSize max size of the records (tag 82)
+	MaxRecordSize int
+	// Records number of records (tag 82)
+	Records int
+	// FileID file identifier (tag 83)
+	FileID []byte
+	// DFName name of the DF, AID of the application (tag 84)
+	DFName []byte
+	// Proprietary proprietary information (tags 85 and A5)
+	Proprietary []byte
+	// SFI short EF identifier (tag 88)
+	SFI byte
+	// LifeCycle life cycle status byte (tag 8A)
+	LifeCycle byte
+	// Raw value of the template
+	Raw []byte
+}
+
+// IsDF the file is a DF (file descriptor byte x0111000)
+func (f *FileInfo) IsDF() bool {
+	return f.Descriptor&0xBF == 0x38
+}
+
+// Structure structure of the EF
+func (f *FileInfo) Structure() FileStructure {
+	if f.IsDF() {
+		return NoStructure
+	}
+	return FileStructure(f.Descriptor & 0x07)
+}
+
+// ParseFileInfo decode the response to SELECT. The response can be the
+// template or the proprietary data of the card, this is returned in
+// Proprietary.
+func ParseFileInfo(data []byte) (*FileInfo, error) {
+	if len(data) <= 0 {
+		return nil, fmt.Errorf("empty file information, %w", tlv.ErrTLV)
+	}
+	f := &FileInfo{}
+	switch data[0] {
+	case TemplateFCI, TemplateFCP, TemplateFMD:
+	default:
+		f.Proprietary = append([]byte{}, data...)
+		return f, nil
+	}
+	template, rest, err := tlv.Next(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d bytes after the template, %w", len(rest), tlv.ErrTLV)
+	}
+	f.Template = byte(template.Tag)
+	f.Raw = template.Value
+
+	for _, obj := range template.Children {
+		value := obj.Value
+		switch obj.Tag {
+		case 0x80:
+			f.Size = beInt(value)
+		case 0x81:
+			f.TotalSize = beInt(value)
+		case 0x82:
+			if err := f.parseDescriptor(value); err != nil {
+				return nil, err
+			}
+		case 0x83:
+			f.FileID = value
+		case 0x84:
+			f.DFName = value
+		case 0x85, 0xA5:
+			f.Proprietary = value
+		case 0x88:
+			if len(value) > 0 {
+				f.SFI = value[0] >> 3
+			}
+		case 0x8A:
+			if len(value) > 0 {
+				f.LifeCycle = value[0]
+			}
+		}
+	}
+	return f, nil
+}
+
+func (f *FileInfo) parseDescriptor(value []byte) error {
+	switch len(value) {
+	case 1, 2:
+	case 3:
+		f.MaxRecordSize = int(value[2])
+	case 4:
+		f.MaxRecordSize = beInt(value[2:4])
+	case 5:
+		f.MaxRecordSize = beInt(value[2:4])
+		f.Records = int(value[4])
+	case 6:
+		f.MaxRecordSize = beInt(value[2:4])
+		f.Records = beInt(value[4:6])
+	default:
+		return fmt.Errorf("file descriptor length %d, %w", len(value), tlv.ErrTLV)
+	}
+	f.Descriptor = value[0]
+	if len(value) > 1 {
+		f.DataCoding = value[1]
+	}
+	return nil
+}
+
+func beInt(data []byte) int {
+	v := 0
+	for _, b := range data {
+		v = v<<8 | int(b)
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package iso7816
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/tlv"
+)
+
+func TestParseFileInfo(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		wantDF        bool
+		wantStructure FileStructure
+		wantSize      int
+		wantRecords   int
+		wantName      []byte
+		wantSFI       byte
+		wantErr       bool
+	}{
+		{
+			name: "FCP transparent EF",
+			data: []byte{0x62, 0x0F, 0x82, 0x02, 0x01, 0x21, 0x83, 0x02, 0xE1, 0x04,
+				0x80, 0x02, 0x01, 0x00, 0x88, 0x01, 0x20},
+			wantStructure: Transparent,
+			wantSize:      256,
+			wantSFI:       4,
+		},
+		{
+			name: "FCP linear fixed EF",
+			data: []byte{0x62, 0x0B, 0x82, 0x05, 0x02, 0x21, 0x00, 0x20, 0x0A,
+				0x83, 0x02, 0x00, 0x01},
+			wantStructure: LinearFixed,
+			wantRecords:   10,
+		},
+		{
+			name: "FCI application",
+			data: []byte{0x6F, 0x0E, 0x84, 0x07, 0xD2, 0x76, 0x00, 0x00, 0x85, 0x01, 0x01,
+				0xA5, 0x03, 0x88, 0x01, 0x01},
+			wantName: []byte{0xD2, 0x76, 0x00, 0x00, 0x85, 0x01, 0x01},
+		},
+		{
+			name:   "FCP DF long length",
+			data:   []byte{0x62, 0x81, 0x04, 0x82, 0x02, 0x38, 0x00},
+			wantDF: true,
+		},
+		{
+			name:    "truncated",
+			data:    []byte{0x62, 0x06, 0x82, 0x02, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "bytes after the template",
+			data:    []byte{0x62, 0x00, 0x90},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileInfo(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, tlv.ErrTLV) {
+					t.Errorf("ParseFileInfo() error = %v, want %v", err, tlv.ErrTLV)
+				}
+				return
+			}
+			if got.IsDF() != tt.wantDF || got.Structure() != tt.wantStructure {
+				t.Errorf("ParseFileInfo() DF = %v, structure = %d, want %v, %d",
+					got.IsDF(), got.Structure(), tt.wantDF, tt.wantStructure)
+			}
+			if got.Size != tt.wantSize || got.Records != tt.wantRecords || got.SFI != tt.wantSFI {
+				t.Errorf("ParseFileInfo() size = %d, records = %d, SFI = %d, want %d, %d, %d",
+					got.Size, got.Records, got.SFI, tt.wantSize, tt.wantRecords, tt.wantSFI)
+			}
+			if !bytes.Equal(got.DFName, tt.wantName) {
+				t.Errorf("ParseFileInfo() DF name = [% X], want [% X]", got.DFName, tt.wantName)
+			}
+		})
+	}
+}
@@ -12,7 +12,8 @@ projects on which it is based:
 package multiiso
 
 import (
-	"fmt"
+	"context"
+	"time"
 
 	"github.com/dumacp/smartcard"
 )
@@ -87,22 +88,39 @@ func (c *Card) EndTransactionResetCard() error {
 	return err
 }
 
+// BeginExclusive lock the serial device to the card, the cards of the other
+// slots of the device wait until EndExclusive
+func (c *Card) BeginExclusive() error {
+	c.Reader.device.lockExclusive(c.Reader)
+	return nil
+}
+
+// EndExclusive unlock the serial device
+func (c *Card) EndExclusive() error {
+	c.Reader.device.unlockExclusive(c.Reader)
+	return nil
+}
+
 // Primitive channel to send command
 func (c *Card) Apdu(apdu []byte) ([]byte, error) {
+	return c.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext primitive channel to send command with ctx cancellation
+func (c *Card) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
 	if c.State != CONNECTED {
 		return nil, smartcard.Error(smartcard.ErrComm)
 	}
-	fmt.Printf("APDU: [% 02X]\n", apdu)
 	var response []byte
 	var err error
 	switch c.modeSend {
 	case APDU1443_4:
-		response, err = c.Reader.SendAPDU1443_4(apdu)
+		response, err = c.Reader.sendAPDU1443_4(ctx, apdu)
 		if err != nil {
 			return response, err
 		}
 	case T1TransactionV2:
-		response, err = c.Reader.T1TransactionV2(apdu)
+		response, err = c.Reader.t1TransactionV2(ctx, apdu)
 		if err != nil {
 			return response, err
 		}
@@ -110,16 +128,27 @@ func (c *Card) Apdu(apdu []byte) ([]byte, error) {
 		// 	return c.reader.T0TransactionV2(apdu)
 
 	default:
-		response, err = c.Reader.TransmitBinary([]byte{}, apdu)
+		response, err = c.Reader.transmitBinary(ctx, []byte{}, apdu)
 		if err != nil {
 			return response, err
 		}
 	}
-	fmt.Printf("RESP: [% 02X]\n", response[:])
 	return response[:], nil
 
 }
 
+// ApduTimed send command and return the round trip time of the frames in
+// the serial device
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	var elapsed time.Duration
+	ctx := context.WithValue(context.Background(), elapsedKey{}, &elapsed)
+	response, err := c.ApduContext(ctx, apdu)
+	if err != nil {
+		return response, 0, err
+	}
+	return response, elapsed, nil
+}
+
 // Get ATR of Card
 func (c *Card) ATR() ([]byte, error) {
 	if c.State != CONNECTED {
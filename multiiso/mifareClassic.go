@@ -1,8 +1,6 @@
 package multiiso
 
 import (
-	"fmt"
-
 	"github.com/dumacp/smartcard"
 	"github.com/dumacp/smartcard/nxp/mifare"
 )
@@ -39,7 +37,7 @@ func NewMifareClassicReader(dev *Device, readerName string, idx int) *Reader {
 		readerName: readerName,
 		idx:        idx,
 	}
-	r.transmit = r.TransmitBinary
+	r.transmit = r.transmitBinary
 	return r
 }
 
@@ -158,7 +156,6 @@ func (mc *mifareClassic) Auth(bNr, keyType int, key []byte) ([]byte, error) {
 	}
 
 	if len(resp1) <= 0 {
-		fmt.Printf("response: %X\n", resp1)
 		return resp1, NilResponse(0)
 	}
 
@@ -0,0 +1,45 @@
+package multiiso
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("multiiso", Open)
+}
+
+// Open open the reader of the URI multiiso://<port>?baud=115200&timeout=1s&mode=binary&idx=0
+// (mode: binary or ascii)
+func Open(u *smartcard.URI) (smartcard.IReader, error) {
+	baud, err := u.Int("baud", 115200)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := u.Duration("timeout", 1*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := u.Int("idx", 0)
+	if err != nil {
+		return nil, err
+	}
+	mode := BinaryMode
+	switch u.Option("mode", "binary") {
+	case "binary":
+	case "ascii":
+		mode = AsciiMode
+	default:
+		return nil, fmt.Errorf("option \"mode\" = %q, %w", u.Option("mode", ""), smartcard.ErrURI)
+	}
+
+	dev, err := NewDevice(u.Device, baud, timeout)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(dev, u.Option("name", u.Device), idx)
+	r.SetModeProtocol(mode)
+	return r, nil
+}
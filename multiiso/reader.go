@@ -11,6 +11,7 @@ projects on which it is based:
 package multiiso
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"strings"
@@ -114,7 +115,7 @@ func NewReader(dev *Device, readerName string, idx int) *Reader {
 		readerName: readerName,
 		idx:        idx,
 	}
-	r.transmit = r.TransmitBinary
+	r.transmit = r.transmitBinary
 	return r
 }
 
@@ -127,16 +128,16 @@ func checksum(data []byte) byte {
 	return sum
 }
 
-type transmitfunc func([]byte, []byte) ([]byte, error)
+type transmitfunc func(context.Context, []byte, []byte) ([]byte, error)
 
 // SetModeProtocol set mode protocol to communication (0: binary, 1: ascii)
 func (r *Reader) SetModeProtocol(mode int) {
 	if mode == BinaryMode {
-		r.transmit = r.TransmitBinary
+		r.transmit = r.transmitBinary
 		r.ModeProtocol = BinaryMode
 		r.device.mode = 0
 	} else {
-		r.transmit = r.TransmitAscii
+		r.transmit = r.transmitAscii
 		r.ModeProtocol = AsciiMode
 		r.device.mode = 1
 	}
@@ -148,18 +149,28 @@ func (r *Reader) SetModeProtocol(mode int) {
 
 // Transmit send data byte to reader in actual mode
 func (r *Reader) Transmit(cmd, data []byte) ([]byte, error) {
-	return r.transmit(cmd, data)
+	return r.transmit(context.Background(), cmd, data)
+}
+
+// TransmitContext send data byte to reader in actual mode with ctx cancellation
+func (r *Reader) TransmitContext(ctx context.Context, cmd, data []byte) ([]byte, error) {
+	return r.transmit(ctx, cmd, data)
 }
 
 // TransmitAscii send in ascii protocol mode
 func (r *Reader) TransmitAscii(cmd, data []byte) ([]byte, error) {
+	return r.transmitAscii(context.Background(), cmd, data)
+}
+
+func (r *Reader) transmitAscii(ctx context.Context, cmd, data []byte) ([]byte, error) {
 	apdu := make([]byte, 0)
 	apdu = append(apdu, cmd...)
 	if data != nil {
 		apdu = append(apdu, strings.ToUpper(hex.EncodeToString(data))...)
 	}
 	// fmt.Printf("reqs TransmitAscii: [%s]\n", apdu)
-	resp1, err := r.device.SendRecv(apdu)
+	defer r.device.waitExclusive(r)()
+	resp1, err := r.device.SendRecvContext(ctx, apdu)
 	// fmt.Printf("resp TransmitAscii: [%s]\n", resp1)
 	// fmt.Printf("resp TransmitAscii: %q\n", resp1)
 	if err != nil {
@@ -170,6 +181,10 @@ func (r *Reader) TransmitAscii(cmd, data []byte) ([]byte, error) {
 
 // TransmitBinary send in binary protocol mode
 func (r *Reader) TransmitBinary(cmd, data []byte) ([]byte, error) {
+	return r.transmitBinary(context.Background(), cmd, data)
+}
+
+func (r *Reader) transmitBinary(ctx context.Context, cmd, data []byte) ([]byte, error) {
 	apdu := make([]byte, 0)
 	apdu = append(apdu, 0x02)
 	apdu = append(apdu, byte(r.idx))
@@ -181,7 +196,8 @@ func (r *Reader) TransmitBinary(cmd, data []byte) ([]byte, error) {
 	apdu = append(apdu, checksum(apdu[1:]))
 	apdu = append(apdu, 0x03)
 	// fmt.Printf("apdu TransmitBinary: [% X]\n", apdu)
-	resp1, err := r.device.SendRecv(apdu)
+	defer r.device.waitExclusive(r)()
+	resp1, err := r.device.SendRecvContext(ctx, apdu)
 	// fmt.Printf("resp TransmitBinary: [% X]\n", resp1)
 	if err != nil {
 		return nil, smartcard.Error(err)
@@ -214,11 +230,15 @@ func verifyresponse(data []byte) error {
 
 // SendDataFrameTransfer send in format Data Frame Transfer
 func (r *Reader) SendDataFrameTransfer(data []byte) ([]byte, error) {
+	return r.sendDataFrameTransfer(context.Background(), data)
+}
+
+func (r *Reader) sendDataFrameTransfer(ctx context.Context, data []byte) ([]byte, error) {
 	cmd := make([]byte, 0)
 	cmd = append(cmd, []byte(datatransfer)...)
 	apdu := make([]byte, 0)
 	apdu = append(apdu, data...)
-	resp1, err := r.TransmitBinary(cmd, apdu)
+	resp1, err := r.transmitBinary(ctx, cmd, apdu)
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +247,10 @@ func (r *Reader) SendDataFrameTransfer(data []byte) ([]byte, error) {
 
 // SendAPDU1443_4 send in format Data Frame Transfer
 func (r *Reader) SendAPDU1443_4(data []byte) ([]byte, error) {
+	return r.sendAPDU1443_4(context.Background(), data)
+}
+
+func (r *Reader) sendAPDU1443_4(ctx context.Context, data []byte) ([]byte, error) {
 	cmd := make([]byte, 0)
 	cmd = append(cmd, byte(len(data)+1))
 	cmd = append(cmd, 0x0F)
@@ -234,7 +258,7 @@ func (r *Reader) SendAPDU1443_4(data []byte) ([]byte, error) {
 
 	cmd = append(cmd, data...)
 
-	response, err := r.SendDataFrameTransfer(cmd)
+	response, err := r.sendDataFrameTransfer(ctx, cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +272,7 @@ func (r *Reader) SendAPDU1443_4(data []byte) ([]byte, error) {
 		for (response[1] & 0x10) == 0x10 {
 			r.blocknumber = response[1]
 			frame := []byte{0x01, 0x0F, byte(0xA0 + r.blockNumber())}
-			response, err = r.SendDataFrameTransfer(frame)
+			response, err = r.sendDataFrameTransfer(ctx, frame)
 			if err != nil {
 				return nil, err
 			}
@@ -266,12 +290,16 @@ func (r *Reader) SendAPDU1443_4(data []byte) ([]byte, error) {
 
 // SendSAMDataFrameTransfer send APDU to SAM device in special socket ("e" command)
 func (r *Reader) SendSAMDataFrameTransfer(data []byte) ([]byte, error) {
+	return r.sendSAMDataFrameTransfer(context.Background(), data)
+}
+
+func (r *Reader) sendSAMDataFrameTransfer(ctx context.Context, data []byte) ([]byte, error) {
 	innerData := make([]byte, 0)
 
 	// innerData = append(innerData, 0x65)
 	innerData = append(innerData, data...)
 
-	response, err := r.Transmit([]byte{0x65}, innerData)
+	response, err := r.transmit(ctx, []byte{0x65}, innerData)
 	// response, err := r.Transmit([]byte{}, innerData)
 	if err != nil {
 		time.Sleep(600 * time.Millisecond) // restore time
@@ -291,6 +319,10 @@ func (r *Reader) SendSAMDataFrameTransfer(data []byte) ([]byte, error) {
 
 // T1TransactionV2 function to send wrapped frames T1 to SAM device through "e" command
 func (r *Reader) T1TransactionV2(data []byte) ([]byte, error) {
+	return r.t1TransactionV2(context.Background(), data)
+}
+
+func (r *Reader) t1TransactionV2(ctx context.Context, data []byte) ([]byte, error) {
 	trama := make([]byte, 0)
 
 	trama = append(trama, byte(len(data)&0xFF))
@@ -302,7 +334,7 @@ func (r *Reader) T1TransactionV2(data []byte) ([]byte, error) {
 
 	trama = append(trama, data...)
 
-	return r.SendSAMDataFrameTransfer(trama)
+	return r.sendSAMDataFrameTransfer(ctx, trama)
 
 }
 
@@ -312,7 +344,7 @@ func (r *Reader) GetRegister(register byte) ([]byte, error) {
 	apdu := make([]byte, 0)
 
 	apdu = append(apdu, register)
-	return r.transmit(cmd, apdu)
+	return r.Transmit(cmd, apdu)
 }
 
 // SetRegister send in format Data Frame Transfer
@@ -322,13 +354,23 @@ func (r *Reader) SetRegister(register byte, data []byte) error {
 
 	apdu = append(apdu, register)
 	apdu = append(apdu, data...)
-	_, err := r.transmit(cmd, apdu)
+	_, err := r.Transmit(cmd, apdu)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// Poll detect a card in the field with a single select command to the
+// reader and return the UID of the card.
+func (r *Reader) Poll() ([]byte, error) {
+	c, err := r.ConnectLegacyCard()
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	return c.uuid, nil
+}
+
 // Create New Card interface
 func (r *Reader) ConnectCard() (smartcard.ICard, error) {
 	c, err := r.ConnectLegacyCard()
@@ -348,7 +390,7 @@ func (r *Reader) ConnectLegacyCard() (*Card, error) {
 	cmd := []byte(highspeedselect)
 	apdu := make([]byte, 0)
 	apdu = append(apdu, 0x88)
-	resp2, err := r.transmit(cmd, apdu)
+	resp2, err := r.Transmit(cmd, apdu)
 	if err != nil {
 		return nil, err
 	}
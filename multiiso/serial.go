@@ -9,17 +9,26 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dumacp/smartcard"
 	"github.com/tarm/serial"
 )
 
+// elapsedKey key of the value of the context (*time.Duration) that sums
+// the round trip times of the frames sent with SendRecvContext
+type elapsedKey struct{}
+
 // Device struct
 type Device struct {
-	port    *serial.Port
-	Ok      bool
-	mux     sync.Mutex
+	port *serial.Port
+	Ok   bool
+	mux  sync.Mutex
+	// excl exclusive access of a slot to the device (BeginExclusive), owner
+	// is the reader of the slot
+	owner   atomic.Pointer[Reader]
+	excl    sync.Mutex
 	timeout time.Duration
 	chRecv  chan []byte
 	contxt  context.Context
@@ -73,6 +82,30 @@ func (dev *Device) Close() bool {
 	return true
 }
 
+// lockExclusive start the exclusive access of the reader r to the device
+func (dev *Device) lockExclusive(r *Reader) {
+	dev.excl.Lock()
+	dev.owner.Store(r)
+}
+
+// unlockExclusive end the exclusive access of the reader r to the device
+func (dev *Device) unlockExclusive(r *Reader) {
+	if dev.owner.CompareAndSwap(r, nil) {
+		dev.excl.Unlock()
+	}
+}
+
+// waitExclusive wait until the exclusive access of the other readers ends
+// (EndExclusive) to send the frames of r, the device is released with the
+// returned function
+func (dev *Device) waitExclusive(r *Reader) func() {
+	if dev.owner.Load() == r {
+		return func() {}
+	}
+	dev.excl.Lock()
+	return dev.excl.Unlock
+}
+
 // Read read serial device with a channel
 func (dev *Device) read() {
 	if !dev.Ok {
@@ -216,29 +249,62 @@ func (dev *Device) Send(data []byte) (int, error) {
 
 // SendRecv write daa bytes in serial device and wait by response
 func (dev *Device) SendRecv(data []byte) ([]byte, error) {
+	return dev.SendRecvContext(context.Background(), data)
+}
+
+// SendRecvContext write data bytes in serial device and wait by response until
+// the device timeout or ctx is done. If ctx is done, the pending read is
+// aborted and the late response is discarded before the next command.
+func (dev *Device) SendRecvContext(ctx context.Context, data []byte) ([]byte, error) {
 	dev.mux.Lock()
-	defer dev.mux.Unlock()
+	locked := true
+	defer func() {
+		if locked {
+			dev.mux.Unlock()
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("SendRecv canceled, %w, %w", err, smartcard.ErrComm)
+	}
 	buff := make([]byte, 0)
 	buff = append(buff, data[:]...)
 
 	// log.Printf("data send: [% X]", data)
+	t0 := time.Now()
 	if n, err := dev.port.Write(buff); err != nil {
 		return nil, err
 	} else if n <= 0 {
 		return nil, fmt.Errorf("dont write in SendRecv command, %w", smartcard.ErrComm)
 	}
+	timer := time.NewTimer(dev.timeout)
 	select {
 	case v, ok := <-dev.chRecv:
+		timer.Stop()
 		if !ok {
 			return nil, fmt.Errorf("close channel in dev")
 		}
+		if elapsed, ok := ctx.Value(elapsedKey{}).(*time.Duration); ok {
+			*elapsed += time.Since(t0)
+		}
 		recv := make([]byte, 0)
 		if len(v) > 0 {
 			recv = append(recv, v...)
 		}
 		return recv, nil
-	case <-time.After(dev.timeout):
+	case <-timer.C:
 		return nil, fmt.Errorf("timeout error in SendRecv command, %w", smartcard.ErrComm)
+	case <-ctx.Done():
+		// keep the device locked until the late response or the timeout
+		locked = false
+		go func() {
+			defer dev.mux.Unlock()
+			defer timer.Stop()
+			select {
+			case <-dev.chRecv:
+			case <-timer.C:
+			}
+		}()
+		return nil, fmt.Errorf("SendRecv canceled, %w, %w", ctx.Err(), smartcard.ErrComm)
 	}
 }
 
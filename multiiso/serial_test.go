@@ -0,0 +1,34 @@
+package multiiso
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDevice_waitExclusive(t *testing.T) {
+	dev := &Device{}
+	owner := &Reader{device: dev}
+	other := &Reader{device: dev}
+
+	dev.lockExclusive(owner)
+	// the frames of the owner are not blocked
+	dev.waitExclusive(owner)()
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		dev.waitExclusive(other)()
+	}()
+	select {
+	case <-sent:
+		t.Fatalf("waitExclusive() of other reader in the exclusive access")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	dev.unlockExclusive(owner)
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatalf("waitExclusive() of other reader after unlockExclusive")
+	}
+}
@@ -2,6 +2,7 @@ package nxp
 
 import (
 	"errors"
+	"fmt"
 )
 
 var ErrEEPROMbusy = errors.New("EEPROM busy, access collision, memmory range cannot be mapped, execution error")
@@ -11,14 +12,63 @@ var ErrCounterNumberInvalid = errors.New("SAM key usage counter number invalid")
 var ErrWrongLengthAPDU = errors.New("wrong length of the APDU or wrong Lc byte")
 var ErrTemperature = errors.New("temperature error")
 
+var ErrUnknownStatus = errors.New("unknown status")
+var ErrFinalChainedCommand = errors.New("final chained command expected")
+var ErrSecurityStatus = errors.New("security status not satisfied, MAC verification failed")
+var ErrReferencedDataInvalid = errors.New("referenced data invalid")
+var ErrConditionsOfUse = errors.New("conditions of use not satisfied")
+var ErrIncorrectData = errors.New("incorrect data field")
+var ErrIncorrectParameters = errors.New("incorrect parameters P1-P2")
+var ErrInsNotSupported = errors.New("instruction code not supported")
+var ErrClaNotSupported = errors.New("class not supported")
+var ErrNoPreciseDiagnosis = errors.New("no precise diagnosis")
+
+// ErrorResponse error response of the card. SW is the status word of the
+// response (the native status of DESFire and MIFARE Plus is 0x91XX) and Err
+// the sentinel of the status, so errors.Is(err, ErrX) works.
 type ErrorResponse struct {
 	Err error
+	SW  uint16
 }
 
 func (e ErrorResponse) Error() string {
-	return e.Err.Error()
+	if e.SW == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (SW: %04X)", e.Err, e.SW)
+}
+
+func (e ErrorResponse) Unwrap() error {
+	return e.Err
 }
 
 func Error(e error) error {
 	return &ErrorResponse{Err: e}
 }
+
+// StatusError error of the status word sw, the sentinel is looked up in
+// codes (ErrUnknownStatus if sw isn't in codes)
+func StatusError(sw uint16, codes map[uint16]error) error {
+	err, ok := codes[sw]
+	if !ok {
+		err = ErrUnknownStatus
+	}
+	return &ErrorResponse{Err: err, SW: sw}
+}
+
+// SamStatus sentinels of the ISO 7816 status words of the SAM AV2/AV3
+var SamStatus = map[uint16]error{
+	0x6400: ErrEEPROMbusy,
+	0x6700: ErrWrongLengthAPDU,
+	0x6883: ErrFinalChainedCommand,
+	0x6982: ErrSecurityStatus,
+	0x6984: ErrReferencedDataInvalid,
+	0x6985: ErrConditionsOfUse,
+	0x6A80: ErrIncorrectData,
+	0x6A82: ErrReferecNumberKeyInvakid,
+	0x6A84: ErrCounterNumberInvalid,
+	0x6A86: ErrIncorrectParameters,
+	0x6D00: ErrInsNotSupported,
+	0x6E00: ErrClaNotSupported,
+	0x6F00: ErrNoPreciseDiagnosis,
+}
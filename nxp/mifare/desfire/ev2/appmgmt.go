@@ -28,6 +28,8 @@ func (d *Desfire) CreateApplication(aid []byte,
 	appKeySetsEnable_rollKey AccessRights,
 	appKeySetsEnable_aksVersion, appKeySetsEnable_NoKeySets, appKeySetsEnable_maxKeySize int,
 	isoFileID, isofileDFName []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(aid) != 3 {
 		return errors.New("aid format error")
@@ -113,6 +115,11 @@ func (d *Desfire) CreateApplication(aid []byte,
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -129,7 +136,10 @@ func (d *Desfire) CreateApplication(aid []byte,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -139,6 +149,8 @@ func (d *Desfire) CreateApplication(aid []byte,
 // SelectApplication select 1 or 2 applications or the PICC level specified
 // by their application identifier.
 func (d *Desfire) SelectApplication(aid1, aid2 []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(aid1) != 3 {
 		return errors.New("aid format error")
@@ -172,6 +184,8 @@ func (d *Desfire) SelectApplication(aid1, aid2 []byte) error {
 
 // Permanently deactivates applications on the PICC. The AID is released.
 func (d *Desfire) DeleteApplication(aid []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(aid) != 3 {
 		return errors.New("aid format error")
@@ -193,6 +207,13 @@ func (d *Desfire) DeleteApplication(aid []byte) error {
 		}
 		apdu = append(apdu, cmdHeader...)
 		apdu = append(apdu, cmcT...)
+	case D40:
+		apdu = append(apdu, cmdHeader...)
+	case EV1:
+		if _, err := d.commandEV1(cmd, cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
+		apdu = append(apdu, cmdHeader...)
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -206,7 +227,10 @@ func (d *Desfire) DeleteApplication(aid []byte) error {
 	}
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -239,6 +263,8 @@ func (d *Desfire) CreateDelegateApplication(aid []byte,
 	appKeySetsEnable_rollKey,
 	appKeySetsEnable_aksVersion, appKeySetsEnable_NoKeySets, appKeySetsEnable_maxKeySize int,
 	isoFileID, isofileDFName []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(aid) != 3 {
 		return nil, errors.New("aid format error")
@@ -355,7 +381,9 @@ func (d *Desfire) CreateDelegateApplication(aid []byte,
 
 // GetApplicationsID returns the application IDentifiers of all active application
 func (d *Desfire) GetApplicationsID() ([]byte, error) {
-	cmd := byte(0x5A)
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	cmd := byte(0x6A)
 	apdu := make([]byte, 0)
 
 	apdu = append(apdu, cmd)
@@ -369,6 +397,11 @@ func (d *Desfire) GetApplicationsID() ([]byte, error) {
 		}
 		// apdu = append(apdu, cmdHeader...)
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV2 mode support")
 	}
@@ -382,6 +415,10 @@ func (d *Desfire) GetApplicationsID() ([]byte, error) {
 	}
 
 	switch d.evMode {
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
 	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
@@ -393,6 +430,8 @@ func (d *Desfire) GetApplicationsID() ([]byte, error) {
 // and (optionally) a DF Name of all active applications with
 // ISO/IEC 7816-4 support.
 func (d *Desfire) GetDFNames() ([][]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0x6D)
 
@@ -410,7 +449,13 @@ func (d *Desfire) GetDFNames() ([][]byte, error) {
 				return nil, err
 			}
 			apdu = append(apdu, cmacT...)
+		case D40:
 		case EV1:
+			if apdu[0] == cmd {
+				if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, errors.New("only EV1 and Ev2 support")
 		}
@@ -423,7 +468,13 @@ func (d *Desfire) GetDFNames() ([][]byte, error) {
 			return nil, err
 		}
 
-		response = append(response, resp[1:len(resp)-8])
+		switch d.evMode {
+		case D40, EV1:
+			// the CMAC of EV1 covers the data of all the frames
+			response = append(response, resp[1:])
+		default:
+			response = append(response, resp[1:len(resp)-8])
+		}
 
 		if resp[0] == 0x00 {
 			break
@@ -431,12 +482,18 @@ func (d *Desfire) GetDFNames() ([][]byte, error) {
 		apdu = []byte{0xAF}
 	}
 
+	if d.evMode == EV1 {
+		return d.responseFramesEV1(response)
+	}
+
 	return response, nil
 }
 
 // GetDeletedInfo returns the DAMSlotVersion and QoutaLimit of a target DAM Slot
 // on the card.
 func (d *Desfire) GetDeletedInfo(damSlotNo int) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if damSlotNo > 0xFFFF {
 		return nil, errors.New("DAMSlotNo format error")
@@ -1,17 +1,136 @@
 package ev2
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/des"
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"math/rand"
 	"time"
 
-	"github.com/aead/cmac"
+	"github.com/dumacp/smartcard"
 )
 
+func Apdu_AuthenticateD40(keyNumber int) []byte {
+	cmd := byte(0x0A)
+
+	apdu := make([]byte, 0)
+
+	apdu = append(apdu, cmd)
+	apdu = append(apdu, byte(keyNumber))
+
+	return apdu
+}
+
+// AuthenticateD40 legacy authentication of DESFire (D40). Only for DES and
+// KeyType.2TDEA keys. After this authentication the D40 secure messaging is
+// used, without IV chaining and with CRC16.
+func (d *Desfire) AuthenticateD40(keyNumber int) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	apdu := Apdu_AuthenticateD40(keyNumber)
+
+	d.lastKey = keyNumber
+
+	// E(Kx, RndB)
+	smartcard.RedactNext(d, nil, []smartcard.Span{{Offset: 1, Length: -1}})
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyResponse(resp); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// AuthenticateD40Part2 second part of AuthenticateD40 with the DES (8 bytes)
+// or 2TDEA (16 bytes) key and the response of the first part.
+func (d *Desfire) AuthenticateD40Part2(key, data []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	k := make([]byte, 0)
+	switch len(key) {
+	case 8:
+		k = append(k, key...)
+		k = append(k, key...)
+	case 16:
+		k = append(k, key...)
+	default:
+		return nil, fmt.Errorf("len key = %d, only DES or 2TDEA keys", len(key))
+	}
+
+	block, err := des.NewTripleDESCipher(append(k, k[:8]...))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != 9 {
+		return nil, fmt.Errorf("len E(Kx, RndB) = %d, %w", len(data)-1, ErrLengthError)
+	}
+
+	rndB := receiveModeD40(block, data[1:])
+
+	rndA := make([]byte, len(rndB))
+	if _, err := crand.Read(rndA); err != nil {
+		return nil, err
+	}
+
+	rndD := make([]byte, 0)
+	rndD = append(rndD, rndA...)
+	rndD = append(rndD, rndB[1:]...)
+	rndD = append(rndD, rndB[0])
+
+	apdu := Apdu_AuthenticateISOPart2(sendModeD40(block, rndD))
+	// E(Kx, RndA || RndB'), E(Kx, RndA')
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyResponse(resp); err != nil {
+		return nil, err
+	}
+	if len(resp[1:]) != len(rndA) {
+		return nil, fmt.Errorf("len E(Kx, RndA') = %d, %w", len(resp[1:]), ErrLengthError)
+	}
+
+	rndAr := receiveModeD40(block, resp[1:])
+	rndAr = append(rndAr[len(rndAr)-1:], rndAr[:len(rndAr)-1]...)
+	if !bytes.Equal(rndAr, rndA) {
+		return nil, fmt.Errorf("RndA' mismatch, %w", ErrAuthenticationError)
+	}
+
+	// the same session key of EV1 with DES and 2TDEA keys
+	kses, err := sessionKeyEV1(k, rndA, rndB)
+	if err != nil {
+		return resp, err
+	}
+	block, err = des.NewTripleDESCipher(append(kses, kses[:8]...))
+	if err != nil {
+		return resp, err
+	}
+
+	d.evMode = D40
+	d.keyEnc = kses
+	d.keyMac = kses
+	d.block = block
+	d.blockMac = block
+	d.iv = nil
+	d.ti = nil
+	d.cmdCtr = 0
+
+	return resp, nil
+}
+
 func Apdu_AuthenticateISO(secondAppIndicator int, keyNumber int) []byte {
 	cmd := byte(0x1A)
 	keyNo := byte(keyNumber) | byte(secondAppIndicator<<7)
@@ -28,11 +147,15 @@ func Apdu_AuthenticateISO(secondAppIndicator int, keyNumber int) []byte {
 // or KeyType.3TDEA keys. After this authentication EV1 backwards compatible secure
 // messaging is used.
 func (d *Desfire) AuthenticateISO(secondAppIndicator SecondAppIndicator, keyNumber int) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	apdu := Apdu_AuthenticateISO(secondAppIndicator.Int(), keyNumber)
 
 	d.lastKey = keyNumber
 
+	// E(Kx, RndB)
+	smartcard.RedactNext(d, nil, []smartcard.Span{{Offset: 1, Length: -1}})
 	resp, err := d.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -57,107 +180,204 @@ func Apdu_AuthenticateISOPart2(cryptograma []byte) []byte {
 
 }
 
+// AuthenticateISOPart2 second part of AuthenticateISO with the 2TDEA (16
+// bytes) or 3TDEA (24 bytes) key and the response of the first part.
 func (d *Desfire) AuthenticateISOPart2(key, data []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
-	if len(key) == 16 {
-		key = append(key, key[:8]...)
+	if len(key) != 16 && len(key) != 24 {
+		return nil, fmt.Errorf("len key = %d, only 2TDEA or 3TDEA keys", len(key))
 	}
-	if len(data) < 8 {
-		return nil, errors.New("nil data")
+
+	k := make([]byte, 0)
+	k = append(k, key...)
+	if len(k) == 16 {
+		k = append(k, key[:8]...)
 	}
 
-	rand.Seed(time.Now().UnixNano())
+	block, err := des.NewTripleDESCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.authenticateEV1Part2(block, key, data)
+}
+
+func Apdu_AuthenticateAES(secondAppIndicator int, keyNumber int) []byte {
+	cmd := byte(0xAA)
+	keyNo := byte(keyNumber) | byte(secondAppIndicator<<7)
+
+	apdu := make([]byte, 0)
 
-	block, err := des.NewTripleDESCipher(key)
+	apdu = append(apdu, cmd)
+	apdu = append(apdu, keyNo)
+
+	return apdu
+}
+
+// AuthenticateAES authentication as already support by DESFire EV1. Only for
+// KeyType.AES keys. After this authentication EV1 backwards compatible secure
+// messaging is used.
+func (d *Desfire) AuthenticateAES(secondAppIndicator SecondAppIndicator, keyNumber int) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	apdu := Apdu_AuthenticateAES(secondAppIndicator.Int(), keyNumber)
+
+	d.lastKey = keyNumber
+
+	// E(Kx, RndB)
+	smartcard.RedactNext(d, nil, []smartcard.Span{{Offset: 1, Length: -1}})
+	resp, err := d.Apdu(apdu)
 	if err != nil {
 		return nil, err
 	}
-	iv := make([]byte, block.BlockSize())
 
-	mode := cipher.NewCBCDecrypter(block, iv)
-	// modeD := cipher.NewCBCDecrypter(block, iv)
+	if err := VerifyResponse(resp); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// AuthenticateAESPart2 second part of AuthenticateAES with the AES key and
+// the response of the first part.
+func (d *Desfire) AuthenticateAESPart2(key, data []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.authenticateEV1Part2(block, key, data)
+}
+
+// authenticateEV1Part2 second part of AuthenticateISO and AuthenticateAES.
+// The IV of the cryptograms is chained along the authentication.
+func (d *Desfire) authenticateEV1Part2(block cipher.Block, key, data []byte) ([]byte, error) {
+	// RndB is 16 bytes with 3TDEA and AES keys
+	if len(data) < 9 || len(data[1:])%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("len E(Kx, RndB) = %d, %w", len(data)-1, ErrLengthError)
+	}
+
+	iv := make([]byte, block.BlockSize())
 
-	// log.Printf("aid1 response: [ %X ], apdu: [ %X ]", response, aid1)
 	rndBC := data[1:]
 
 	rndB := make([]byte, len(rndBC))
-	mode.CryptBlocks(rndB, rndBC)
-	rndBr := make([]byte, len(rndB))
-	copy(rndBr, rndB)
-	rndBr = append(rndBr, rndBr[0])
-	rndBr = rndBr[1:]
-	// log.Printf("rotate rndB: [ %X ], [ %X ]", rndB, rndBr)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(rndB, rndBC)
+
 	rndA := make([]byte, len(rndB))
-	rand.Read(rndA)
-	// log.Printf("origin rndA: [ %X ]", rndA)
+	if _, err := crand.Read(rndA); err != nil {
+		return nil, err
+	}
 
 	rndD := make([]byte, 0)
-
 	rndD = append(rndD, rndA...)
-	rndD = append(rndD, rndBr...)
+	rndD = append(rndD, rndB[1:]...)
+	rndD = append(rndD, rndB[0])
 
 	rndDc := make([]byte, len(rndD))
-	mode = cipher.NewCBCEncrypter(block, rndBC[len(rndBC)-block.BlockSize():])
-	mode.CryptBlocks(rndDc, rndD)
+	cipher.NewCBCEncrypter(block, rndBC[len(rndBC)-block.BlockSize():]).CryptBlocks(rndDc, rndD)
 
-	//fmt.Printf("aid2: [% X]\n", aid2)
 	apdu := Apdu_AuthenticateISOPart2(rndDc)
+	// E(Kx, RndA || RndB'), E(Kx, RndA')
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
 	resp, err := d.Apdu(apdu)
 	if err != nil {
-		// log.Printf("fail response: [ %X ], apdu: [ %X ]", resp, apdu)
 		return nil, err
 	}
 
 	if err := VerifyResponse(resp); err != nil {
 		return nil, err
 	}
+	if len(resp[1:]) != len(rndA) {
+		return nil, fmt.Errorf("len E(Kx, RndA') = %d, %w", len(resp[1:]), ErrLengthError)
+	}
 
-	piccRndA := make([]byte, len(resp[1:]))
-	mode = cipher.NewCBCDecrypter(block, rndDc[len(rndDc)-block.BlockSize():])
-	mode.CryptBlocks(piccRndA, resp[1:])
+	rndAr := make([]byte, len(resp[1:]))
+	cipher.NewCBCDecrypter(block, rndDc[len(rndDc)-block.BlockSize():]).CryptBlocks(rndAr, resp[1:])
+	rndAr = append(rndAr[len(rndAr)-1:], rndAr[:len(rndAr)-1]...)
+	if !bytes.Equal(rndAr, rndA) {
+		return nil, fmt.Errorf("RndA' mismatch, %w", ErrAuthenticationError)
+	}
 
-	// log.Printf("origin rndA: [ %X ]", rndA)
-	// log.Printf("respon rndA: [ %X ]", piccRndA)
+	if err := d.setSessionKeyEV1(key, rndA, rndB); err != nil {
+		return resp, err
+	}
 
-	d.evMode = EV1
-	d.iv = make([]byte, block.BlockSize())
+	return resp, nil
+}
 
-	kex := make([]byte, 0)
-	kex = append(kex, rndA[0:4]...)
-	kex = append(kex, rndB[0:4]...)
+// sessionKeyEV1 session key of the EV1 secure messaging, the same key is used
+// for the encryption and the CMAC
+func sessionKeyEV1(key, rndA, rndB []byte) ([]byte, error) {
+	kses := make([]byte, 0)
+	switch {
+	case len(key) == 16 && len(rndA) == 16:
+		// AES
+		kses = append(kses, rndA[0:4]...)
+		kses = append(kses, rndB[0:4]...)
+		kses = append(kses, rndA[12:16]...)
+		kses = append(kses, rndB[12:16]...)
+	case len(key) == 16 && len(rndA) == 8:
+		// 2TDEA, DES when both halves of the key are equal
+		kses = append(kses, rndA[0:4]...)
+		kses = append(kses, rndB[0:4]...)
+		if bytes.Equal(key[:8], key[8:]) {
+			kses = append(kses, kses...)
+		} else {
+			kses = append(kses, rndA[4:8]...)
+			kses = append(kses, rndB[4:8]...)
+		}
+	case len(key) == 24 && len(rndA) == 16:
+		// 3TDEA
+		kses = append(kses, rndA[0:4]...)
+		kses = append(kses, rndB[0:4]...)
+		kses = append(kses, rndA[6:10]...)
+		kses = append(kses, rndB[6:10]...)
+		kses = append(kses, rndA[12:16]...)
+		kses = append(kses, rndB[12:16]...)
+	default:
+		return nil, errors.New("len key is invalid")
+	}
+	return kses, nil
+}
 
-	d.cmdCtr = 0
+func (d *Desfire) setSessionKeyEV1(key, rndA, rndB []byte) error {
+	kses, err := sessionKeyEV1(key, rndA, rndB)
+	if err != nil {
+		return err
+	}
 
-	switch len(kex) {
-	case 8:
-		key := make([]byte, 0)
-		key = append(key, kex...)
-		key = append(key, kex...)
-		key = append(key, kex...)
-		// fmt.Printf("key 8 enc: [% X]\n", key)
-		d.keyEnc = key
-		block, err = des.NewTripleDESCipher(key)
+	var block cipher.Block
+	switch len(kses) {
 	case 16:
-		key := make([]byte, 0)
-		key = append(key, kex...)
-		key = append(key, kex[:8]...)
-		// fmt.Printf("key 16 enc: [% X]\n", key)
-		d.keyEnc = key
-		block, err = des.NewTripleDESCipher(key)
-	case 24:
-		key := make([]byte, 0)
-		key = append(key, kex[:]...)
-		// fmt.Printf("key 24 enc: [% X]\n", key)
-		d.keyEnc = key
-		block, err = des.NewTripleDESCipher(key)
+		if len(rndA) == 16 {
+			block, err = aes.NewCipher(kses)
+		} else {
+			block, err = des.NewTripleDESCipher(append(kses, kses[:8]...))
+		}
 	default:
-		return resp, errors.New("len key is invalid")
+		block, err = des.NewTripleDESCipher(kses)
 	}
 	if err != nil {
-		return resp, err
+		return err
 	}
+
+	d.evMode = EV1
+	d.keyEnc = kses
+	d.keyMac = kses
 	d.block = block
-	return resp, nil
+	d.blockMac = block
+	d.iv = make([]byte, block.BlockSize())
+	d.ti = nil
+	d.cmdCtr = 0
+
+	return nil
 }
 
 func Apdu_AuthenticateEV2First(secondAppIndicator int, keyNumber int, pcdCap2 []byte) []byte {
@@ -193,9 +413,13 @@ func Apdu_AuthenticateEV2FirstPart2(data []byte) []byte {
 // AuthenticateEV2First authentication for Keytype AES keys. After this authentication EV2 secure
 // messaging is used. This authentication in intended to be the first in a transaction.
 func (d *Desfire) AuthenticateEV2First(secondAppIndicator SecondAppIndicator, keyNumber int, pcdCap2 []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	apdu := Apdu_AuthenticateEV2First(secondAppIndicator.Int(), keyNumber, pcdCap2)
 
+	// E(Kx, RndB)
+	smartcard.RedactNext(d, nil, []smartcard.Span{{Offset: 1, Length: -1}})
 	resp, err := d.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -212,6 +436,8 @@ func (d *Desfire) AuthenticateEV2First(secondAppIndicator SecondAppIndicator, ke
 }
 
 func (d *Desfire) AuthenticateEV2FirstPart2(key, data []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	rand.Seed(time.Now().UnixNano())
 
@@ -243,9 +469,10 @@ func (d *Desfire) AuthenticateEV2FirstPart2(key, data []byte) ([]byte, error) {
 	mode.CryptBlocks(rndDc, rndD)
 
 	apdu := Apdu_AuthenticateEV2FirstPart2(rndDc)
+	// E(Kx, RndA || RndB'), E(Kx, TI || RndA' || PDcap2 || PCDcap2)
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
 	resp, err := d.Apdu(apdu)
 	if err != nil {
-		fmt.Printf("fail response: [ %X ], apdu: [ %X ]\n", resp, apdu)
 		return nil, err
 	}
 
@@ -253,6 +480,10 @@ func (d *Desfire) AuthenticateEV2FirstPart2(key, data []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	// E(Kx, TI || RndA' || PDcap2 || PCDcap2)
+	if len(resp[1:]) < 32 || len(resp[1:])%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("len E(Kx, TI || RndA' || PDcap2 || PCDcap2) = %d, %w", len(resp[1:]), ErrLengthError)
+	}
 	lastResp := make([]byte, len(resp[1:]))
 	mode = cipher.NewCBCDecrypter(block, iv[:])
 	mode.CryptBlocks(lastResp, resp[1:])
@@ -261,10 +492,23 @@ func (d *Desfire) AuthenticateEV2FirstPart2(key, data []byte) ([]byte, error) {
 	d.ti = append(d.ti, lastResp[:4]...)
 
 	d.pdCap2 = make([]byte, 0)
-	d.pdCap2 = append(d.pdCap2, lastResp[len(lastResp)-6:]...)
+	// TI || RndA' || PDcap2 || PCDcap2
+	d.pdCap2 = append(d.pdCap2, lastResp[20:26]...)
 
 	d.evMode = EV2
 
+	if err := d.setSessionKeysEV2(key, rndA, rndB); err != nil {
+		return resp, err
+	}
+
+	d.cmdCtr = 0
+
+	return resp, nil
+}
+
+// sessionVectorsEV2 session vectors SV1 and SV2 to derive the session keys
+// of the EV2 secure messaging from RndA and RndB
+func sessionVectorsEV2(rndA, rndB []byte) ([]byte, []byte) {
 	sv1 := []byte{0xA5, 0x5A, 0x00, 0x01, 0x00, 0x80}
 	sv2 := []byte{0x5A, 0xA5, 0x00, 0x01, 0x00, 0x80}
 
@@ -283,43 +527,40 @@ func (d *Desfire) AuthenticateEV2FirstPart2(key, data []byte) ([]byte, error) {
 	sv1 = append(sv1, trailing...)
 	sv2 = append(sv2, trailing...)
 
-	blockEnc, err := aes.NewCipher(key)
-	if err != nil {
-		return resp, err
-	}
+	return sv1, sv2
+}
 
-	ksesAuthEnc, err := cmac.Sum(sv1, blockEnc, 16)
-	if err != nil {
-		return resp, err
-	}
-	d.ksesAuthEnc = ksesAuthEnc
+// setSessionKeysEV2 derive the session keys of the EV2 secure messaging
+// with the authentication key
+func (d *Desfire) setSessionKeysEV2(key, rndA, rndB []byte) error {
+	sv1, sv2 := sessionVectorsEV2(rndA, rndB)
 
-	blockMack, err := aes.NewCipher(key)
+	ksesAuthEnc, err := sessionKeyEV2(key, sv1)
 	if err != nil {
-		return resp, err
+		return err
 	}
+	d.ksesAuthEnc = ksesAuthEnc
 
-	ksesAuthMac, err := cmac.Sum(sv2, blockMack, 16)
+	ksesAuthMac, err := sessionKeyEV2(key, sv2)
 	if err != nil {
-		return resp, err
+		return err
 	}
 	d.ksesAuthMac = ksesAuthMac
 
 	d.block, err = aes.NewCipher(ksesAuthEnc)
 	if err != nil {
-		return resp, err
+		return err
 	}
 	d.blockMac, err = aes.NewCipher(ksesAuthMac)
 	if err != nil {
-		return resp, err
+		return err
 	}
-
-	d.cmdCtr = 0
-
-	return resp, nil
+	return nil
 }
 
 func (d *Desfire) AuthenticateEV2FirstPart2_block_1(rndB []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	rand.Seed(time.Now().UnixNano())
 
@@ -343,11 +584,14 @@ func (d *Desfire) AuthenticateEV2FirstPart2_block_1(rndB []byte) ([]byte, error)
 
 }
 func (d *Desfire) AuthenticateEV2FirstPart2_block_2(rndDc []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	apdu := Apdu_AuthenticateEV2FirstPart2(rndDc)
+	// E(Kx, RndA || RndB'), E(Kx, TI || RndA' || PDcap2 || PCDcap2)
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
 	resp, err := d.Apdu(apdu)
 	if err != nil {
-		fmt.Printf("fail response: [ %X ], apdu: [ %X ]\n", resp, apdu)
 		return nil, err
 	}
 
@@ -359,38 +603,31 @@ func (d *Desfire) AuthenticateEV2FirstPart2_block_2(rndDc []byte) ([]byte, error
 
 }
 func (d *Desfire) AuthenticateEV2FirstPart2_block_3(lastResp []byte) ([]byte, []byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
+	// TI || RndA' || PDcap2 || PCDcap2
+	if len(lastResp) < 26 {
+		return nil, nil, fmt.Errorf("len TI || RndA' || PDcap2 || PCDcap2 = %d, %w", len(lastResp), ErrLengthError)
+	}
 	d.ti = make([]byte, 0)
 	d.ti = append(d.ti, lastResp[:4]...)
 
 	// log.Printf("TI: [ %X ]", d.ti)
 
 	d.pdCap2 = make([]byte, 0)
-	d.pdCap2 = append(d.pdCap2, lastResp[len(lastResp)-6:]...)
+	// TI || RndA' || PDcap2 || PCDcap2
+	d.pdCap2 = append(d.pdCap2, lastResp[20:26]...)
 
 	d.evMode = EV2
 
-	sv1 := []byte{0xA5, 0x5A, 0x00, 0x01, 0x00, 0x80}
-	sv2 := []byte{0x5A, 0xA5, 0x00, 0x01, 0x00, 0x80}
-
-	trailing := make([]byte, 0)
-	trailing = append(trailing, d.rndA[0:2]...)
-	xor := make([]byte, 0)
-	for _, indx := range []int{2, 3, 4, 5, 6, 7} {
-		xor = append(xor, d.rndA[indx])
-	}
-	for i, v := range d.rndB[0:6] {
-		trailing = append(trailing, xor[i]^v)
-	}
-	trailing = append(trailing, d.rndB[6:]...)
-	trailing = append(trailing, d.rndA[8:]...)
-
-	sv1 = append(sv1, trailing...)
-	sv2 = append(sv2, trailing...)
+	sv1, sv2 := sessionVectorsEV2(d.rndA, d.rndB)
 
 	return sv1, sv2, nil
 }
 func (d *Desfire) AuthenticateEV2FirstPart2_block_4(ksesAuthEnc, ksesAuthMac []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	var err error
 	d.ksesAuthEnc = make([]byte, len(ksesAuthEnc))
@@ -413,10 +650,102 @@ func (d *Desfire) AuthenticateEV2FirstPart2_block_4(ksesAuthEnc, ksesAuthMac []b
 	return nil
 }
 
-func (d *Desfire) AuthenticateEV2NonFirst() ([]byte, error) {
-	panic("not implemented") // TODO: Implement
+func Apdu_AuthenticateEV2NonFirst(secondAppIndicator int, keyNumber int) []byte {
+	cmd := byte(0x77)
+	keyNo := byte(keyNumber) | byte(secondAppIndicator<<7)
+
+	apdu := make([]byte, 0)
+
+	apdu = append(apdu, cmd)
+	apdu = append(apdu, keyNo)
+
+	return apdu
+}
+
+// AuthenticateEV2NonFirst authentication for Keytype AES keys after an
+// AuthenticateEV2First in the same transaction. The transaction identifier
+// (TI) and the command counter are kept, only the session keys change.
+func (d *Desfire) AuthenticateEV2NonFirst(secondAppIndicator SecondAppIndicator, keyNumber int) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.evMode != EV2 || len(d.ti) != 4 {
+		return nil, errors.New("AuthenticateEV2NonFirst without a previous AuthenticateEV2First")
+	}
+
+	apdu := Apdu_AuthenticateEV2NonFirst(secondAppIndicator.Int(), keyNumber)
+
+	// E(Kx, RndB)
+	smartcard.RedactNext(d, nil, []smartcard.Span{{Offset: 1, Length: -1}})
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyResponse(resp); err != nil {
+		return resp, err
+	}
+
+	d.lastKey = keyNumber
+
+	return resp[1:], nil
 }
 
-func (d *Desfire) AuthenticateEV2NonFirstPart2() ([]byte, error) {
-	panic("not implemented") // TODO: Implement
+// AuthenticateEV2NonFirstPart2 second part of AuthenticateEV2NonFirst with
+// the key and the response of the first part, E(Kx, RndB)
+func (d *Desfire) AuthenticateEV2NonFirstPart2(key, data []byte) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != block.BlockSize() {
+		return nil, fmt.Errorf("len E(Kx, RndB) = %d, %w", len(data), ErrLengthError)
+	}
+	iv := make([]byte, block.BlockSize())
+
+	rndB := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(rndB, data)
+	rndA := make([]byte, len(rndB))
+	if _, err := crand.Read(rndA); err != nil {
+		return nil, err
+	}
+
+	rndD := make([]byte, 0)
+	rndD = append(rndD, rndA...)
+	rndD = append(rndD, rndB[1:]...)
+	rndD = append(rndD, rndB[0])
+
+	rndDc := make([]byte, len(rndD))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(rndDc, rndD)
+
+	apdu := Apdu_AuthenticateEV2FirstPart2(rndDc)
+	// E(Kx, RndA || RndB'), E(Kx, RndA')
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyResponse(resp); err != nil {
+		return nil, err
+	}
+	if len(resp[1:]) != block.BlockSize() {
+		return nil, fmt.Errorf("len E(Kx, RndA') = %d, %w", len(resp[1:]), ErrLengthError)
+	}
+
+	rndAr := make([]byte, len(resp[1:]))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(rndAr, resp[1:])
+	rndAr = append(rndAr[len(rndAr)-1:], rndAr[:len(rndAr)-1]...)
+	if !bytes.Equal(rndAr, rndA) {
+		return nil, fmt.Errorf("RndA' mismatch, %w", ErrAuthenticationError)
+	}
+
+	if err := d.setSessionKeysEV2(key, rndA, rndB); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
 }
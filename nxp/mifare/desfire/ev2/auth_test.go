@@ -1,9 +1,12 @@
 package ev2
 
 import (
+	"bytes"
+	"crypto/aes"
 	"crypto/cipher"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"hash/crc32"
 	"log"
 	"reflect"
@@ -12,20 +15,20 @@ import (
 
 	"github.com/dumacp/smartcard"
 	"github.com/dumacp/smartcard/pcsc"
+	"github.com/dumacp/smartcard/sim"
 )
 
-func Test_desfire_AuthenticateEV2First(t *testing.T) {
-
+// piccReader PICC reader of PC/SC, the hardware tests are skipped without it
+func piccReader(t *testing.T) pcsc.Reader {
+	t.Helper()
 	ctx, err := pcsc.NewContext()
 	if err != nil {
-		log.Fatal(err)
+		t.Skipf("without PC/SC context: %s", err)
 	}
-
 	readers, err := ctx.ListReaders()
 	if err != nil {
-		log.Fatal(err)
+		t.Skipf("without PC/SC readers: %s", err)
 	}
-
 	var reader pcsc.Reader
 	for i, r := range readers {
 		log.Printf("reader %q: %s", i, r)
@@ -33,6 +36,15 @@ func Test_desfire_AuthenticateEV2First(t *testing.T) {
 			reader = pcsc.NewReader(ctx, r)
 		}
 	}
+	if reader == nil {
+		t.Skip("without PC/SC PICC reader")
+	}
+	return reader
+}
+
+func Test_desfire_AuthenticateEV2First(t *testing.T) {
+
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
@@ -119,23 +131,7 @@ func Test_desfire_AuthenticateEV2First(t *testing.T) {
 
 func Test_desfire_GetApplicationsID(t *testing.T) {
 
-	ctx, err := pcsc.NewContext()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	readers, err := ctx.ListReaders()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var reader pcsc.Reader
-	for i, r := range readers {
-		log.Printf("reader %q: %s", i, r)
-		if strings.Contains(r, "PICC") {
-			reader = pcsc.NewReader(ctx, r)
-		}
-	}
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
@@ -249,23 +245,7 @@ func Test_desfire_CRC32(t *testing.T) {
 }
 
 func Test_desfire_AuthenticateISO(t *testing.T) {
-	ctx, err := pcsc.NewContext()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	readers, err := ctx.ListReaders()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var reader pcsc.Reader
-	for i, r := range readers {
-		log.Printf("reader %q: %s", i, r)
-		if strings.Contains(r, "PICC") {
-			reader = pcsc.NewReader(ctx, r)
-		}
-	}
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
@@ -392,7 +372,23 @@ func TestDesfire_AuthenticateEV2FirstPart2(t *testing.T) {
 		want    []byte
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "short response",
+			fields: fields{
+				ICard: func() smartcard.ICard {
+					c := sim.NewCard(nil, nil, nil, 0x20)
+					c.Handle([]byte{0xAF}, func(apdu []byte) ([]byte, error) {
+						return append([]byte{0x00}, make([]byte, 16)...), nil
+					})
+					return c
+				}(),
+			},
+			args: args{
+				key:  make([]byte, 16),
+				data: make([]byte, 16),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -424,3 +420,95 @@ func TestDesfire_AuthenticateEV2FirstPart2(t *testing.T) {
 		})
 	}
 }
+
+// nonFirstCard simulates the PICC side of AuthenticateEV2NonFirst with key
+func nonFirstCard(key, rndB []byte, badRndA bool) *sim.Card {
+	block, _ := aes.NewCipher(key)
+	iv := make([]byte, aes.BlockSize)
+	c := sim.NewCard(nil, nil, nil, 0x20)
+	c.Handle([]byte{0x77}, func(apdu []byte) ([]byte, error) {
+		rndBc := make([]byte, len(rndB))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(rndBc, rndB)
+		return append([]byte{0xAF}, rndBc...), nil
+	})
+	c.Handle([]byte{0xAF}, func(apdu []byte) ([]byte, error) {
+		rndD := make([]byte, len(apdu[1:]))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(rndD, apdu[1:])
+		rndBr := append(append([]byte{}, rndB[1:]...), rndB[0])
+		if !bytes.Equal(rndD[16:], rndBr) {
+			return []byte{0xAE}, nil
+		}
+		rndAr := append(append([]byte{}, rndD[1:16]...), rndD[0])
+		if badRndA {
+			rndAr[0] ^= 0xFF
+		}
+		rndArc := make([]byte, len(rndAr))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(rndArc, rndAr)
+		return append([]byte{0x00}, rndArc...), nil
+	})
+	return c
+}
+
+func TestDesfire_AuthenticateEV2NonFirst(t *testing.T) {
+	key := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	rndB := []byte{0x91, 0x51, 0x7B, 0x24, 0x0C, 0x7A, 0x5E, 0x25,
+		0x8E, 0x95, 0x15, 0xC1, 0x43, 0x9A, 0x02, 0x7E}
+	ti := []byte{0x9D, 0x00, 0xC4, 0xDF}
+	tests := []struct {
+		name       string
+		first      bool
+		badRndA    bool
+		wantErr    bool
+		wantErrIs  error
+		wantCmdCtr uint16
+	}{
+		{
+			name:       "after first",
+			first:      true,
+			wantCmdCtr: 3,
+		},
+		{
+			name:    "without first",
+			wantErr: true,
+		},
+		{
+			name:      "wrong RndA'",
+			first:     true,
+			badRndA:   true,
+			wantErr:   true,
+			wantErrIs: ErrAuthenticationError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDesfire(nonFirstCard(key, rndB, tt.badRndA))
+			if tt.first {
+				d.evMode = EV2
+				d.ti = append([]byte{}, ti...)
+				d.cmdCtr = 3
+			}
+			data, err := d.AuthenticateEV2NonFirst(TargetPrimaryApp, 1)
+			if err == nil {
+				_, err = d.AuthenticateEV2NonFirstPart2(key, data)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthenticateEV2NonFirst() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("AuthenticateEV2NonFirst() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(d.ti, ti) || d.cmdCtr != tt.wantCmdCtr {
+				t.Errorf("AuthenticateEV2NonFirst() TI = [% X], cmdCtr = %d, want [% X], %d",
+					d.ti, d.cmdCtr, ti, tt.wantCmdCtr)
+			}
+			if len(d.ksesAuthEnc) != 16 || len(d.ksesAuthMac) != 16 || d.lastKey != 1 {
+				t.Errorf("AuthenticateEV2NonFirst() session keys [% X] [% X], lastKey %d",
+					d.ksesAuthEnc, d.ksesAuthMac, d.lastKey)
+			}
+		})
+	}
+}
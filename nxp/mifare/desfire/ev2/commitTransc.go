@@ -9,6 +9,8 @@ import "errors"
 func (d *Desfire) CommitTransaction(
 	return_TMC_and_TMV bool,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xC7)
 
@@ -28,7 +30,11 @@ func (d *Desfire) CommitTransaction(
 			return nil, err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
+		if _, err := d.commandEV1(cmd, cmdHeader, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
 	}
@@ -45,8 +51,14 @@ func (d *Desfire) CommitTransaction(
 	switch d.evMode {
 	case EV2:
 		responseData = resp[1 : len(resp)-8]
+	case D40:
+		responseData = resp[1:]
 	case EV1:
-		return nil, errors.New("only desfire EV2 mode support")
+		var err error
+		responseData, err = d.responseEV1(resp[0], resp[1:], PLAIN)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only desfire EV2 mode support")
 	}
@@ -56,7 +68,7 @@ func (d *Desfire) CommitTransaction(
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return responseData, nil
 	default:
 		return nil, errors.New("only EV2 mode support")
@@ -67,6 +79,8 @@ func (d *Desfire) CommitTransaction(
 // FileType.LinearRecord and Filetype.CyclicRecord files within the selected
 // application(s). If applicable, theTransaction MAC calculation is aborted.
 func (d *Desfire) AbortTransaction() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xA7)
 
@@ -81,7 +95,11 @@ func (d *Desfire) AbortTransaction() error {
 			return err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
+		if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -95,7 +113,10 @@ func (d *Desfire) AbortTransaction() error {
 	}
 
 	switch d.evMode {
-	case EV1, EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 support")
@@ -103,10 +124,13 @@ func (d *Desfire) AbortTransaction() error {
 }
 
 // CommitReaderID commit reader ID for a ongoing transacion. This will allow a backend
-// to identified the attacking merchant in case of fraud detetcted.
+// to identified the attacking merchant in case of fraud detetcted. The response is
+// the EncTMRI of the authenticated session and empty without authentication.
 func (d *Desfire) CommitReaderID(
 	tmri []byte,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(tmri) != 16 {
 		return nil, errors.New("only 16 bytes is allowed")
@@ -130,9 +154,13 @@ func (d *Desfire) CommitReaderID(
 			return nil, err
 		}
 		apdu = append(apdu, cmacT...)
-	case EV1:
+	case D40:
+		// without authentication if AppCommitReaderIDKey is FREE
+		if d.block != nil {
+			return nil, errors.New("only EV2 mode support")
+		}
 	default:
-		return nil, errors.New("only EV1 and Ev2 support")
+		return nil, errors.New("only EV2 mode support")
 	}
 
 	resp, err := d.Apdu(apdu)
@@ -143,24 +171,16 @@ func (d *Desfire) CommitReaderID(
 		return nil, err
 	}
 
-	var responseData []byte
-	switch d.evMode {
-	case EV2:
-		responseData = resp[1 : len(resp)-8]
-	case EV1:
-		return nil, errors.New("only desfire EV2 mode support")
-	default:
-		return nil, errors.New("only desfire EV2 mode support")
-	}
-
 	defer func() {
 		d.cmdCtr++
 	}()
 
+	// EncTMRI, the previous TMRI encrypted with the TM session key (only
+	// with authentication)
 	switch d.evMode {
 	case EV2:
-		return responseData, nil
+		return resp[1 : len(resp)-8], nil
 	default:
-		return nil, errors.New("only EV2 mode support")
+		return resp[1:], nil
 	}
 }
@@ -12,6 +12,8 @@ func (d *Desfire) ReadData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	length int,
 	commMode CommMode,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if offset > 0xFFFFFF {
 		return nil, errors.New("wrong len (not 3) in \"offset\"")
@@ -21,6 +23,10 @@ func (d *Desfire) ReadData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}
 
 	cmd := 0xAD
+	if d.evMode != EV2 {
+		// EV1 and D40 only support the native chaining
+		cmd = 0xBD
+	}
 
 	apdu := make([]byte, 0)
 	apdu = append(apdu, byte(cmd))
@@ -55,8 +61,13 @@ func (d *Desfire) ReadData(fileNo int, targetSecondaryApp SecondAppIndicator,
 			case PLAIN:
 			default:
 			}
+		case D40:
 		case EV1:
-			return nil, errors.New("only EV1 and Ev2 support")
+			if cmdHeader != nil {
+				if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, errors.New("only EV1 and Ev2 support")
 		}
@@ -85,8 +96,9 @@ func (d *Desfire) ReadData(fileNo int, targetSecondaryApp SecondAppIndicator,
 			default:
 				responseData = resp[1:]
 			}
-		case EV1:
-			return nil, errors.New("only desfire EV2 mode support")
+		case D40, EV1:
+			// the secure messaging covers the data of all the frames
+			responseData = resp[1:]
 		default:
 			return nil, errors.New("only desfire EV2 mode support")
 		}
@@ -104,6 +116,10 @@ func (d *Desfire) ReadData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
+	case D40:
+		return d.responseD40(response, commMode)
+	case EV1:
+		return d.responseEV1(0x00, response, commMode)
 	case EV2:
 		return response, nil
 	default:
@@ -117,6 +133,8 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	datafile []byte,
 	commMode CommMode,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if offset > 0xFFFFFF {
 		return errors.New("wrong len (not 3) in \"offset\"")
@@ -127,6 +145,10 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}
 
 	cmd := 0x8D
+	if d.evMode != EV2 {
+		// EV1 and D40 only support the native chaining
+		cmd = 0x3D
+	}
 
 	apdu := make([]byte, 0)
 	apdu = append(apdu, byte(cmd))
@@ -151,6 +173,22 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	datafile_copy := make([]byte, len(datafile))
 	copy(datafile_copy, datafile)
 
+	// the secure messaging covers the data of all the frames
+	switch d.evMode {
+	case D40:
+		var err error
+		datafile_copy, err = d.commandD40(datafile, commMode)
+		if err != nil {
+			return err
+		}
+	case EV1:
+		var err error
+		datafile_copy, err = d.commandEV1(byte(cmd), cmdHeader, datafile, commMode)
+		if err != nil {
+			return err
+		}
+	}
+
 	for len(datafile_copy) > 0 {
 
 		var data []byte
@@ -190,8 +228,8 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 			case PLAIN:
 			default:
 			}
-		case EV1:
-			return errors.New("only EV1 and Ev2 support")
+		case D40, EV1:
+			apdu = append(apdu, data...)
 		default:
 			return errors.New("only EV1 and Ev2 support")
 		}
@@ -204,7 +242,12 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 			return err
 		}
 
-		// TODO: verify MAC
+		// TODO: verify MAC of EV2
+		if d.evMode == EV1 && resp[0] == 0x00 {
+			if _, err := d.responseEV1(resp[0], resp[1:], PLAIN); err != nil {
+				return err
+			}
+		}
 
 		if resp[0] == 0x00 {
 			break
@@ -217,7 +260,7 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -228,6 +271,8 @@ func (d *Desfire) WriteData(fileNo int, targetSecondaryApp SecondAppIndicator,
 func (d *Desfire) GetValue(fileNo int, targetSecondaryApp SecondAppIndicator,
 	commMode CommMode,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x6C
 
@@ -251,8 +296,11 @@ func (d *Desfire) GetValue(fileNo int, targetSecondaryApp SecondAppIndicator,
 		case PLAIN:
 		default:
 		}
+	case D40:
 	case EV1:
-		return nil, errors.New("only EV1 and Ev2 support")
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
 	}
@@ -281,8 +329,18 @@ func (d *Desfire) GetValue(fileNo int, targetSecondaryApp SecondAppIndicator,
 		default:
 			responseData = resp[1:]
 		}
+	case D40:
+		var err error
+		responseData, err = d.responseD40(resp[1:], commMode)
+		if err != nil {
+			return nil, err
+		}
 	case EV1:
-		return nil, errors.New("only desfire EV2 mode support")
+		var err error
+		responseData, err = d.responseEV1(resp[0], resp[1:], commMode)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only desfire EV2 mode support")
 	}
@@ -291,7 +349,7 @@ func (d *Desfire) GetValue(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return responseData[0:4], nil
 	default:
 		return nil, errors.New("only EV2 mode support")
@@ -303,6 +361,8 @@ func (d *Desfire) Credit(fileNo int, targetSecondaryApp SecondAppIndicator,
 	value uint,
 	commMode CommMode,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x0C
 
@@ -344,8 +404,18 @@ func (d *Desfire) Credit(fileNo int, targetSecondaryApp SecondAppIndicator,
 		case PLAIN:
 		default:
 		}
+	case D40:
+		payload, err := d.commandD40(data, commMode)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, payload...)
 	case EV1:
-		return errors.New("only EV1 and Ev2 support")
+		payload, err := d.commandEV1(byte(cmd), cmdHeader, data, commMode)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, payload...)
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -380,7 +450,10 @@ func (d *Desfire) Credit(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -393,6 +466,8 @@ func (d *Desfire) LimitedCredit(fileNo int, targetSecondaryApp SecondAppIndicato
 	value uint,
 	commMode CommMode,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x1C
 
@@ -434,8 +509,18 @@ func (d *Desfire) LimitedCredit(fileNo int, targetSecondaryApp SecondAppIndicato
 		case PLAIN:
 		default:
 		}
+	case D40:
+		payload, err := d.commandD40(data, commMode)
+		if err != nil {
+			return nil, err
+		}
+		apdu = append(apdu, payload...)
 	case EV1:
-		return nil, errors.New("only EV1 and Ev2 support")
+		payload, err := d.commandEV1(byte(cmd), cmdHeader, data, commMode)
+		if err != nil {
+			return nil, err
+		}
+		apdu = append(apdu, payload...)
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
 	}
@@ -457,8 +542,14 @@ func (d *Desfire) LimitedCredit(fileNo int, targetSecondaryApp SecondAppIndicato
 		default:
 			responseData = resp[1:]
 		}
+	case D40:
+		responseData = resp[1:]
 	case EV1:
-		return nil, errors.New("only desfire EV2 mode support")
+		var err error
+		responseData, err = d.responseEV1(resp[0], resp[1:], PLAIN)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only desfire EV2 mode support")
 	}
@@ -468,7 +559,7 @@ func (d *Desfire) LimitedCredit(fileNo int, targetSecondaryApp SecondAppIndicato
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return responseData, nil
 	default:
 		return nil, errors.New("only EV2 mode support")
@@ -480,6 +571,8 @@ func (d *Desfire) Debit(fileNo int, targetSecondaryApp SecondAppIndicator,
 	value uint,
 	commMode CommMode,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0xDC
 
@@ -521,8 +614,18 @@ func (d *Desfire) Debit(fileNo int, targetSecondaryApp SecondAppIndicator,
 		case PLAIN:
 		default:
 		}
+	case D40:
+		payload, err := d.commandD40(data, commMode)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, payload...)
 	case EV1:
-		return errors.New("only EV1 and Ev2 support")
+		payload, err := d.commandEV1(byte(cmd), cmdHeader, data, commMode)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, payload...)
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -555,7 +658,10 @@ func (d *Desfire) Debit(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -570,6 +676,8 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 	sizeRecord int,
 	commMode CommMode,
 ) ([][]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if recNo > 0xFFFFFF {
 		return nil, errors.New("wrong len (not 3) in \"recNo\"")
@@ -579,6 +687,10 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}
 
 	cmd := 0xAB
+	if d.evMode != EV2 {
+		// EV1 and D40 only support the native chaining
+		cmd = 0xBB
+	}
 
 	apdu := make([]byte, 0)
 	apdu = append(apdu, byte(cmd))
@@ -613,8 +725,13 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 			case PLAIN:
 			default:
 			}
+		case D40:
 		case EV1:
-			return nil, errors.New("only EV1 and Ev2 support")
+			if cmdHeader != nil {
+				if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, errors.New("only EV1 and Ev2 support")
 		}
@@ -647,8 +764,9 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 			default:
 				responseData = resp[1:]
 			}
-		case EV1:
-			return nil, errors.New("only desfire EV2 mode support")
+		case D40, EV1:
+			// the secure messaging covers the data of all the frames
+			responseData = resp[1:]
 		default:
 			return nil, errors.New("only desfire EV2 mode support")
 		}
@@ -664,6 +782,21 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 		d.cmdCtr++
 	}()
 
+	switch d.evMode {
+	case D40:
+		var err error
+		response, err = d.responseD40(response, commMode)
+		if err != nil {
+			return nil, err
+		}
+	case EV1:
+		var err error
+		response, err = d.responseEV1(0x00, response, commMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	result := make([][]byte, 0)
 
 	for len(response) > sizeRecord {
@@ -675,7 +808,7 @@ func (d *Desfire) ReadRecords(fileNo int, targetSecondaryApp SecondAppIndicator,
 	result = append(result, response)
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return result, nil
 	default:
 		return nil, errors.New("only EV2 mode support")
@@ -688,6 +821,8 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 	dataRecord []byte,
 	commMode CommMode,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if offset > 0xFFFFFF {
 		return errors.New("wrong len (not 3) in \"offset\"")
@@ -698,6 +833,10 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}
 
 	cmd := 0x8B
+	if d.evMode != EV2 {
+		// EV1 and D40 only support the native chaining
+		cmd = 0x3B
+	}
 
 	apdu := make([]byte, 0)
 	apdu = append(apdu, byte(cmd))
@@ -722,6 +861,22 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 	datafile_copy := make([]byte, len(dataRecord))
 	copy(datafile_copy, dataRecord)
 
+	// the secure messaging covers the data of all the frames
+	switch d.evMode {
+	case D40:
+		var err error
+		datafile_copy, err = d.commandD40(dataRecord, commMode)
+		if err != nil {
+			return err
+		}
+	case EV1:
+		var err error
+		datafile_copy, err = d.commandEV1(byte(cmd), cmdHeader, dataRecord, commMode)
+		if err != nil {
+			return err
+		}
+	}
+
 	for len(datafile_copy) > 0 {
 
 		var data []byte
@@ -761,8 +916,8 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 			case PLAIN:
 			default:
 			}
-		case EV1:
-			return errors.New("only EV1 and Ev2 support")
+		case D40, EV1:
+			apdu = append(apdu, data...)
 		default:
 			return errors.New("only EV1 and Ev2 support")
 		}
@@ -775,7 +930,12 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 			return err
 		}
 
-		// TODO: verify MAC
+		// TODO: verify MAC of EV2
+		if d.evMode == EV1 && resp[0] == 0x00 {
+			if _, err := d.responseEV1(resp[0], resp[1:], PLAIN); err != nil {
+				return err
+			}
+		}
 
 		if resp[0] == 0x00 {
 			break
@@ -788,7 +948,7 @@ func (d *Desfire) WriteRecord(fileNo int, targetSecondaryApp SecondAppIndicator,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -803,6 +963,8 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 	dataRecord []byte,
 	commMode CommMode,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if offset > 0xFFFFFF {
 		return errors.New("wrong len (not 3) in \"offset\"")
@@ -812,7 +974,7 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 		return errors.New("wrong len (max 0xFFFFFF) in \"len(dataRecord)\"")
 	}
 
-	cmd := 0x8B
+	cmd := 0xDB
 
 	apdu := make([]byte, 0)
 	apdu = append(apdu, byte(cmd))
@@ -843,6 +1005,22 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 	datafile_copy := make([]byte, len(dataRecord))
 	copy(datafile_copy, dataRecord)
 
+	// the secure messaging covers the data of all the frames
+	switch d.evMode {
+	case D40:
+		var err error
+		datafile_copy, err = d.commandD40(dataRecord, commMode)
+		if err != nil {
+			return err
+		}
+	case EV1:
+		var err error
+		datafile_copy, err = d.commandEV1(byte(cmd), cmdHeader, dataRecord, commMode)
+		if err != nil {
+			return err
+		}
+	}
+
 	for len(datafile_copy) > 0 {
 
 		var data []byte
@@ -882,8 +1060,8 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 			case PLAIN:
 			default:
 			}
-		case EV1:
-			return errors.New("only EV1 and Ev2 support")
+		case D40, EV1:
+			apdu = append(apdu, data...)
 		default:
 			return errors.New("only EV1 and Ev2 support")
 		}
@@ -896,7 +1074,12 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 			return err
 		}
 
-		// TODO: verify MAC
+		// TODO: verify MAC of EV2
+		if d.evMode == EV1 && resp[0] == 0x00 {
+			if _, err := d.responseEV1(resp[0], resp[1:], PLAIN); err != nil {
+				return err
+			}
+		}
 
 		if resp[0] == 0x00 {
 			break
@@ -909,7 +1092,7 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case D40, EV1, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -920,6 +1103,8 @@ func (d *Desfire) UpdateRecord(fileNo int, targetSecondaryApp SecondAppIndicator
 // file.
 func (d *Desfire) ClearRecordFile(fileNo int, targetSecondaryApp SecondAppIndicator,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0xEB
 
@@ -938,8 +1123,11 @@ func (d *Desfire) ClearRecordFile(fileNo int, targetSecondaryApp SecondAppIndica
 			return err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
-		return errors.New("only EV1 and Ev2 support")
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -956,7 +1144,10 @@ func (d *Desfire) ClearRecordFile(fileNo int, targetSecondaryApp SecondAppIndica
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
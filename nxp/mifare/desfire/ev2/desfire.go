@@ -13,7 +13,8 @@ package ev2
 import (
 	"crypto/cipher"
 	"errors"
-	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/dumacp/smartcard"
 )
@@ -48,9 +49,13 @@ const (
 	TargetSecondaryApp
 )
 
-//Desfire desfire card
+//Desfire desfire card. The methods are safe for concurrent use, but the
+//sequences of commands (authentication and secure messaging) must run in a
+//transaction of smartcard.Session: create the Desfire from the Transaction.
 type Desfire struct {
 	smartcard.ICard
+	// mux guards the session state (ti, cmdCtr, keys)
+	mux          sync.Mutex
 	rndA         []byte
 	rndB         []byte
 	ti           []byte
@@ -67,6 +72,9 @@ type Desfire struct {
 	blockMac     cipher.Block
 	ksesAuthEnc  []byte
 	ksesAuthMac  []byte
+	// isoWrapping the commands are wrapped in ISO 7816-4 APDUs, it is read
+	// by Apdu without mux (the commands hold mux)
+	isoWrapping atomic.Bool
 }
 
 //NewDesfire Create Desfire from Card
@@ -85,9 +93,19 @@ func VerifyResponse(resp []byte) error {
 	if len(resp) <= 0 {
 		return errors.New("error in response: nil response")
 	}
-	return fmt.Errorf("error in response:, code error: %X, response: [% X]", resp[0], resp)
+	return StatusError(resp[0])
 }
 
 func (d *Desfire) GetModeEV() EVmode {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 	return d.evMode
 }
+
+// PDCap2 the capabilities of the card (PDcap2) in the response of
+// AuthenticateEV2First
+func (d *Desfire) PDCap2() []byte {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.pdCap2
+}
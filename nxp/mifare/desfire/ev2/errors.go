@@ -0,0 +1,61 @@
+package ev2
+
+import (
+	"errors"
+
+	"github.com/dumacp/smartcard/nxp"
+)
+
+// DESFire status codes
+var (
+	ErrNoChanges           = errors.New("NO_CHANGES")
+	ErrOutOfEEPROM         = errors.New("OUT_OF_EEPROM_ERROR")
+	ErrIllegalCommandCode  = errors.New("ILLEGAL_COMMAND_CODE")
+	ErrIntegrityError      = errors.New("INTEGRITY_ERROR")
+	ErrNoSuchKey           = errors.New("NO_SUCH_KEY")
+	ErrLengthError         = errors.New("LENGTH_ERROR")
+	ErrPermissionDenied    = errors.New("PERMISSION_DENIED")
+	ErrParameterError      = errors.New("PARAMETER_ERROR")
+	ErrApplicationNotFound = errors.New("APPLICATION_NOT_FOUND")
+	ErrApplIntegrityError  = errors.New("APPL_INTEGRITY_ERROR")
+	ErrAuthenticationError = errors.New("AUTHENTICATION_ERROR")
+	ErrBoundaryError       = errors.New("BOUNDARY_ERROR")
+	ErrPICCIntegrityError  = errors.New("PICC_INTEGRITY_ERROR")
+	ErrCommandAborted      = errors.New("COMMAND_ABORTED")
+	ErrPICCDisabledError   = errors.New("PICC_DISABLED_ERROR")
+	ErrCountError          = errors.New("COUNT_ERROR")
+	ErrDuplicateError      = errors.New("DUPLICATE_ERROR")
+	ErrEEPROMError         = errors.New("EEPROM_ERROR")
+	ErrFileNotFound        = errors.New("FILE_NOT_FOUND")
+	ErrFileIntegrityError  = errors.New("FILE_INTEGRITY_ERROR")
+)
+
+// Status sentinels of the DESFire status codes, as ISO 7816 status word
+// (0x91XX)
+var Status = map[uint16]error{
+	0x910C: ErrNoChanges,
+	0x910E: ErrOutOfEEPROM,
+	0x911C: ErrIllegalCommandCode,
+	0x911E: ErrIntegrityError,
+	0x9140: ErrNoSuchKey,
+	0x917E: ErrLengthError,
+	0x919D: ErrPermissionDenied,
+	0x919E: ErrParameterError,
+	0x91A0: ErrApplicationNotFound,
+	0x91A1: ErrApplIntegrityError,
+	0x91AE: ErrAuthenticationError,
+	0x91BE: ErrBoundaryError,
+	0x91C1: ErrPICCIntegrityError,
+	0x91CA: ErrCommandAborted,
+	0x91CD: ErrPICCDisabledError,
+	0x91CE: ErrCountError,
+	0x91DE: ErrDuplicateError,
+	0x91EE: ErrEEPROMError,
+	0x91F0: ErrFileNotFound,
+	0x91F1: ErrFileIntegrityError,
+}
+
+// StatusError error of the DESFire status code
+func StatusError(code byte) error {
+	return nxp.StatusError(0x9100|uint16(code), Status)
+}
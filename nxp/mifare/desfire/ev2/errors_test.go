@@ -0,0 +1,70 @@
+package ev2
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/nxp"
+)
+
+func TestVerifyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    error
+		wantSW  uint16
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			resp: []byte{0x00, 0x01},
+		},
+		{
+			name: "additional frame",
+			resp: []byte{0xAF, 0x01},
+		},
+		{
+			name:    "authentication error",
+			resp:    []byte{0xAE},
+			want:    ErrAuthenticationError,
+			wantSW:  0x91AE,
+			wantErr: true,
+		},
+		{
+			name:    "permission denied",
+			resp:    []byte{0x9D},
+			want:    ErrPermissionDenied,
+			wantSW:  0x919D,
+			wantErr: true,
+		},
+		{
+			name:    "unknown status",
+			resp:    []byte{0x55},
+			want:    nxp.ErrUnknownStatus,
+			wantSW:  0x9155,
+			wantErr: true,
+		},
+		{
+			name:    "nil response",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyResponse(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyResponse() error = %v, want %v", err, tt.want)
+			}
+			var errResp *nxp.ErrorResponse
+			if !errors.As(err, &errResp) || errResp.SW != tt.wantSW {
+				t.Errorf("VerifyResponse() error = %v, want SW %04X", err, tt.wantSW)
+			}
+		})
+	}
+}
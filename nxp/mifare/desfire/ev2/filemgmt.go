@@ -47,6 +47,8 @@ func (d *Desfire) CreateStdDataFile(fileNo int, targetSecondaryApp SecondAppIndi
 	accessRights_Change AccessRights,
 	fileSize int,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xCD)
 
@@ -94,6 +96,11 @@ func (d *Desfire) CreateStdDataFile(fileNo int, targetSecondaryApp SecondAppIndi
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -110,7 +117,10 @@ func (d *Desfire) CreateStdDataFile(fileNo int, targetSecondaryApp SecondAppIndi
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -130,6 +140,8 @@ func (d *Desfire) CreateBackupDataFile(fileNo int, targetSecondaryApp SecondAppI
 	accessRights_Change AccessRights,
 	fileSize int,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xCB)
 
@@ -178,6 +190,11 @@ func (d *Desfire) CreateBackupDataFile(fileNo int, targetSecondaryApp SecondAppI
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -194,7 +211,10 @@ func (d *Desfire) CreateBackupDataFile(fileNo int, targetSecondaryApp SecondAppI
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -215,6 +235,8 @@ func (d *Desfire) CreateValueFile(fileNo int, targetSecondaryApp SecondAppIndica
 	limitedCreditEnabled bool,
 	freeAccesstoGetValue bool,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xCC)
 
@@ -287,6 +309,11 @@ func (d *Desfire) CreateValueFile(fileNo int, targetSecondaryApp SecondAppIndica
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -303,7 +330,10 @@ func (d *Desfire) CreateValueFile(fileNo int, targetSecondaryApp SecondAppIndica
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -323,6 +353,8 @@ func (d *Desfire) CreateLinearRecorFile(fileNo int, targetSecondaryApp SecondApp
 	accessRights_Change AccessRights,
 	recordSize, maxNoOfRecords int,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xC1)
 
@@ -379,6 +411,11 @@ func (d *Desfire) CreateLinearRecorFile(fileNo int, targetSecondaryApp SecondApp
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -395,7 +432,10 @@ func (d *Desfire) CreateLinearRecorFile(fileNo int, targetSecondaryApp SecondApp
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -416,6 +456,8 @@ func (d *Desfire) CreateCyclicRecorFile(fileNo int, targetSecondaryApp SecondApp
 	accessRights_Change AccessRights,
 	recordSize, maxNoOfRecords int,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xC0)
 
@@ -473,6 +515,11 @@ func (d *Desfire) CreateCyclicRecorFile(fileNo int, targetSecondaryApp SecondApp
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -489,7 +536,10 @@ func (d *Desfire) CreateCyclicRecorFile(fileNo int, targetSecondaryApp SecondApp
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -508,6 +558,8 @@ func (d *Desfire) CreateTransactionMACFile(fileNo int, targetSecondaryApp Second
 	tmKeyVersion int,
 	tmKeyOption_keyType KeyType,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xCE)
 
@@ -591,6 +643,8 @@ func (d *Desfire) CreateTransactionMACFile(fileNo int, targetSecondaryApp Second
 func (d *Desfire) DeleteFile(fileNo int,
 	targetSecondaryApp SecondAppIndicator,
 ) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xDF)
 
@@ -608,6 +662,11 @@ func (d *Desfire) DeleteFile(fileNo int,
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -624,7 +683,10 @@ func (d *Desfire) DeleteFile(fileNo int,
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -634,6 +696,8 @@ func (d *Desfire) DeleteFile(fileNo int,
 // GetFileIDs returns the File IDentifiers of all active files within the current selected
 // application.
 func (d *Desfire) GetFileIDs() ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0x6F)
 
@@ -647,6 +711,11 @@ func (d *Desfire) GetFileIDs() ([]byte, error) {
 			return nil, err
 		}
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV2 mode support")
 	}
@@ -663,6 +732,10 @@ func (d *Desfire) GetFileIDs() ([]byte, error) {
 	}()
 
 	switch d.evMode {
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
 	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
@@ -674,6 +747,8 @@ func (d *Desfire) GetFileIDs() ([]byte, error) {
 func (d *Desfire) GetISOFileIDs(fileNo int,
 	targetSecondaryApp SecondAppIndicator,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xAF)
 
@@ -714,6 +789,8 @@ func (d *Desfire) GetISOFileIDs(fileNo int,
 func (d *Desfire) GetFileSettings(fileNo int,
 	targetSecondaryApp SecondAppIndicator,
 ) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xF5)
 
@@ -731,6 +808,11 @@ func (d *Desfire) GetFileSettings(fileNo int,
 		}
 
 		apdu = append(apdu, cmcT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(cmd, cmdHeader, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV2 mode support")
 	}
@@ -747,6 +829,10 @@ func (d *Desfire) GetFileSettings(fileNo int,
 	}()
 
 	switch d.evMode {
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
 	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
@@ -754,6 +840,78 @@ func (d *Desfire) GetFileSettings(fileNo int,
 	}
 }
 
+// GetFileCounters returns the SDMReadCtr (3 bytes LSB first) of a
+// FileType.StandardData file with Secure Dynamic Messaging (DESFire EV3).
+// Without authentication the counter is read in PLAIN mode, if the SDMCtrRet
+// access right is FREE.
+func (d *Desfire) GetFileCounters(fileNo int, targetSecondaryApp SecondAppIndicator,
+	commMode CommMode,
+) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	cmd := byte(0xF6)
+
+	apdu := make([]byte, 0)
+	apdu = append(apdu, cmd)
+	cmdHeader := make([]byte, 0)
+	cmdHeader = append(cmdHeader, byte(fileNo|targetSecondaryApp.Int()<<7))
+	apdu = append(apdu, cmdHeader...)
+	switch d.evMode {
+	case EV2:
+		switch commMode {
+		case FULL, MAC:
+			cmcT, err := calcMacOnCommandEV2(d.blockMac, d.ti, byte(cmd), d.cmdCtr,
+				cmdHeader, nil)
+			if err != nil {
+				return nil, err
+			}
+			apdu = append(apdu, cmcT...)
+		}
+	case D40:
+		if commMode != PLAIN || d.block != nil {
+			return nil, errors.New("only EV2 mode or PLAIN without authentication support")
+		}
+	default:
+		return nil, errors.New("only EV2 mode support")
+	}
+
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyResponse(resp); err != nil {
+		return nil, err
+	}
+	defer func() {
+		d.cmdCtr++
+	}()
+
+	var responseData []byte
+	switch d.evMode {
+	case EV2:
+		switch commMode {
+		case FULL:
+			iv, err := calcResponseIVOnFullModeEV2(d.ksesAuthEnc, d.ti, d.cmdCtr+1)
+			if err != nil {
+				return nil, err
+			}
+			responseData = getDataOnFullModeResponseEV2(d.block, iv, resp)
+		case MAC:
+			responseData = resp[1 : len(resp)-8]
+		default:
+			responseData = resp[1:]
+		}
+	default:
+		responseData = resp[1:]
+	}
+
+	if len(responseData) < 3 {
+		return nil, fmt.Errorf("len SDMReadCtr = %d, %w", len(responseData), ErrLengthError)
+	}
+	return responseData[:3], nil
+}
+
 // ChangeFileSettings changes the access parameters of an existing file.
 func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppIndicator,
 	isoFileID []byte,
@@ -766,8 +924,8 @@ func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppInd
 	nrAddAccessRights int,
 	addAccessRights []byte,
 ) error {
-
-	cmd := 0x5F
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if len(isoFileID) != 2 && len(isoFileID) != 0 {
 		return errors.New("wrong len (not 4 or nil) in \"isoFileID\"")
@@ -776,13 +934,6 @@ func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppInd
 		return errors.New("wrong len (nrAddAccessRights * 2) in \"nrAddAccessRights\"")
 	}
 
-	apdu := make([]byte, 0)
-	apdu = append(apdu, byte(cmd))
-	cmdHeader := make([]byte, 0)
-	cmdHeader = append(cmdHeader, byte(fileNo|targetSecondaryApp.Int()<<7))
-
-	apdu = append(apdu, cmdHeader...)
-
 	data := make([]byte, 0)
 	data = append(data, isoFileID...)
 	if !fileOption_AdditionalAccessRights_Disabled {
@@ -809,6 +960,94 @@ func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppInd
 		data = append(data, addAccessRights...)
 	}
 
+	return d.changeFileSettings(fileNo, targetSecondaryApp, data)
+}
+
+// ChangeFileSettingsSDM changes the access parameters of a FileType.StandardData
+// file and enables its Secure Dynamic Messaging mirrors (DESFire EV3).
+func (d *Desfire) ChangeFileSettingsSDM(fileNo int, targetSecondaryApp SecondAppIndicator,
+	fileOption_commMode CommMode,
+	accessRights_Read AccessRights,
+	accessRights_Write AccessRights,
+	accessRights_ReadWrite AccessRights,
+	accessRights_Change AccessRights,
+	sdm *SDMSettings,
+) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if sdm == nil {
+		return errors.New("SDM settings is null")
+	}
+
+	settings := &FileSettings{
+		FileType: StandardDataFile,
+		CommMode: fileOption_commMode,
+		AccessRights: FileAccessRights{
+			Read:      accessRights_Read,
+			Write:     accessRights_Write,
+			ReadWrite: accessRights_ReadWrite,
+			Change:    accessRights_Change,
+		},
+		SDM: sdm,
+	}
+	data, err := settings.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return d.changeFileSettings(fileNo, targetSecondaryApp, data)
+}
+
+// ChangeFileSettingsTMAC changes the access parameters of the FileType.TransactionMAC
+// file. With tmcLimit > 0 the card refuses the transactions after the TMC reaches
+// the limit (DESFire EV3).
+func (d *Desfire) ChangeFileSettingsTMAC(fileNo int, targetSecondaryApp SecondAppIndicator,
+	fileOption_commMode CommMode,
+	accessRights_Read AccessRights,
+	accessRights_AppCommitReaderIDKey AccessRights,
+	accessRights_Change AccessRights,
+	tmcLimit int,
+) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if tmcLimit < 0 {
+		return errors.New("wrong value (negative) in \"tmcLimit\"")
+	}
+
+	settings := &FileSettings{
+		FileType: TransactionMACFile,
+		CommMode: fileOption_commMode,
+		AccessRights: FileAccessRights{
+			Read:      accessRights_Read,
+			Write:     0x0F,
+			ReadWrite: accessRights_AppCommitReaderIDKey,
+			Change:    accessRights_Change,
+		},
+		TMCLimit: tmcLimit,
+	}
+	data, err := settings.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return d.changeFileSettings(fileNo, targetSecondaryApp, data)
+}
+
+// changeFileSettings send ChangeFileSettings with data in FULL mode
+func (d *Desfire) changeFileSettings(fileNo int, targetSecondaryApp SecondAppIndicator,
+	data []byte,
+) error {
+	cmd := 0x5F
+
+	apdu := make([]byte, 0)
+	apdu = append(apdu, byte(cmd))
+	cmdHeader := make([]byte, 0)
+	cmdHeader = append(cmdHeader, byte(fileNo|targetSecondaryApp.Int()<<7))
+
+	apdu = append(apdu, cmdHeader...)
+
 	switch d.evMode {
 	case EV2:
 		iv, err := calcCommandIVOnFullModeEV2(d.ksesAuthEnc, d.ti, d.cmdCtr)
@@ -824,6 +1063,18 @@ func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppInd
 
 		apdu = append(apdu, cryptograma...)
 		apdu = append(apdu, cmacT...)
+	case D40:
+		cryptograma, err := d.commandD40(data, FULL)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, cryptograma...)
+	case EV1:
+		cryptograma, err := d.commandEV1(byte(cmd), cmdHeader, data, FULL)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, cryptograma...)
 	default:
 		return errors.New("only EV2 mode support")
 	}
@@ -859,7 +1110,10 @@ func (d *Desfire) ChangeFileSettings(fileNo int, targetSecondaryApp SecondAppInd
 	}()
 
 	switch d.evMode {
-	case EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 mode support")
@@ -0,0 +1,253 @@
+package ev2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// FileType type of the file in the response of GetFileSettings
+type FileType int
+
+const (
+	StandardDataFile FileType = iota
+	BackupDataFile
+	ValueFile
+	LinearRecordFile
+	CyclicRecordFile
+	TransactionMACFile
+)
+
+// FileAccessRights access rights of a file (Read, Write, ReadWrite and
+// Change), 2 bytes LSB first in the commands
+type FileAccessRights struct {
+	Read      AccessRights
+	Write     AccessRights
+	ReadWrite AccessRights
+	Change    AccessRights
+}
+
+func parseFileAccessRights(data []byte) FileAccessRights {
+	accessRights := binary.LittleEndian.Uint16(data)
+	return FileAccessRights{
+		Read:      AccessRights(accessRights >> 12 & 0x0F),
+		Write:     AccessRights(accessRights >> 8 & 0x0F),
+		ReadWrite: AccessRights(accessRights >> 4 & 0x0F),
+		Change:    AccessRights(accessRights & 0x0F),
+	}
+}
+
+// Bytes access rights of the commands
+func (a FileAccessRights) Bytes() []byte {
+	accessRights := uint16(0)
+
+	accessRights |= (uint16(a.Read&0x0F) << 12)
+	accessRights |= (uint16(a.Write&0x0F) << 8)
+	accessRights |= (uint16(a.ReadWrite&0x0F) << 4)
+	accessRights |= (uint16(a.Change&0x0F) << 0)
+
+	accessRightsBytes := make([]byte, 2)
+
+	binary.LittleEndian.PutUint16(accessRightsBytes, accessRights)
+
+	return accessRightsBytes
+}
+
+// FileSettings settings of a file in the response of GetFileSettings. Only
+// the fields of the FileType are set: FileSize (data files), the limits of
+// FileType.Value, the record counts (record files) and the TMKey and
+// TMCLimit (FileType.TransactionMAC). SDM are the Secure Dynamic Messaging
+// settings of a FileType.StandardData file (DESFire EV3), nil if disabled.
+// For FileType.TransactionMAC, AccessRights.ReadWrite is the
+// AppCommitReaderIDKey.
+type FileSettings struct {
+	FileType        FileType
+	CommMode        CommMode
+	AccessRights    FileAccessRights
+	AddAccessRights []FileAccessRights
+
+	FileSize int
+
+	LowerLimit           int
+	UpperLimit           int
+	LimitedCreditValue   int
+	LimitedCreditEnabled bool
+	FreeGetValue         bool
+
+	RecordSize     int
+	MaxRecords     int
+	CurrentRecords int
+
+	TMKeyOption  KeyType
+	TMKeyVersion int
+	TMCLimit     int
+
+	SDM *SDMSettings
+}
+
+// ParseFileSettings settings of the file in the response of GetFileSettings
+func ParseFileSettings(data []byte) (*FileSettings, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("len file settings = %d, %w", len(data), ErrLengthError)
+	}
+	s := &FileSettings{
+		FileType:     FileType(data[0]),
+		CommMode:     CommMode(data[1] & 0x03),
+		AccessRights: parseFileAccessRights(data[2:4]),
+	}
+	fileOption := data[1]
+	data = data[4:]
+
+	// next n bytes of the settings
+	next := func(n int) ([]byte, error) {
+		if len(data) < n {
+			return nil, fmt.Errorf("file settings of FileType %d, %w", s.FileType, ErrLengthError)
+		}
+		v := data[:n]
+		data = data[n:]
+		return v, nil
+	}
+
+	switch s.FileType {
+	case StandardDataFile, BackupDataFile:
+		v, err := next(3)
+		if err != nil {
+			return nil, err
+		}
+		s.FileSize = getUint24(v)
+	case ValueFile:
+		v, err := next(13)
+		if err != nil {
+			return nil, err
+		}
+		s.LowerLimit = int(int32(binary.LittleEndian.Uint32(v[0:4])))
+		s.UpperLimit = int(int32(binary.LittleEndian.Uint32(v[4:8])))
+		s.LimitedCreditValue = int(int32(binary.LittleEndian.Uint32(v[8:12])))
+		s.LimitedCreditEnabled = v[12]&0x01 != 0
+		s.FreeGetValue = v[12]&0x02 != 0
+	case LinearRecordFile, CyclicRecordFile:
+		v, err := next(9)
+		if err != nil {
+			return nil, err
+		}
+		s.RecordSize = getUint24(v[0:3])
+		s.MaxRecords = getUint24(v[3:6])
+		s.CurrentRecords = getUint24(v[6:9])
+	case TransactionMACFile:
+		v, err := next(2)
+		if err != nil {
+			return nil, err
+		}
+		s.TMKeyOption = KeyType(v[0] & 0x03)
+		s.TMKeyVersion = int(v[1])
+		if fileOption&(0x01<<5) != 0 {
+			v, err := next(4)
+			if err != nil {
+				return nil, err
+			}
+			s.TMCLimit = int(binary.LittleEndian.Uint32(v))
+		}
+	default:
+		return nil, fmt.Errorf("FileType 0x%02X, %w", s.FileType, ErrParameterError)
+	}
+
+	if fileOption&(0x01<<7) != 0 {
+		v, err := next(1)
+		if err != nil {
+			return nil, err
+		}
+		nrAddAccessRights := int(v[0])
+		v, err = next(2 * nrAddAccessRights)
+		if err != nil {
+			return nil, err
+		}
+		s.AddAccessRights = make([]FileAccessRights, 0, nrAddAccessRights)
+		for i := 0; i < nrAddAccessRights; i++ {
+			s.AddAccessRights = append(s.AddAccessRights, parseFileAccessRights(v[2*i:]))
+		}
+	}
+
+	if fileOption&(0x01<<6) != 0 {
+		sdm, err := parseSDMSettings(data)
+		if err != nil {
+			return nil, err
+		}
+		s.SDM = sdm
+	}
+
+	return s, nil
+}
+
+// Bytes settings of the file in the data of ChangeFileSettings: the
+// FileOption, the access rights and, if they are set, the additional
+// access rights, the SDM settings and the TMCLimit. The size, limits and
+// records of the file can't be changed and are not encoded.
+func (s *FileSettings) Bytes() ([]byte, error) {
+	if len(s.AddAccessRights) > 0x0F {
+		return nil, fmt.Errorf("%d additional access rights, %w", len(s.AddAccessRights), ErrParameterError)
+	}
+	if s.SDM != nil && s.FileType != StandardDataFile {
+		return nil, errors.New("SDM only in FileType.StandardData files")
+	}
+	if s.TMCLimit < 0 {
+		return nil, errors.New("wrong value (negative) in \"TMCLimit\"")
+	}
+
+	fileOption := byte(s.CommMode & 0x03)
+	if len(s.AddAccessRights) > 0 {
+		fileOption |= 0x01 << 7
+	}
+	if s.SDM != nil {
+		fileOption |= 0x01 << 6
+	}
+	if s.FileType == TransactionMACFile && s.TMCLimit > 0 {
+		fileOption |= 0x01 << 5
+	}
+
+	data := make([]byte, 0)
+	data = append(data, fileOption)
+	data = append(data, s.AccessRights.Bytes()...)
+
+	if len(s.AddAccessRights) > 0 {
+		data = append(data, byte(len(s.AddAccessRights)))
+		for _, v := range s.AddAccessRights {
+			data = append(data, v.Bytes()...)
+		}
+	}
+
+	if s.SDM != nil {
+		sdmData, err := s.SDM.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, sdmData...)
+	}
+
+	if s.FileType == TransactionMACFile && s.TMCLimit > 0 {
+		tmcLimitBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(tmcLimitBytes, uint32(s.TMCLimit))
+		data = append(data, tmcLimitBytes...)
+	}
+
+	return data, nil
+}
+
+// ChangeFileSettingsWith changes the access parameters of an existing file
+// with the settings, e.g. the settings of ParseFileSettings with the changed
+// fields.
+func (d *Desfire) ChangeFileSettingsWith(fileNo int, targetSecondaryApp SecondAppIndicator,
+	settings *FileSettings,
+) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if settings == nil {
+		return errors.New("file settings is null")
+	}
+	data, err := settings.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return d.changeFileSettings(fileNo, targetSecondaryApp, data)
+}
@@ -0,0 +1,159 @@
+package ev2
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFileSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      *FileSettings
+		wantBytes string
+		wantErr   error
+	}{
+		{
+			// AN12196, GetFileSettings of the NDEF file with SDM
+			name: "StandardData with SDM",
+			data: "004000E0000100C1F121200000430000430000",
+			want: &FileSettings{
+				FileType:     StandardDataFile,
+				CommMode:     PLAIN,
+				AccessRights: FileAccessRights{Read: FREE, Write: KeyID_0x00, ReadWrite: KeyID_0x00, Change: KeyID_0x00},
+				FileSize:     256,
+				SDM: &SDMSettings{
+					Options:        SDMUIDMirror | SDMReadCtr | SDMASCIIEncoding,
+					MetaRead:       KeyID_0x02,
+					FileRead:       KeyID_0x01,
+					CtrRet:         KeyID_0x01,
+					PICCDataOffset: 0x20,
+					MACInputOffset: 0x43,
+					MACOffset:      0x43,
+				},
+			},
+			wantBytes: "4000E0C1F121200000430000430000",
+		},
+		{
+			name: "Value with additional access rights",
+			data: "0283100000000000E80300000000000001012301",
+			want: &FileSettings{
+				FileType:             ValueFile,
+				CommMode:             FULL,
+				AccessRights:         FileAccessRights{Read: KeyID_0x00, Write: KeyID_0x00, ReadWrite: KeyID_0x01, Change: KeyID_0x00},
+				AddAccessRights:      []FileAccessRights{{Read: KeyID_0x00, Write: KeyID_0x01, ReadWrite: KeyID_0x02, Change: KeyID_0x03}},
+				UpperLimit:           1000,
+				LimitedCreditEnabled: true,
+			},
+			wantBytes: "831000012301",
+		},
+		{
+			name: "Value with negative limit",
+			data: "020010009CFFFFFF640000000000000002",
+			want: &FileSettings{
+				FileType:     ValueFile,
+				CommMode:     PLAIN,
+				AccessRights: FileAccessRights{Read: KeyID_0x00, Write: KeyID_0x00, ReadWrite: KeyID_0x01, Change: KeyID_0x00},
+				LowerLimit:   -100,
+				UpperLimit:   100,
+				FreeGetValue: true,
+			},
+			wantBytes: "001000",
+		},
+		{
+			name: "CyclicRecord",
+			data: "040130121000000A0000030000",
+			want: &FileSettings{
+				FileType:       CyclicRecordFile,
+				CommMode:       MAC,
+				AccessRights:   FileAccessRights{Read: KeyID_0x01, Write: KeyID_0x02, ReadWrite: KeyID_0x03, Change: KeyID_0x00},
+				RecordSize:     16,
+				MaxRecords:     10,
+				CurrentRecords: 3,
+			},
+			wantBytes: "013012",
+		},
+		{
+			name: "TransactionMAC with TMCLimit",
+			data: "0520301F0200E8030000",
+			want: &FileSettings{
+				FileType:     TransactionMACFile,
+				CommMode:     PLAIN,
+				AccessRights: FileAccessRights{Read: KeyID_0x01, Write: NO_ACCESS, ReadWrite: KeyID_0x03, Change: KeyID_0x00},
+				TMKeyOption:  AES,
+				TMCLimit:     1000,
+			},
+			wantBytes: "20301FE8030000",
+		},
+		{
+			name:    "unknown FileType",
+			data:    "07000000000100",
+			wantErr: ErrParameterError,
+		},
+		{
+			name:    "short record settings",
+			data:    "0400301210000000",
+			wantErr: ErrLengthError,
+		},
+		{
+			name:    "short SDM settings",
+			data:    "004000E0000100C1F1212000004300",
+			wantErr: ErrLengthError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileSettings(mustHex(t, tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseFileSettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFileSettings() = %+v, want %+v", got, tt.want)
+			}
+			data, err := got.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if want := mustHex(t, tt.wantBytes); !bytes.Equal(data, want) {
+				t.Errorf("Bytes() = [% X], want [% X]", data, want)
+			}
+		})
+	}
+}
+
+func TestFileSettings_Bytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *FileSettings
+		wantErr  bool
+	}{
+		{
+			name: "SDM in a Value file",
+			settings: &FileSettings{
+				FileType: ValueFile,
+				SDM:      &SDMSettings{MetaRead: NO_ACCESS, FileRead: NO_ACCESS, CtrRet: NO_ACCESS},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many additional access rights",
+			settings: &FileSettings{
+				FileType:        StandardDataFile,
+				AddAccessRights: make([]FileAccessRights, 16),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.settings.Bytes(); (err != nil) != tt.wantErr {
+				t.Errorf("Bytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package ev2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/iso7816"
+)
+
+// SetISOWrapping select the ISO 7816-4 wrapping of the native commands:
+// the command Cmd || Data is sent as 90 Cmd 00 00 Lc Data 00 and the
+// response Data || 91 Status is returned as Status || Data. The PC/SC
+// readers need the wrapping, the native readers (serial) don't.
+func (d *Desfire) SetISOWrapping(wrapping bool) {
+	d.isoWrapping.Store(wrapping)
+}
+
+// ISOWrapping the native commands are wrapped in ISO 7816-4 APDUs
+func (d *Desfire) ISOWrapping() bool {
+	return d.isoWrapping.Load()
+}
+
+// wrapISO ISO 7816-4 APDU of the native command
+func wrapISO(apdu []byte) ([]byte, error) {
+	if len(apdu) <= 0 {
+		return nil, errors.New("native command is null")
+	}
+	data := apdu[1:]
+	if len(data) > 0xFF {
+		return nil, fmt.Errorf("len data = %d (max 255), %w", len(data), ErrParameterError)
+	}
+	result := []byte{0x90, apdu[0], 0x00, 0x00}
+	if len(data) > 0 {
+		result = append(result, byte(len(data)))
+		result = append(result, data...)
+	}
+	result = append(result, 0x00)
+	return result, nil
+}
+
+// unwrapISO native response (Status || Data) of the ISO 7816-4 response
+// (Data || 91 Status). 91AF is the status of the additional frames, the
+// other status words are the errors of iso7816.NewStatusError.
+func unwrapISO(resp []byte) ([]byte, error) {
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("error in response: [% X], %w", resp, ErrLengthError)
+	}
+	sw1, sw2 := resp[len(resp)-2], resp[len(resp)-1]
+	if sw1 != 0x91 {
+		if err := iso7816.NewStatusError(uint16(sw1)<<8 | uint16(sw2)); err != nil {
+			return nil, err
+		}
+		// 9000 is not a status of the native commands
+		return nil, fmt.Errorf("error in response: [% X], %w", resp, iso7816.ErrUnknownStatus)
+	}
+	result := make([]byte, 0, len(resp)-1)
+	result = append(result, sw2)
+	result = append(result, resp[:len(resp)-2]...)
+	return result, nil
+}
+
+// Apdu send the native command to the card, wrapped in ISO 7816-4 with
+// SetISOWrapping(true), and return the native response
+func (d *Desfire) Apdu(apdu []byte) ([]byte, error) {
+	return d.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext send the native command to the card with ctx, wrapped in ISO
+// 7816-4 with SetISOWrapping(true), and return the native response
+func (d *Desfire) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	if !d.isoWrapping.Load() {
+		return smartcard.ApduContext(ctx, d.ICard, apdu)
+	}
+	cmd, err := wrapISO(apdu)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := smartcard.ApduContext(ctx, d.ICard, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return unwrapISO(resp)
+}
+
+// ApduTimed send the native command to the card, wrapped in ISO 7816-4 with
+// SetISOWrapping(true), and return the native response and the round trip
+// time measured by the card of the reader
+func (d *Desfire) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	if !d.isoWrapping.Load() {
+		return smartcard.ApduTimed(d.ICard, apdu)
+	}
+	cmd, err := wrapISO(apdu)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, elapsed, err := smartcard.ApduTimed(d.ICard, cmd)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err = unwrapISO(resp)
+	return resp, elapsed, err
+}
+
+// RedactNext forward the sensitive bytes of the next native exchange to the
+// card, the spans are moved to the data of the ISO 7816-4 APDUs with
+// SetISOWrapping(true)
+func (d *Desfire) RedactNext(command, response []smartcard.Span) {
+	if !d.isoWrapping.Load() {
+		smartcard.RedactNext(d.ICard, command, response)
+		return
+	}
+	cmdSpans := make([]smartcard.Span, 0, len(command))
+	for _, s := range command {
+		// Cmd || Data -> 90 Cmd 00 00 Lc Data 00
+		if s.Offset > 0 {
+			s.Offset += 4
+		}
+		if s.Length < 0 {
+			s.Length--
+		}
+		cmdSpans = append(cmdSpans, s)
+	}
+	respSpans := make([]smartcard.Span, 0, len(response))
+	for _, s := range response {
+		// Status || Data -> Data || 91 Status
+		if s.Offset > 0 {
+			s.Offset--
+		}
+		if s.Length < 0 {
+			s.Length -= 2
+		}
+		respSpans = append(respSpans, s)
+	}
+	smartcard.RedactNext(d.ICard, cmdSpans, respSpans)
+}
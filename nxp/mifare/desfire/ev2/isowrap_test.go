@@ -0,0 +1,183 @@
+package ev2
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/iso7816"
+	"github.com/dumacp/smartcard/sim"
+)
+
+func Test_wrapISO(t *testing.T) {
+	tests := []struct {
+		name    string
+		apdu    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "GetVersion",
+			apdu: []byte{0x60},
+			want: []byte{0x90, 0x60, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "ReadData",
+			apdu: []byte{0xAD, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00},
+			want: []byte{0x90, 0xAD, 0x00, 0x00, 0x07, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "additional frame",
+			apdu: []byte{0xAF},
+			want: []byte{0x90, 0xAF, 0x00, 0x00, 0x00},
+		},
+		{
+			name:    "null command",
+			wantErr: true,
+		},
+		{
+			name:    "data too long",
+			apdu:    make([]byte, 257),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wrapISO(tt.apdu)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wrapISO() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("wrapISO() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unwrapISO(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "OK",
+			resp: []byte{0x91, 0x00},
+			want: []byte{0x00},
+		},
+		{
+			name: "additional frame",
+			resp: []byte{0x04, 0x01, 0x01, 0x91, 0xAF},
+			want: []byte{0xAF, 0x04, 0x01, 0x01},
+		},
+		{
+			name: "DESFire error",
+			resp: []byte{0x91, 0xAE},
+			want: []byte{0xAE},
+		},
+		{
+			name:    "ISO error",
+			resp:    []byte{0x6E, 0x00},
+			wantErr: iso7816.ErrClaNotSupported,
+		},
+		{
+			name:    "ISO success",
+			resp:    []byte{0x90, 0x00},
+			wantErr: iso7816.ErrUnknownStatus,
+		},
+		{
+			name:    "short response",
+			resp:    []byte{0x91},
+			wantErr: ErrLengthError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unwrapISO(tt.resp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unwrapISO() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("unwrapISO() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesfire_ISOWrapping(t *testing.T) {
+	frames := [][]byte{
+		{0x04, 0x01, 0x01, 0x12, 0x00, 0x1A, 0x05},
+		{0x04, 0x01, 0x01, 0x02, 0x01, 0x1A, 0x05},
+		{0x04, 0x51, 0x6A, 0x12, 0x34, 0x56, 0x80, 0xBA, 0x44, 0x34, 0x73, 0x40, 0x39, 0x20},
+	}
+	tests := []struct {
+		name    string
+		wrapped bool
+		script  []sim.Exchange
+	}{
+		{
+			name: "native",
+			script: []sim.Exchange{
+				{Command: []byte{0x60}, Response: append([]byte{0xAF}, frames[0]...)},
+				{Command: []byte{0xAF}, Response: append([]byte{0xAF}, frames[1]...)},
+				{Command: []byte{0xAF}, Response: append([]byte{0x00}, frames[2]...)},
+			},
+		},
+		{
+			name:    "ISO 7816-4 wrapped",
+			wrapped: true,
+			script: []sim.Exchange{
+				{Command: []byte{0x90, 0x60, 0x00, 0x00, 0x00}, Response: append(append([]byte{}, frames[0]...), 0x91, 0xAF)},
+				{Command: []byte{0x90, 0xAF, 0x00, 0x00, 0x00}, Response: append(append([]byte{}, frames[1]...), 0x91, 0xAF)},
+				{Command: []byte{0x90, 0xAF, 0x00, 0x00, 0x00}, Response: append(append([]byte{}, frames[2]...), 0x91, 0x00)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := sim.NewCard(nil, nil, nil, 0x20)
+			card.Script(tt.script...)
+			d := NewDesfire(card)
+			d.SetISOWrapping(tt.wrapped)
+			got, err := d.GetVersion()
+			if err != nil {
+				t.Fatalf("GetVersion() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, frames) {
+				t.Errorf("GetVersion() = [% X], want [% X]", got, frames)
+			}
+			if card.Pending() != 0 {
+				t.Errorf("Pending() = %d, want 0", card.Pending())
+			}
+		})
+	}
+}
+
+// redactCard card that records the spans of RedactNext
+type redactCard struct {
+	*sim.Card
+	command, response []smartcard.Span
+}
+
+func (c *redactCard) RedactNext(command, response []smartcard.Span) {
+	c.command, c.response = command, response
+}
+
+func TestDesfire_RedactNext(t *testing.T) {
+	card := &redactCard{Card: sim.NewCard(nil, nil, nil, 0x20)}
+	d := NewDesfire(card)
+	d.SetISOWrapping(true)
+	d.RedactNext([]smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
+
+	cmd, _ := wrapISO([]byte{0xAF, 0x01, 0x02, 0x03})
+	if start, end := card.command[0].Resolve(len(cmd)); start != 5 || end != len(cmd)-1 {
+		t.Errorf("command span = [%d, %d), want [5, %d)", start, end, len(cmd)-1)
+	}
+	resp := []byte{0x01, 0x02, 0x03, 0x91, 0xAF}
+	if start, end := card.response[0].Resolve(len(resp)); start != 0 || end != 3 {
+		t.Errorf("response span = [%d, %d), want [0, 3)", start, end)
+	}
+}
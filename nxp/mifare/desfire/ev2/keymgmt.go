@@ -2,6 +2,8 @@ package ev2
 
 import (
 	"errors"
+
+	"github.com/dumacp/smartcard"
 )
 
 // ChangeKey depensing on the currently selectd AID, this command
@@ -9,6 +11,8 @@ import (
 func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 	keyType KeyType, secondAppIndicator SecondAppIndicator,
 	newKey, oldKey []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0xC4
 
@@ -34,6 +38,16 @@ func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 		if err != nil {
 			return err
 		}
+	case D40:
+		if d.block == nil {
+			return errors.New("ChangeKey without authentication")
+		}
+		cryptograma, err = changeKeyCryptogramD40(d.block,
+			keyNo, d.lastKey, keyType.Int(), keyVersion,
+			newKey, oldKey)
+		if err != nil {
+			return err
+		}
 	case EV1:
 
 		cryptograma, err = changeKeyCryptogramEV1(d.block,
@@ -43,7 +57,7 @@ func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 			return err
 		}
 		// log.Printf("cryptograma: [% X], len: %d", cryptograma, len(cryptograma))
-		d.iv = cryptograma[len(cryptograma)-d.block.BlockSize()-1:]
+		d.iv = append([]byte{}, cryptograma[len(cryptograma)-d.block.BlockSize():]...)
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -63,6 +77,8 @@ func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 	// }
 
 	apdu = append(apdu, cryptograma...)
+	// key cryptogram
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: len(apdu) - len(cryptograma), Length: -1}}, nil)
 	resp, err := d.Apdu(apdu)
 	if err != nil {
 		return err
@@ -74,6 +90,14 @@ func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 		return err
 	}
 
+	// the session ends when the authenticated key is changed, the response
+	// is without CMAC
+	if d.evMode == EV1 && (keyNo&0x1F) != d.lastKey {
+		if _, err := d.responseEV1(resp[0], resp[1:], PLAIN); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -82,6 +106,8 @@ func (d *Desfire) ChangeKey(keyNo, keyVersion int,
 func (d *Desfire) ChangeKeyEV2(keyNo, keySetNo, keyVersion int,
 	keyType KeyType, secondAppIndicator SecondAppIndicator,
 	newKey, oldKey []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	if d.evMode != EV2 {
 		return errors.New("only EV2 mode support")
@@ -124,6 +150,8 @@ func (d *Desfire) ChangeKeyEV2(keyNo, keySetNo, keyVersion int,
 	// }
 
 	apdu = append(apdu, cryptograma...)
+	// key cryptogram
+	smartcard.RedactNext(d, []smartcard.Span{{Offset: len(apdu) - len(cryptograma), Length: -1}}, nil)
 	resp, err := d.Apdu(apdu)
 	if err != nil {
 		return err
@@ -143,6 +171,8 @@ func (d *Desfire) ChangeKeyEV2(keyNo, keySetNo, keyVersion int,
 // application. In addition it returns the number of keys which are configured
 // for the selected application an if applicable the AppKeySettings.
 func (d *Desfire) GetKeySettings() ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x45
 
@@ -156,7 +186,11 @@ func (d *Desfire) GetKeySettings() ([]byte, error) {
 			return nil, err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
+		if _, err := d.commandEV1(byte(cmd), nil, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
 	}
@@ -173,7 +207,11 @@ func (d *Desfire) GetKeySettings() ([]byte, error) {
 	}()
 
 	switch d.evMode {
-	case EV1, EV2:
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
+	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
 		return nil, errors.New("only EV2 support")
@@ -184,6 +222,8 @@ func (d *Desfire) GetKeySettings() ([]byte, error) {
 // initialize the key set with specific index.
 func (d *Desfire) InitializeKeySet(keySetNo int, keySetType KeyType,
 	secondAppIndicator SecondAppIndicator) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x56
 
@@ -230,6 +270,8 @@ func (d *Desfire) InitializeKeySet(keySetNo int, keySetType KeyType,
 // specific number.
 func (d *Desfire) FinalizeKeySet(keySetNo, keySetVersion int,
 	secondAppIndicator SecondAppIndicator) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x57
 
@@ -275,6 +317,8 @@ func (d *Desfire) FinalizeKeySet(keySetNo, keySetVersion int,
 // RollKeySet the currently selected application, roll to the key set with
 // specific number.
 func (d *Desfire) RollKeySet(keySetNo int, secondAppIndicator SecondAppIndicator) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x55
 
@@ -320,6 +364,8 @@ func (d *Desfire) RollKeySet(keySetNo int, secondAppIndicator SecondAppIndicator
 // ChangeKeySettings depending on the currently selected AID, this command changes
 // the PICCKeySettings of the PICC or the AppKeySettings of the application.
 func (d *Desfire) ChangeKeySettings(keySetting int) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x54
 
@@ -343,6 +389,18 @@ func (d *Desfire) ChangeKeySettings(keySetting int) error {
 
 		apdu = append(apdu, cryptograma...)
 		apdu = append(apdu, cmacT...)
+	case D40:
+		cryptograma, err := d.commandD40(data, FULL)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, cryptograma...)
+	case EV1:
+		cryptograma, err := d.commandEV1(byte(cmd), nil, data, FULL)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, cryptograma...)
 	default:
 		return errors.New("only Ev2 support")
 	}
@@ -359,7 +417,10 @@ func (d *Desfire) ChangeKeySettings(keySetting int) error {
 	}()
 
 	switch d.evMode {
-	case EV1, EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 support")
@@ -380,6 +441,8 @@ const (
 // specific KeySet in currently AID = , all KeySet in currently AID = 0)
 func (d *Desfire) GetKeyVersion(keyNo, keySetNo int, keySetOption KeySetOptionVersion,
 	secondAppIndicator SecondAppIndicator) ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x64
 
@@ -409,7 +472,12 @@ func (d *Desfire) GetKeyVersion(keyNo, keySetNo int, keySetOption KeySetOptionVe
 
 		apdu = append(apdu, cmdHeader...)
 		apdu = append(apdu, cmacT...)
+	case D40:
+		apdu = append(apdu, cmdHeader...)
 	case EV1:
+		if _, err := d.commandEV1(byte(cmd), cmdHeader, nil, PLAIN); err != nil {
+			return nil, err
+		}
 		apdu = append(apdu, cmdHeader...)
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
@@ -427,7 +495,11 @@ func (d *Desfire) GetKeyVersion(keyNo, keySetNo int, keySetOption KeySetOptionVe
 	}()
 
 	switch d.evMode {
-	case EV1, EV2:
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
+	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
 		return nil, errors.New("only EV2 support")
@@ -6,11 +6,9 @@ import (
 	"encoding/hex"
 	"log"
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/dumacp/smartcard"
-	"github.com/dumacp/smartcard/pcsc"
 )
 
 func Test_desfire_Crypto(t *testing.T) {
@@ -39,23 +37,7 @@ func Test_desfire_Crypto(t *testing.T) {
 }
 
 func Test_desfire_GetKeySettings(t *testing.T) {
-	ctx, err := pcsc.NewContext()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	readers, err := ctx.ListReaders()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var reader pcsc.Reader
-	for i, r := range readers {
-		log.Printf("reader %q: %s", i, r)
-		if strings.Contains(r, "PICC") {
-			reader = pcsc.NewReader(ctx, r)
-		}
-	}
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
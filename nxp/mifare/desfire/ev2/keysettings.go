@@ -0,0 +1,143 @@
+package ev2
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeySettings PICCKeySettings or AppKeySettings in the response of
+// GetKeySettings. ChangeKey is the key to change the keys (0xE the same
+// key, 0xF frozen) and the flags are the bits of KeySett1. KeyType and
+// KeyCount are the type and the number of keys of KeySett2. The EV2 fields
+// (KeySett3 and the application key sets) are set if KeySett3 is present.
+type KeySettings struct {
+	ChangeKey               AccessRights
+	ConfigurationChangeable bool
+	FreeCreateDelete        bool
+	FreeDirectoryAccess     bool
+	MasterKeyChangeable     bool
+
+	KeyType    KeyType
+	ISOFileIDs bool
+	KeyCount   int
+
+	KeySett3       bool
+	CapabilityData bool
+	VCKeys         bool
+	KeySets        bool
+	AKSVersion     int
+	NoKeySets      int
+	MaxKeySize     int
+	RollKey        AccessRights
+}
+
+// ParseKeySettings settings of the keys in the response of GetKeySettings
+func ParseKeySettings(data []byte) (*KeySettings, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("len key settings = %d, %w", len(data), ErrLengthError)
+	}
+	keySett1, keySett2 := data[0], data[1]
+	s := &KeySettings{
+		ChangeKey:               AccessRights(keySett1 >> 4),
+		ConfigurationChangeable: keySett1&(0x01<<3) != 0,
+		FreeCreateDelete:        keySett1&(0x01<<2) != 0,
+		FreeDirectoryAccess:     keySett1&(0x01<<1) != 0,
+		MasterKeyChangeable:     keySett1&(0x01<<0) != 0,
+		KeyType:                 KeyType(keySett2 >> 6),
+		ISOFileIDs:              keySett2&(0x01<<5) != 0,
+		KeySett3:                keySett2&(0x01<<4) != 0,
+		KeyCount:                int(keySett2 & 0x0F),
+	}
+	if !s.KeySett3 {
+		return s, nil
+	}
+	if len(data) < 3 {
+		return nil, fmt.Errorf("len key settings = %d, %w", len(data), ErrLengthError)
+	}
+	keySett3 := data[2]
+	s.CapabilityData = keySett3&(0x01<<2) != 0
+	s.VCKeys = keySett3&(0x01<<1) != 0
+	s.KeySets = keySett3&(0x01<<0) != 0
+	if !s.KeySets {
+		return s, nil
+	}
+	// AKSVersion || NoKeySets || MaxKeySize || AppKeySetSett
+	if len(data) < 7 {
+		return nil, fmt.Errorf("len key settings = %d, %w", len(data), ErrLengthError)
+	}
+	s.AKSVersion = int(data[3])
+	s.NoKeySets = int(data[4])
+	s.MaxKeySize = int(data[5])
+	s.RollKey = AccessRights(data[6] & 0x0F)
+	return s, nil
+}
+
+// KeySett1 KeySett1 of the settings, the data of ChangeKeySettings
+func (s *KeySettings) KeySett1() byte {
+	keySett1 := byte(s.ChangeKey&0x0F) << 4
+	if s.ConfigurationChangeable {
+		keySett1 |= 0x01 << 3
+	}
+	if s.FreeCreateDelete {
+		keySett1 |= 0x01 << 2
+	}
+	if s.FreeDirectoryAccess {
+		keySett1 |= 0x01 << 1
+	}
+	if s.MasterKeyChangeable {
+		keySett1 |= 0x01 << 0
+	}
+	return keySett1
+}
+
+// Bytes settings of the keys as in the response of GetKeySettings (and in
+// the key settings of CreateApplication)
+func (s *KeySettings) Bytes() ([]byte, error) {
+	if s.KeyCount < 0 || s.KeyCount > 0x0F {
+		return nil, fmt.Errorf("KeyCount %d, %w", s.KeyCount, ErrParameterError)
+	}
+	if s.KeySets && !s.KeySett3 {
+		return nil, errors.New("KeySets without KeySett3")
+	}
+
+	keySett2 := byte(s.KeyType&0x03) << 6
+	if s.ISOFileIDs {
+		keySett2 |= 0x01 << 5
+	}
+	if s.KeySett3 {
+		keySett2 |= 0x01 << 4
+	}
+	keySett2 |= byte(s.KeyCount)
+
+	data := []byte{s.KeySett1(), keySett2}
+	if !s.KeySett3 {
+		return data, nil
+	}
+
+	keySett3 := byte(0x00)
+	if s.CapabilityData {
+		keySett3 |= 0x01 << 2
+	}
+	if s.VCKeys {
+		keySett3 |= 0x01 << 1
+	}
+	if s.KeySets {
+		keySett3 |= 0x01 << 0
+	}
+	data = append(data, keySett3)
+	if s.KeySets {
+		data = append(data, byte(s.AKSVersion), byte(s.NoKeySets),
+			byte(s.MaxKeySize), byte(s.RollKey&0x0F))
+	}
+	return data, nil
+}
+
+// ChangeKeySettingsWith depending on the currently selected AID, this
+// command changes the PICCKeySettings of the PICC or the AppKeySettings of
+// the application with KeySett1 of the settings.
+func (d *Desfire) ChangeKeySettingsWith(settings *KeySettings) error {
+	if settings == nil {
+		return errors.New("key settings is null")
+	}
+	return d.ChangeKeySettings(int(settings.KeySett1()))
+}
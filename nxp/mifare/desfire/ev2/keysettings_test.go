@@ -0,0 +1,93 @@
+package ev2
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseKeySettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *KeySettings
+		wantErr error
+	}{
+		{
+			name: "PICC AES",
+			data: "0F81",
+			want: &KeySettings{
+				ChangeKey:               KeyID_0x00,
+				ConfigurationChangeable: true,
+				FreeCreateDelete:        true,
+				FreeDirectoryAccess:     true,
+				MasterKeyChangeable:     true,
+				KeyType:                 AES,
+				KeyCount:                1,
+			},
+		},
+		{
+			name: "application 2TDEA frozen",
+			data: "F00E",
+			want: &KeySettings{
+				ChangeKey: NO_ACCESS,
+				KeyType:   TDEA2,
+				KeyCount:  14,
+			},
+		},
+		{
+			name: "application EV2 with key sets",
+			data: "EBB50100021001",
+			want: &KeySettings{
+				ChangeKey:               FREE,
+				ConfigurationChangeable: true,
+				FreeDirectoryAccess:     true,
+				MasterKeyChangeable:     true,
+				KeyType:                 AES,
+				ISOFileIDs:              true,
+				KeyCount:                5,
+				KeySett3:                true,
+				KeySets:                 true,
+				NoKeySets:               2,
+				MaxKeySize:              16,
+				RollKey:                 KeyID_0x01,
+			},
+		},
+		{
+			name:    "short",
+			data:    "0F",
+			wantErr: ErrLengthError,
+		},
+		{
+			name:    "key sets without AKS settings",
+			data:    "EBB50100",
+			wantErr: ErrLengthError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := mustHex(t, tt.data)
+			got, err := ParseKeySettings(data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseKeySettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeySettings() = %+v, want %+v", got, tt.want)
+			}
+			if got.KeySett1() != data[0] {
+				t.Errorf("KeySett1() = %02X, want %02X", got.KeySett1(), data[0])
+			}
+			b, err := got.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if !bytes.Equal(b, data) {
+				t.Errorf("Bytes() = [% X], want [% X]", b, data)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package ev2
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Returns the free memory avalaible on the card
 func (d *Desfire) FreeMem() ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0x6E)
 
@@ -18,7 +21,11 @@ func (d *Desfire) FreeMem() ([]byte, error) {
 			return nil, err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
+		if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("only EV1 and Ev2 support")
 	}
@@ -32,7 +39,11 @@ func (d *Desfire) FreeMem() ([]byte, error) {
 	}
 
 	switch d.evMode {
-	case EV1, EV2:
+	case D40:
+		return resp[1:], nil
+	case EV1:
+		return d.responseEV1(resp[0], resp[1:], PLAIN)
+	case EV2:
 		return resp[1 : len(resp)-8], nil
 	default:
 		return nil, errors.New("only EV2 support")
@@ -44,6 +55,8 @@ func (d *Desfire) FreeMem() ([]byte, error) {
 // files are deleted. The deleted memory is released and can
 // be reused.
 func (d *Desfire) Format() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0xFC)
 
@@ -56,7 +69,11 @@ func (d *Desfire) Format() error {
 			return err
 		}
 		apdu = append(apdu, cmacT...)
+	case D40:
 	case EV1:
+		if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+			return err
+		}
 	default:
 		return errors.New("only EV1 and Ev2 support")
 	}
@@ -73,7 +90,10 @@ func (d *Desfire) Format() error {
 	}()
 
 	switch d.evMode {
-	case EV1, EV2:
+	case EV1:
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	case D40, EV2:
 		return nil
 	default:
 		return errors.New("only EV2 support")
@@ -96,6 +116,8 @@ const (
 // with a key, defines if the UID or the random ID is sent back
 // during communication setup and configures the ATS string.
 func (d *Desfire) SetConfiguration(option ConfigurationOption, data []byte) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0x5C)
 
@@ -125,6 +147,12 @@ func (d *Desfire) SetConfiguration(option ConfigurationOption, data []byte) erro
 
 		apdu = append(apdu, cryptograma...)
 		apdu = append(apdu, cmacT...)
+	case EV1:
+		cryptograma, err := d.commandEV1(cmd, cmdHeader, data, FULL)
+		if err != nil {
+			return err
+		}
+		apdu = append(apdu, cryptograma...)
 	default:
 		return errors.New("only Desfire Ev2 mode support")
 	}
@@ -140,12 +168,19 @@ func (d *Desfire) SetConfiguration(option ConfigurationOption, data []byte) erro
 		d.cmdCtr++
 	}()
 
+	if d.evMode == EV1 {
+		_, err := d.responseEV1(resp[0], resp[1:], PLAIN)
+		return err
+	}
+
 	return nil
 }
 
 // GetVersion returns manufacturing related data of the PICC. First
 // part HW related information as specified in CardVersioinList Table.
 func (d *Desfire) GetVersion() ([][]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := byte(0x60)
 
@@ -163,7 +198,13 @@ func (d *Desfire) GetVersion() ([][]byte, error) {
 				return nil, err
 			}
 			apdu = append(apdu, cmacT...)
+		case D40:
 		case EV1:
+			if apdu[0] == cmd {
+				if _, err := d.commandEV1(cmd, nil, nil, PLAIN); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			return nil, errors.New("only EV1 and Ev2 support")
 		}
@@ -176,7 +217,13 @@ func (d *Desfire) GetVersion() ([][]byte, error) {
 			return nil, err
 		}
 
-		response = append(response, resp[1:len(resp)-8])
+		switch d.evMode {
+		case D40, EV1:
+			// the CMAC of EV1 covers the data of all the frames
+			response = append(response, resp[1:])
+		default:
+			response = append(response, resp[1:len(resp)-8])
+		}
 
 		if resp[0] == 0x00 {
 			break
@@ -187,11 +234,140 @@ func (d *Desfire) GetVersion() ([][]byte, error) {
 		d.cmdCtr++
 	}()
 
+	if d.evMode == EV1 {
+		return d.responseFramesEV1(response)
+	}
+
 	return response, nil
 }
 
+// Generation generation of the DESFire card
+type Generation int
+
+const (
+	GenerationUnknown Generation = iota
+	GenerationD40
+	GenerationEV1
+	GenerationEV2
+	GenerationEV3
+)
+
+// CardGeneration generation of the card in the first frame (hardware
+// version) of the response of GetVersion, see ProductVersion.Generation.
+// EV3 cards use the EV2 secure messaging and add SDM, GetFileCounters and
+// the TMCLimit of the Transaction MAC file.
+func CardGeneration(version [][]byte) (Generation, error) {
+	if len(version) < 1 || len(version[0]) < 7 {
+		return GenerationUnknown, fmt.Errorf("hardware version, %w", ErrLengthError)
+	}
+	hw := parseProductVersion(version[0])
+	if hw.VendorID != 0x04 {
+		return GenerationUnknown, fmt.Errorf("vendor 0x%02X is not NXP", hw.VendorID)
+	}
+	return hw.Generation(), nil
+}
+
+// ProductVersion hardware or software version in the response of
+// GetVersion. StorageSize is the encoded size: 2^(StorageSize>>1) bytes,
+// or between 2^(StorageSize>>1) and 2^((StorageSize>>1)+1) bytes if bit 0
+// is set.
+type ProductVersion struct {
+	VendorID     byte
+	Type         byte
+	SubType      byte
+	MajorVersion byte
+	MinorVersion byte
+	StorageSize  byte
+	Protocol     byte
+}
+
+// Size storage size in bytes and if it is the exact size (else the size is
+// between Size and 2*Size)
+func (p ProductVersion) Size() (int, bool) {
+	return 1 << (p.StorageSize >> 1), p.StorageSize&0x01 == 0
+}
+
+// Generation generation of the card from the hardware major version (0x00
+// D40, 0x01 EV1, 0x12 EV2, 0x33 EV3), GenerationUnknown if the vendor is
+// not NXP
+func (p ProductVersion) Generation() Generation {
+	if p.VendorID != 0x04 {
+		return GenerationUnknown
+	}
+	switch p.MajorVersion {
+	case 0x00:
+		return GenerationD40
+	case 0x01:
+		return GenerationEV1
+	case 0x12:
+		return GenerationEV2
+	case 0x33:
+		return GenerationEV3
+	default:
+		return GenerationUnknown
+	}
+}
+
+func parseProductVersion(data []byte) ProductVersion {
+	return ProductVersion{
+		VendorID:     data[0],
+		Type:         data[1],
+		SubType:      data[2],
+		MajorVersion: data[3],
+		MinorVersion: data[4],
+		StorageSize:  data[5],
+		Protocol:     data[6],
+	}
+}
+
+// Version response of GetVersion: the hardware and software versions, the
+// UID, the batch number and the calendar week and year of production
+type Version struct {
+	Hardware       ProductVersion
+	Software       ProductVersion
+	UID            []byte
+	BatchNo        []byte
+	ProductionWeek int
+	ProductionYear int
+}
+
+// fromBCD value of the BCD byte
+func fromBCD(b byte) int {
+	return int(b>>4)*10 + int(b&0x0F)
+}
+
+// ParseVersion version of the card in the response of GetVersion (3 frames)
+func ParseVersion(version [][]byte) (*Version, error) {
+	if len(version) < 3 || len(version[0]) < 7 || len(version[1]) < 7 || len(version[2]) < 14 {
+		return nil, fmt.Errorf("version, %w", ErrLengthError)
+	}
+	return &Version{
+		Hardware:       parseProductVersion(version[0]),
+		Software:       parseProductVersion(version[1]),
+		UID:            version[2][0:7],
+		BatchNo:        version[2][7:12],
+		ProductionWeek: fromBCD(version[2][12]),
+		ProductionYear: 2000 + fromBCD(version[2][13]),
+	}, nil
+}
+
+// Generation generation of the card from the hardware major version
+func (v *Version) Generation() Generation {
+	return v.Hardware.Generation()
+}
+
+// ParseFreeMem free memory in bytes in the response of FreeMem
+func ParseFreeMem(data []byte) (int, error) {
+	if len(data) != 3 {
+		return 0, fmt.Errorf("len free memory = %d, %w", len(data), ErrLengthError)
+	}
+	return getUint24(data), nil
+}
+
 // GetCardUID resturn the UID
 func (d *Desfire) GetCardUID() ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
 
 	cmd := 0x51
 
@@ -207,6 +383,9 @@ func (d *Desfire) GetCardUID() ([]byte, error) {
 			return nil, err
 		}
 	case EV1:
+		if _, err := d.commandEV1(byte(cmd), nil, nil, PLAIN); err != nil {
+			return nil, err
+		}
 	default:
 		// return nil, errors.New("desfire EV2 or EV1 only support")
 	}
@@ -231,11 +410,11 @@ func (d *Desfire) GetCardUID() ([]byte, error) {
 		}
 		responseData = getDataOnFullModeResponseEV2(d.block, iv, resp)
 	case EV1:
-		iv, err := calcResponseIVOnFullModeEV1(d.block, cmd, nil, nil)
+		var err error
+		responseData, err = d.responseEV1(resp[0], resp[1:], FULL)
 		if err != nil {
 			return nil, err
 		}
-		responseData = getDataOnFullModeResponseEV1(d.block, iv, resp)
 	default:
 		return nil, errors.New("only desfire EV2 mode support")
 	}
@@ -248,3 +427,76 @@ func (d *Desfire) GetCardUID() ([]byte, error) {
 	}
 	return responseData[2 : responseData[1]+2], nil
 }
+
+// ReadSignature returns the NXP originality signature of the UID (56 bytes,
+// ECDSA secp224r1), in FULL mode after the authentication. Verify it with
+// package originality.
+func (d *Desfire) ReadSignature() ([]byte, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	cmd := byte(0x3C)
+
+	apdu := make([]byte, 0)
+	apdu = append(apdu, cmd)
+	cmdHeader := []byte{0x00}
+	apdu = append(apdu, cmdHeader...)
+
+	switch d.evMode {
+	case EV2:
+		cmacT, err := calcMacOnCommandEV2(d.blockMac, d.ti, cmd, d.cmdCtr, cmdHeader, nil)
+		if err != nil {
+			return nil, err
+		}
+		apdu = append(apdu, cmacT...)
+	case D40:
+	case EV1:
+		if _, err := d.commandEV1(cmd, cmdHeader, nil, PLAIN); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("only EV1 and Ev2 support")
+	}
+
+	resp, err := d.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyResponse(resp); err != nil {
+		return nil, err
+	}
+	defer func() {
+		d.cmdCtr++
+	}()
+
+	var responseData []byte
+	switch d.evMode {
+	case EV2:
+		iv, err := calcResponseIVOnFullModeEV2(d.ksesAuthEnc, d.ti, d.cmdCtr+1)
+		if err != nil {
+			return nil, err
+		}
+		responseData = getDataOnFullModeResponseEV2(d.block, iv, resp)
+	case D40:
+		commMode := FULL
+		if d.block == nil {
+			commMode = PLAIN
+		}
+		var err error
+		responseData, err = d.responseD40(resp[1:], commMode)
+		if err != nil {
+			return nil, err
+		}
+	case EV1:
+		var err error
+		responseData, err = d.responseEV1(resp[0], resp[1:], FULL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(responseData) < 56 {
+		return nil, fmt.Errorf("len signature = %d, %w", len(responseData), ErrLengthError)
+	}
+	return responseData[:56], nil
+}
@@ -1,34 +1,18 @@
 package ev2
 
 import (
+	"bytes"
+	"errors"
 	"log"
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/dumacp/smartcard"
-	"github.com/dumacp/smartcard/pcsc"
 )
 
 func Test_desfire_GetVersion(t *testing.T) {
 
-	ctx, err := pcsc.NewContext()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	readers, err := ctx.ListReaders()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var reader pcsc.Reader
-	for i, r := range readers {
-		log.Printf("reader %q: %s", i, r)
-		if strings.Contains(r, "PICC") {
-			reader = pcsc.NewReader(ctx, r)
-		}
-	}
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
@@ -89,23 +73,7 @@ func Test_desfire_GetVersion(t *testing.T) {
 }
 
 func Test_desfire_GetCardUID(t *testing.T) {
-	ctx, err := pcsc.NewContext()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	readers, err := ctx.ListReaders()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var reader pcsc.Reader
-	for i, r := range readers {
-		log.Printf("reader %q: %s", i, r)
-		if strings.Contains(r, "PICC") {
-			reader = pcsc.NewReader(ctx, r)
-		}
-	}
+	reader := piccReader(t)
 
 	direct, err := reader.ConnectDirect()
 	if err != nil {
@@ -189,3 +157,114 @@ func Test_desfire_GetCardUID(t *testing.T) {
 		})
 	}
 }
+
+func TestDesfire_ReadSignature(t *testing.T) {
+	signature := bytes.Repeat([]byte{0x5A, 0xA5}, 28)
+	tests := []struct {
+		name string
+		auth bool
+	}{
+		{
+			name: "plain without authentication",
+		},
+		{
+			name: "full after AuthenticateAES",
+			auth: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := mustHex(t, "00112233445566778899AABBCCDDEEFF")
+			card := newEV1Card(t, 0xAA, key)
+			card.Handle([]byte{0x3C, 0x00}, func(apdu []byte) ([]byte, error) {
+				if card.block == nil {
+					return append([]byte{0x00}, signature...), nil
+				}
+				card.iv = cmacEV1(card.block, card.iv, apdu)
+				return append([]byte{0x00}, card.full(signature)...), nil
+			})
+			d := NewDesfire(card)
+			if tt.auth {
+				data, err := d.AuthenticateAES(TargetPrimaryApp, 0)
+				if err == nil {
+					_, err = d.AuthenticateAESPart2(key, data)
+				}
+				if err != nil {
+					t.Fatalf("AuthenticateAES() error = %v", err)
+				}
+			}
+			got, err := d.ReadSignature()
+			if err != nil {
+				t.Fatalf("ReadSignature() error = %v", err)
+			}
+			if !bytes.Equal(got, signature) {
+				t.Errorf("ReadSignature() = [% X], want [% X]", got, signature)
+			}
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	version := [][]byte{
+		mustHex(t, "04010112001A05"),
+		mustHex(t, "04010102011A05"),
+		mustHex(t, "04516A12345680BA443473403920"),
+	}
+	got, err := ParseVersion(version)
+	if err != nil {
+		t.Fatalf("ParseVersion() error = %v", err)
+	}
+	want := &Version{
+		Hardware: ProductVersion{VendorID: 0x04, Type: 0x01, SubType: 0x01,
+			MajorVersion: 0x12, MinorVersion: 0x00, StorageSize: 0x1A, Protocol: 0x05},
+		Software: ProductVersion{VendorID: 0x04, Type: 0x01, SubType: 0x01,
+			MajorVersion: 0x02, MinorVersion: 0x01, StorageSize: 0x1A, Protocol: 0x05},
+		UID:            mustHex(t, "04516A12345680"),
+		BatchNo:        mustHex(t, "BA44347340"),
+		ProductionWeek: 39,
+		ProductionYear: 2020,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseVersion() = %+v, want %+v", got, want)
+	}
+	if size, exact := got.Hardware.Size(); size != 8192 || !exact {
+		t.Errorf("Size() = %d, %v, want 8192, true", size, exact)
+	}
+	if got.Generation() != GenerationEV2 {
+		t.Errorf("Generation() = %v, want %v", got.Generation(), GenerationEV2)
+	}
+	if _, err := ParseVersion(version[:2]); !errors.Is(err, ErrLengthError) {
+		t.Errorf("ParseVersion(2 frames) error = %v, want %v", err, ErrLengthError)
+	}
+}
+
+func TestParseFreeMem(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "7 KiB",
+			data: "001C00",
+			want: 7168,
+		},
+		{
+			name:    "short",
+			data:    "001C",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFreeMem(mustHex(t, tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFreeMem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFreeMem() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ev2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"github.com/aead/cmac"
+	"github.com/dumacp/smartcard/nxp"
+)
+
+// ProximityCheck run the proximity check (PreparePC, ProximityCheck and
+// VerifyPC) in rounds (1, 2, 4 or 8) of ProximityCheck. The MAC of VerifyPC
+// is calculated with key (the VCProximityKey, AES) or with the session
+// key of AuthenticateEV2First if key is nil. The result has the round trip
+// times of the ProximityCheck commands to enforce the threshold.
+func (d *Desfire) ProximityCheck(key []byte, rounds int) (*nxp.ProximityCheck, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	var blockMac cipher.Block
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		blockMac = block
+	} else {
+		if d.evMode != EV2 || d.blockMac == nil {
+			return nil, errors.New("proximity check without key only after AuthenticateEV2First")
+		}
+		blockMac = d.blockMac
+	}
+
+	verify := func(resp []byte) error {
+		// ProximityCheck and VerifyPC answer with the status 0x90
+		if len(resp) > 0 && resp[0] == 0x90 {
+			return nil
+		}
+		return VerifyResponse(resp)
+	}
+	mac := func(data []byte) ([]byte, error) {
+		cmacS, err := cmac.Sum(data, blockMac, blockMac.BlockSize())
+		if err != nil {
+			return nil, err
+		}
+		return truncateMacEV2(cmacS), nil
+	}
+
+	return nxp.RunProximityCheck(d, rounds, verify, mac)
+}
@@ -0,0 +1,88 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+
+	"github.com/aead/cmac"
+	"github.com/dumacp/smartcard/nxp"
+	"github.com/dumacp/smartcard/sim"
+)
+
+func TestDesfire_ProximityCheck(t *testing.T) {
+	key := mustHex(t, "000102030405060708090A0B0C0D0E0F")
+	rndR := mustHex(t, "1122334455667788")
+	prepare := mustHex(t, "0000012C")
+
+	tests := []struct {
+		name    string
+		key     []byte
+		cardKey []byte
+		wantErr error
+	}{
+		{
+			name:    "VCProximityKey",
+			key:     key,
+			cardKey: key,
+		},
+		{
+			name:    "other key",
+			key:     mustHex(t, "F0E0D0C0B0A090807060504030201000"),
+			cardKey: key,
+			wantErr: ErrIntegrityError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := aes.NewCipher(tt.cardKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mac := func(data []byte) []byte {
+				cmacS, _ := cmac.Sum(data, block, block.BlockSize())
+				return truncateMacEV2(cmacS)
+			}
+			rndC := make([]byte, 0)
+			card := sim.NewCard(nil, nil, nil, 0x20)
+			card.Handle([]byte{0xF0}, func(apdu []byte) ([]byte, error) {
+				return prepare, nil
+			})
+			card.Handle([]byte{0xF2}, func(apdu []byte) ([]byte, error) {
+				n := int(apdu[1])
+				i := len(rndC)
+				rndC = append(rndC, apdu[2:2+n]...)
+				return append([]byte{0x90}, rndR[i:i+n]...), nil
+			})
+			card.Handle([]byte{0xFD}, func(apdu []byte) ([]byte, error) {
+				data := append([]byte{}, prepare[1:]...)
+				for i := range rndC {
+					data = append(data, rndR[i], rndC[i])
+				}
+				if !bytes.Equal(apdu[1:], mac(append([]byte{0xFD}, data...))) {
+					return []byte{0x1E}, nil
+				}
+				return append([]byte{0x90}, mac(append([]byte{0x90}, data...))...), nil
+			})
+
+			got, err := NewDesfire(card).ProximityCheck(tt.key, 4)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProximityCheck() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Times) != 4 || !bytes.Equal(got.RndR, rndR) {
+				t.Errorf("ProximityCheck() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestDesfire_ProximityCheck_withoutSession(t *testing.T) {
+	card := sim.NewCard(nil, nil, nil, 0x20)
+	if _, err := NewDesfire(card).ProximityCheck(nil, 8); err == nil || errors.Is(err, nxp.ErrProximityCheck) {
+		t.Errorf("ProximityCheck() error = %v, want session error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package ev2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// SDMOptions options of the Secure Dynamic Messaging (SDM) of a
+// FileType.StandardData file (DESFire EV3)
+type SDMOptions int
+
+const (
+	SDMASCIIEncoding SDMOptions = 0x01
+	SDMENCFileData   SDMOptions = 0x10
+	SDMReadCtrLimit  SDMOptions = 0x20
+	SDMReadCtr       SDMOptions = 0x40
+	SDMUIDMirror     SDMOptions = 0x80
+)
+
+// SDMSettings settings of the SDM mirrors of a file. MetaRead, FileRead and
+// CtrRet are the SDM access rights (FREE in MetaRead mirrors the plain UID
+// and SDMReadCtr, a key the encrypted PICCData). The offsets are positions
+// in the file, only those enabled by Options and the access rights are sent.
+type SDMSettings struct {
+	Options        SDMOptions
+	MetaRead       AccessRights
+	FileRead       AccessRights
+	CtrRet         AccessRights
+	UIDOffset      int
+	ReadCtrOffset  int
+	PICCDataOffset int
+	MACInputOffset int
+	ENCOffset      int
+	ENCLength      int
+	MACOffset      int
+	ReadCtrLimit   int
+}
+
+// putUint24 3 bytes LSB first of the offsets and lengths of SDM
+func putUint24(v int) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(v))
+	return b[:3]
+}
+
+// getUint24 value of 3 bytes LSB first
+func getUint24(data []byte) int {
+	return int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+}
+
+// parseSDMSettings SDM settings in the response of GetFileSettings, the
+// same fields of Bytes
+func parseSDMSettings(data []byte) (*SDMSettings, error) {
+	if len(data) < 3 {
+		return nil, fmt.Errorf("len SDM settings = %d, %w", len(data), ErrLengthError)
+	}
+	accessRights := binary.LittleEndian.Uint16(data[1:3])
+	s := &SDMSettings{
+		Options:  SDMOptions(data[0]),
+		MetaRead: AccessRights(accessRights >> 12 & 0x0F),
+		FileRead: AccessRights(accessRights >> 8 & 0x0F),
+		CtrRet:   AccessRights(accessRights & 0x0F),
+	}
+	data = data[3:]
+
+	offsets := make([]*int, 0)
+	switch {
+	case s.MetaRead == FREE:
+		if s.Options&SDMUIDMirror != 0 {
+			offsets = append(offsets, &s.UIDOffset)
+		}
+		if s.Options&SDMReadCtr != 0 {
+			offsets = append(offsets, &s.ReadCtrOffset)
+		}
+	case s.MetaRead != NO_ACCESS:
+		offsets = append(offsets, &s.PICCDataOffset)
+	}
+	if s.FileRead != NO_ACCESS {
+		offsets = append(offsets, &s.MACInputOffset)
+		if s.Options&SDMENCFileData != 0 {
+			offsets = append(offsets, &s.ENCOffset, &s.ENCLength)
+		}
+		offsets = append(offsets, &s.MACOffset)
+	}
+	if s.Options&SDMReadCtrLimit != 0 {
+		offsets = append(offsets, &s.ReadCtrLimit)
+	}
+
+	if len(data) < 3*len(offsets) {
+		return nil, fmt.Errorf("len SDM settings = %d, %w", 3+len(data), ErrLengthError)
+	}
+	for i, v := range offsets {
+		*v = getUint24(data[3*i:])
+	}
+	return s, nil
+}
+
+// Bytes SDM fields of ChangeFileSettings, from SDMOptions
+func (s *SDMSettings) Bytes() ([]byte, error) {
+	offsets := []int{s.UIDOffset, s.ReadCtrOffset, s.PICCDataOffset,
+		s.MACInputOffset, s.ENCOffset, s.ENCLength, s.MACOffset, s.ReadCtrLimit}
+	for _, v := range offsets {
+		if v < 0 || v > 0xFFFFFF {
+			return nil, fmt.Errorf("SDM offset %d out of range, %w", v, ErrParameterError)
+		}
+	}
+	if s.Options&SDMENCFileData != 0 && s.FileRead == NO_ACCESS {
+		return nil, errors.New("SDMENCFileData without SDMFileRead key")
+	}
+
+	data := make([]byte, 0)
+	data = append(data, byte(s.Options))
+
+	accessRights := uint16(0)
+
+	accessRights |= (uint16(s.MetaRead&0x0F) << 12)
+	accessRights |= (uint16(s.FileRead&0x0F) << 8)
+	accessRights |= (uint16(0x0F) << 4)
+	accessRights |= (uint16(s.CtrRet&0x0F) << 0)
+
+	accessRightsBytes := make([]byte, 2)
+
+	binary.LittleEndian.PutUint16(accessRightsBytes, accessRights)
+
+	data = append(data, accessRightsBytes...)
+
+	switch {
+	case s.MetaRead == FREE:
+		if s.Options&SDMUIDMirror != 0 {
+			data = append(data, putUint24(s.UIDOffset)...)
+		}
+		if s.Options&SDMReadCtr != 0 {
+			data = append(data, putUint24(s.ReadCtrOffset)...)
+		}
+	case s.MetaRead != NO_ACCESS:
+		data = append(data, putUint24(s.PICCDataOffset)...)
+	}
+	if s.FileRead != NO_ACCESS {
+		data = append(data, putUint24(s.MACInputOffset)...)
+		if s.Options&SDMENCFileData != 0 {
+			data = append(data, putUint24(s.ENCOffset)...)
+			data = append(data, putUint24(s.ENCLength)...)
+		}
+		data = append(data, putUint24(s.MACOffset)...)
+	}
+	if s.Options&SDMReadCtrLimit != 0 {
+		data = append(data, putUint24(s.ReadCtrLimit)...)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,194 @@
+package ev2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+func TestSDMSettings_Bytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		sdm     SDMSettings
+		want    []byte
+		wantErr bool
+	}{
+		{
+			// AN12196, ChangeFileSettings of the NDEF file
+			name: "encrypted PICCData and MAC",
+			sdm: SDMSettings{
+				Options:        SDMUIDMirror | SDMReadCtr | SDMASCIIEncoding,
+				MetaRead:       KeyID_0x02,
+				FileRead:       KeyID_0x01,
+				CtrRet:         KeyID_0x01,
+				PICCDataOffset: 0x20,
+				MACInputOffset: 0x43,
+				MACOffset:      0x43,
+			},
+			want: mustHex(t, "C1F121200000430000430000"),
+		},
+		{
+			name: "plain UID and SDMReadCtr",
+			sdm: SDMSettings{
+				Options:       SDMUIDMirror | SDMReadCtr | SDMReadCtrLimit | SDMASCIIEncoding,
+				MetaRead:      FREE,
+				FileRead:      NO_ACCESS,
+				CtrRet:        FREE,
+				UIDOffset:     0x2A,
+				ReadCtrOffset: 0x39,
+				ReadCtrLimit:  0x0100,
+			},
+			want: mustHex(t, "E1FEEF2A0000390000000100"),
+		},
+		{
+			name: "encrypted file data",
+			sdm: SDMSettings{
+				Options:        SDMUIDMirror | SDMReadCtr | SDMENCFileData | SDMASCIIEncoding,
+				MetaRead:       KeyID_0x02,
+				FileRead:       KeyID_0x01,
+				CtrRet:         NO_ACCESS,
+				PICCDataOffset: 0x20,
+				MACInputOffset: 0x20,
+				ENCOffset:      0x43,
+				ENCLength:      0x20,
+				MACOffset:      0x6A,
+			},
+			want: mustHex(t, "D1FF212000002000004300002000006A0000"),
+		},
+		{
+			name: "encrypted file data without key",
+			sdm: SDMSettings{
+				Options:  SDMENCFileData,
+				MetaRead: NO_ACCESS,
+				FileRead: NO_ACCESS,
+				CtrRet:   NO_ACCESS,
+			},
+			wantErr: true,
+		},
+		{
+			name: "offset out of range",
+			sdm: SDMSettings{
+				Options:   SDMUIDMirror,
+				MetaRead:  FREE,
+				FileRead:  NO_ACCESS,
+				CtrRet:    NO_ACCESS,
+				UIDOffset: 0x1000000,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sdm.Bytes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SDMSettings.Bytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("SDMSettings.Bytes() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardGeneration(t *testing.T) {
+	tests := []struct {
+		name    string
+		version [][]byte
+		want    Generation
+		wantErr bool
+	}{
+		{
+			name:    "EV1",
+			version: [][]byte{mustHex(t, "04010101001805")},
+			want:    GenerationEV1,
+		},
+		{
+			name:    "EV2",
+			version: [][]byte{mustHex(t, "04010112001A05")},
+			want:    GenerationEV2,
+		},
+		{
+			name: "EV3",
+			version: [][]byte{
+				mustHex(t, "04010133001A05"),
+				mustHex(t, "04010103001A05"),
+				mustHex(t, "045F6A12345680BA65448810212101"),
+			},
+			want: GenerationEV3,
+		},
+		{
+			name:    "unknown",
+			version: [][]byte{mustHex(t, "04010142001A05")},
+			want:    GenerationUnknown,
+		},
+		{
+			name:    "not NXP",
+			version: [][]byte{mustHex(t, "05010133001A05")},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CardGeneration(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CardGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CardGeneration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesfire_GetFileCounters(t *testing.T) {
+	card := sim.NewCard(nil, nil, nil, 0x20)
+	card.Handle([]byte{0xF6, 0x02}, func(apdu []byte) ([]byte, error) {
+		return mustHex(t, "002A00000000"), nil
+	})
+	card.Handle([]byte{0xF6, 0x03}, func(apdu []byte) ([]byte, error) {
+		return []byte{0x9D}, nil
+	})
+	tests := []struct {
+		name     string
+		fileNo   int
+		commMode CommMode
+		want     []byte
+		wantErr  bool
+	}{
+		{
+			name:     "plain",
+			fileNo:   2,
+			commMode: PLAIN,
+			want:     []byte{0x2A, 0x00, 0x00},
+		},
+		{
+			name:     "permission denied",
+			fileNo:   3,
+			commMode: PLAIN,
+			wantErr:  true,
+		},
+		{
+			name:     "full without authentication",
+			fileNo:   2,
+			commMode: FULL,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDesfire(card)
+			got, err := d.GetFileCounters(tt.fileNo, TargetPrimaryApp, tt.commMode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFileCounters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("GetFileCounters() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
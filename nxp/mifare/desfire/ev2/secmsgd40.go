@@ -0,0 +1,173 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+)
+
+// crcD40 CRC16 of the D40 secure messaging (CRC_A of ISO/IEC 14443-3), LSB
+// first
+func crcD40(data []byte) []byte {
+	crc := uint16(0x6363)
+	for _, v := range data {
+		v ^= byte(crc)
+		v ^= v << 4
+		crc = (crc >> 8) ^ uint16(v)<<8 ^ uint16(v)<<3 ^ uint16(v)>>4
+	}
+	return []byte{byte(crc), byte(crc >> 8)}
+}
+
+// sendModeD40 cryptogram of data in the D40 send mode. The PCD always
+// deciphers: y(i) = D(x(i) XOR y(i-1)), with the zero IV in every message.
+func sendModeD40(block cipher.Block, data []byte) []byte {
+	bs := block.BlockSize()
+
+	dest := make([]byte, len(data))
+	prev := make([]byte, bs)
+	x := make([]byte, bs)
+	for i := 0; i+bs <= len(data); i += bs {
+		for j := range x {
+			x[j] = data[i+j] ^ prev[j]
+		}
+		block.Decrypt(dest[i:i+bs], x)
+		prev = dest[i : i+bs]
+	}
+	return dest
+}
+
+// receiveModeD40 plain data of the cryptogram in the D40 receive mode (CBC
+// deciphering with the zero IV in every message)
+func receiveModeD40(block cipher.Block, data []byte) []byte {
+	dest := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, make([]byte, block.BlockSize())).CryptBlocks(dest, data)
+	return dest
+}
+
+// macD40 MAC of the D40 secure messaging, the first 4 bytes of the last
+// block of the CBC enciphering of data (zero padding)
+func macD40(block cipher.Block, data []byte) []byte {
+	bs := block.BlockSize()
+
+	plaindata := make([]byte, 0)
+	plaindata = append(plaindata, data...)
+	if len(plaindata) <= 0 || len(plaindata)%bs != 0 {
+		plaindata = append(plaindata, make([]byte, bs-len(plaindata)%bs)...)
+	}
+
+	dest := make([]byte, len(plaindata))
+	cipher.NewCBCEncrypter(block, make([]byte, bs)).CryptBlocks(dest, plaindata)
+
+	return dest[len(dest)-bs : len(dest)-bs+4]
+}
+
+func changeKeyCryptogramD40(block cipher.Block,
+	keyNo, authKey, keyType, keyVersion int,
+	newKey, oldKey []byte) ([]byte, error) {
+
+	plaindata := make([]byte, 0)
+	if (keyNo & 0x1F) == authKey {
+		plaindata = append(plaindata, newKey...)
+		if keyType == int(AES) {
+			plaindata = append(plaindata, byte(keyVersion))
+		}
+		plaindata = append(plaindata, crcD40(plaindata)...)
+	} else {
+		if len(oldKey) < len(newKey) {
+			return nil, errors.New("old key is null")
+		}
+		for i := range newKey {
+			plaindata = append(plaindata, newKey[i]^oldKey[i])
+		}
+		if keyType == int(AES) {
+			plaindata = append(plaindata, byte(keyVersion))
+		}
+		plaindata = append(plaindata, crcD40(plaindata)...)
+		plaindata = append(plaindata, crcD40(newKey)...)
+	}
+
+	if len(plaindata)%block.BlockSize() != 0 {
+		plaindata = append(plaindata, make([]byte, block.BlockSize()-len(plaindata)%block.BlockSize())...)
+	}
+
+	return sendModeD40(block, plaindata), nil
+}
+
+// commandD40 data field of the command in commMode with the D40 secure
+// messaging (after AuthenticateD40). PLAIN mode doesn't need authentication.
+func (d *Desfire) commandD40(data []byte, commMode CommMode) ([]byte, error) {
+	switch commMode {
+	case MAC, FULL:
+		if d.block == nil {
+			return nil, errors.New("D40 secure messaging without authentication")
+		}
+	}
+
+	result := make([]byte, 0)
+	switch commMode {
+	case FULL:
+		plaindata := make([]byte, 0)
+		plaindata = append(plaindata, data...)
+		plaindata = append(plaindata, crcD40(data)...)
+		if len(plaindata)%d.block.BlockSize() != 0 {
+			plaindata = append(plaindata,
+				make([]byte, d.block.BlockSize()-len(plaindata)%d.block.BlockSize())...)
+		}
+		result = append(result, sendModeD40(d.block, plaindata)...)
+	case MAC:
+		result = append(result, data...)
+		result = append(result, macD40(d.block, data)...)
+	default:
+		result = append(result, data...)
+	}
+	return result, nil
+}
+
+// responseD40 verify the response in commMode with the D40 secure messaging
+// and return its data. data is the response of all the frames, without the
+// status bytes. In MAC mode the response ends with the MAC of data, in FULL
+// mode it is the cryptogram of data || CRC16(data).
+func (d *Desfire) responseD40(data []byte, commMode CommMode) ([]byte, error) {
+	switch commMode {
+	case MAC, FULL:
+		if d.block == nil {
+			return nil, errors.New("D40 secure messaging without authentication")
+		}
+	}
+
+	switch commMode {
+	case FULL:
+		bs := d.block.BlockSize()
+		if len(data) <= 0 || len(data)%bs != 0 {
+			return nil, fmt.Errorf("len cryptogram = %d, %w", len(data), ErrLengthError)
+		}
+		plaindata := receiveModeD40(d.block, data)
+
+		// the CRC is followed by the zero padding. The CRC16 of data || CRC16
+		// is zero, so the search starts with the longest padding.
+		start := len(plaindata) - 1 - bs
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i <= len(plaindata)-2; i++ {
+			if !bytes.Equal(plaindata[i+2:], make([]byte, len(plaindata)-i-2)) {
+				continue
+			}
+			if bytes.Equal(plaindata[i:i+2], crcD40(plaindata[:i])) {
+				return plaindata[:i], nil
+			}
+		}
+		return nil, fmt.Errorf("CRC16 of response, %w", ErrIntegrityError)
+	case MAC:
+		if len(data) < 4 {
+			return nil, fmt.Errorf("len response = %d without MAC, %w", len(data), ErrLengthError)
+		}
+		if !bytes.Equal(data[len(data)-4:], macD40(d.block, data[:len(data)-4])) {
+			return nil, fmt.Errorf("MAC of response, %w", ErrIntegrityError)
+		}
+		return data[:len(data)-4], nil
+	default:
+		return data, nil
+	}
+}
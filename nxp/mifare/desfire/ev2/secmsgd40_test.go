@@ -0,0 +1,302 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+func Test_crcD40(t *testing.T) {
+	// examples of ISO/IEC 14443-3 annex B
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			name: "00 00",
+			data: []byte{0x00, 0x00},
+			want: []byte{0xA0, 0x1E},
+		},
+		{
+			name: "12 34",
+			data: []byte{0x12, 0x34},
+			want: []byte{0x26, 0xCF},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crcD40(tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("crcD40() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+// d40Card simulates the PICC side of AuthenticateD40 and of the D40 secure
+// messaging, with a value file (1, FULL) and a standard data file (2, MAC)
+type d40Card struct {
+	*sim.Card
+	key    []byte
+	rndB   []byte
+	block  cipher.Block
+	value  uint32
+	data   []byte
+	frames []byte
+	newKey []byte
+}
+
+func new3DES(t *testing.T, key []byte) cipher.Block {
+	k := append(append([]byte{}, key...), key[:8]...)
+	if len(key) == 8 {
+		k = append(k, key...)
+	}
+	block, err := des.NewTripleDESCipher(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// decipher the cryptogram of send mode, x(i) = E(y(i)) XOR y(i-1)
+func cardReceiveD40(block cipher.Block, data []byte) []byte {
+	dest := make([]byte, len(data))
+	prev := make([]byte, block.BlockSize())
+	for i := 0; i < len(data); i += block.BlockSize() {
+		block.Encrypt(dest[i:i+block.BlockSize()], data[i:i+block.BlockSize()])
+		for j := range prev {
+			dest[i+j] ^= prev[j]
+		}
+		prev = data[i : i+block.BlockSize()]
+	}
+	return dest
+}
+
+func cardSendD40(block cipher.Block, data []byte) []byte {
+	dest := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, block.BlockSize())).CryptBlocks(dest, data)
+	return dest
+}
+
+func newD40Card(t *testing.T, key []byte) *d40Card {
+	c := &d40Card{
+		Card: sim.NewCard(nil, nil, nil, 0x20),
+		key:  key,
+		rndB: []byte{0x4C, 0x64, 0x31, 0xA8, 0xD2, 0xB0, 0xEF, 0x17},
+		data: make([]byte, 40),
+	}
+	keyBlock := new3DES(t, key)
+	authenticating := false
+
+	c.Handle([]byte{0x0A}, func(apdu []byte) ([]byte, error) {
+		authenticating = true
+		return append([]byte{0xAF}, cardSendD40(keyBlock, c.rndB)...), nil
+	})
+	c.Handle([]byte{0xAF}, func(apdu []byte) ([]byte, error) {
+		if !authenticating {
+			n := len(c.frames)
+			if n > 16 {
+				n = 16
+			}
+			resp := append([]byte{0x00}, c.frames[:n]...)
+			c.frames = c.frames[n:]
+			if len(c.frames) > 0 {
+				resp[0] = 0xAF
+			}
+			return resp, nil
+		}
+		authenticating = false
+		rndD := cardReceiveD40(keyBlock, apdu[1:])
+		rndBr := append(append([]byte{}, c.rndB[1:]...), c.rndB[0])
+		if !bytes.Equal(rndD[8:], rndBr) {
+			return []byte{0xAE}, nil
+		}
+		rndA := rndD[:8]
+		rndAr := append(append([]byte{}, rndA[1:]...), rndA[0])
+
+		kses, err := sessionKeyEV1(append(append([]byte{}, key...), key...)[:16], rndA, c.rndB)
+		if err != nil {
+			return nil, err
+		}
+		c.block = new3DES(t, kses)
+		return append([]byte{0x00}, cardSendD40(keyBlock, rndAr)...), nil
+	})
+	// GetKeySettings, PLAIN
+	c.Handle([]byte{0x45}, func(apdu []byte) ([]byte, error) {
+		return []byte{0x00, 0x0F, 0x01}, nil
+	})
+	// GetValue, FULL
+	c.Handle([]byte{0x6C, 0x01}, func(apdu []byte) ([]byte, error) {
+		if c.block == nil {
+			return []byte{0xAE}, nil
+		}
+		value := make([]byte, 4)
+		binary.LittleEndian.PutUint32(value, c.value)
+		plain := append(append(value, crcD40(value)...), 0x00, 0x00)
+		return append([]byte{0x00}, cardSendD40(c.block, plain)...), nil
+	})
+	// Credit, MAC
+	c.Handle([]byte{0x0C, 0x01}, func(apdu []byte) ([]byte, error) {
+		if !bytes.Equal(apdu[6:], macD40(c.block, apdu[2:6])) {
+			return []byte{0x1E}, nil
+		}
+		c.value += binary.LittleEndian.Uint32(apdu[2:6])
+		return []byte{0x00}, nil
+	})
+	// ReadData, MAC
+	c.Handle([]byte{0xBD, 0x02}, func(apdu []byte) ([]byte, error) {
+		c.frames = append(append([]byte{}, c.data...), macD40(c.block, c.data)...)
+		resp := append([]byte{0xAF}, c.frames[:16]...)
+		c.frames = c.frames[16:]
+		return resp, nil
+	})
+	// WriteData, FULL
+	c.Handle([]byte{0x3D, 0x02}, func(apdu []byte) ([]byte, error) {
+		plain := cardReceiveD40(c.block, apdu[8:])
+		length := int(apdu[5])
+		if !bytes.Equal(plain[length:length+2], crcD40(plain[:length])) {
+			return []byte{0x1E}, nil
+		}
+		copy(c.data, plain[:length])
+		return []byte{0x00}, nil
+	})
+	// ChangeKey of the authenticated key (PICC master key to AES)
+	c.Handle([]byte{0xC4, 0x80}, func(apdu []byte) ([]byte, error) {
+		plain := cardReceiveD40(c.block, apdu[2:])
+		if !bytes.Equal(plain[17:19], crcD40(plain[:17])) {
+			return []byte{0x1E}, nil
+		}
+		c.newKey = plain[:16]
+		return []byte{0x00}, nil
+	})
+	return c
+}
+
+func TestDesfire_SecureMessagingD40(t *testing.T) {
+	aesKey := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{
+			name: "DES",
+			key:  make([]byte, 8),
+		},
+		{
+			name: "2TDEA",
+			key: []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF,
+				0xFE, 0xDC, 0xBA, 0x98, 0x76, 0x54, 0x32, 0x10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := newD40Card(t, tt.key)
+			d := NewDesfire(card)
+
+			// plain communication without authentication
+			settings, err := d.GetKeySettings()
+			if err != nil || !bytes.Equal(settings, []byte{0x0F, 0x01}) {
+				t.Fatalf("GetKeySettings() = [% X], %v", settings, err)
+			}
+			if _, err := d.GetValue(1, TargetPrimaryApp, FULL); err == nil {
+				t.Fatalf("GetValue() without authentication, want error")
+			}
+
+			data, err := d.AuthenticateD40(0)
+			if err == nil {
+				_, err = d.AuthenticateD40Part2(tt.key, data)
+			}
+			if err != nil {
+				t.Fatalf("AuthenticateD40() error = %v", err)
+			}
+
+			if err := d.Credit(1, TargetPrimaryApp, 100, MAC); err != nil {
+				t.Fatalf("Credit() error = %v", err)
+			}
+			value, err := d.GetValue(1, TargetPrimaryApp, FULL)
+			if err != nil || binary.LittleEndian.Uint32(value) != 100 {
+				t.Fatalf("GetValue() = [% X], %v, want 100", value, err)
+			}
+			want := bytes.Repeat([]byte{0xA5}, 30)
+			if err := d.WriteData(2, TargetPrimaryApp, 0, want, FULL); err != nil {
+				t.Fatalf("WriteData() error = %v", err)
+			}
+			got, err := d.ReadData(2, TargetPrimaryApp, 0, 0, MAC)
+			if err != nil {
+				t.Fatalf("ReadData() error = %v", err)
+			}
+			want = append(want, make([]byte, 10)...)
+			if !bytes.Equal(got, want) {
+				t.Errorf("ReadData() = [% X], want [% X]", got, want)
+			}
+
+			if err := d.ChangeKey(0, 0, AES, TargetPrimaryApp, aesKey, nil); err != nil {
+				t.Fatalf("ChangeKey() error = %v", err)
+			}
+			if !bytes.Equal(card.newKey, aesKey) {
+				t.Errorf("ChangeKey() new key = [% X], want [% X]", card.newKey, aesKey)
+			}
+		})
+	}
+}
+
+func TestDesfire_responseD40(t *testing.T) {
+	d := &Desfire{block: new3DES(t, make([]byte, 16))}
+	data := []byte{0x01, 0x02, 0x03}
+	mac := macD40(d.block, data)
+	tests := []struct {
+		name     string
+		data     []byte
+		commMode CommMode
+		want     []byte
+		wantErr  error
+	}{
+		{
+			name:     "plain",
+			data:     data,
+			commMode: PLAIN,
+			want:     data,
+		},
+		{
+			name:     "mac",
+			data:     append(append([]byte{}, data...), mac...),
+			commMode: MAC,
+			want:     data,
+		},
+		{
+			name:     "wrong mac",
+			data:     append(append([]byte{}, data...), 0x00, 0x00, 0x00, 0x00),
+			commMode: MAC,
+			wantErr:  ErrIntegrityError,
+		},
+		{
+			name:     "full",
+			data:     cardSendD40(d.block, append(append(append([]byte{}, data...), crcD40(data)...), 0x00, 0x00, 0x00)),
+			commMode: FULL,
+			want:     data,
+		},
+		{
+			name:     "wrong crc",
+			data:     cardSendD40(d.block, append(append([]byte{}, data...), 0x00, 0x00, 0x00, 0x00, 0x00)),
+			commMode: FULL,
+			wantErr:  ErrIntegrityError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.responseD40(tt.data, tt.commMode)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("responseD40() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("responseD40() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
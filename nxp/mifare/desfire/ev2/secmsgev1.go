@@ -1,14 +1,70 @@
 package ev2
 
 import (
+	"bytes"
 	"crypto/cipher"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash/crc32"
-
-	"github.com/aead/cmac"
 )
 
+// crcEV1 CRC32 of the EV1 secure messaging (CRC-32/JAMCRC), LSB first
+func crcEV1(data []byte) []byte {
+	crc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(crc, ^crc32.ChecksumIEEE(data))
+	return crc
+}
+
+// cmacEV1 CMAC (NIST SP 800-38B) of data with iv, the EV1 secure messaging
+// chains the CMAC of the previous message as IV. The CMAC isn't truncated,
+// it is the IV of the next message and only the first 8 bytes are sent.
+func cmacEV1(block cipher.Block, iv, data []byte) []byte {
+	bs := block.BlockSize()
+
+	rb := byte(0x87)
+	if bs == 8 {
+		rb = 0x1B
+	}
+	k1 := make([]byte, bs)
+	block.Encrypt(k1, k1)
+	k1 = cmacShiftEV1(k1, rb)
+	k2 := cmacShiftEV1(k1, rb)
+
+	msg := make([]byte, len(data))
+	copy(msg, data)
+	if len(msg) > 0 && len(msg)%bs == 0 {
+		for i := range k1 {
+			msg[len(msg)-bs+i] ^= k1[i]
+		}
+	} else {
+		msg = append(msg, 0x80)
+		msg = append(msg, make([]byte, (bs-len(msg)%bs)%bs)...)
+		for i := range k2 {
+			msg[len(msg)-bs+i] ^= k2[i]
+		}
+	}
+
+	dest := make([]byte, len(msg))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(dest, msg)
+
+	return dest[len(dest)-bs:]
+}
+
+func cmacShiftEV1(data []byte, rb byte) []byte {
+	res := make([]byte, len(data))
+	for i := range data {
+		res[i] = data[i] << 1
+		if i+1 < len(data) {
+			res[i] |= data[i+1] >> 7
+		}
+	}
+	if data[0]&0x80 != 0 {
+		res[len(res)-1] ^= rb
+	}
+	return res
+}
+
 func encryptionOncommandEV1(block cipher.Block, cmd int, cmdHeader, cmdData, iv []byte) ([]byte, error) {
 
 	mode := cipher.NewCBCEncrypter(block, iv)
@@ -20,10 +76,8 @@ func encryptionOncommandEV1(block cipher.Block, cmd int, cmdHeader, cmdData, iv
 	crcData = append(crcData, cmdHeader...)
 	crcData = append(crcData, cmdData...)
 
-	crc := crc32.NewIEEE().Sum(crcData)
-
 	plaindata = append(plaindata, cmdData...)
-	plaindata = append(plaindata, crc[len(crc)-4:]...)
+	plaindata = append(plaindata, crcEV1(crcData)...)
 
 	if len(plaindata)%block.BlockSize() != 0 {
 		plaindata = append(plaindata,
@@ -36,36 +90,101 @@ func encryptionOncommandEV1(block cipher.Block, cmd int, cmdHeader, cmdData, iv
 	return resp, nil
 }
 
-func calcResponseIVOnFullModeEV1(block cipher.Block,
-	cmd int, cmdHeader, iv []byte) ([]byte, error) {
+func getDataOnFullModeResponseEV1(block cipher.Block, iv []byte,
+	reponse []byte) []byte {
 
-	data := make([]byte, 0)
-	data = append(data, byte(cmd))
-	data = append(data, cmdHeader...)
-	// data = append(data, 0x80)
-	// data = append(data, make([]byte, 6)...)
+	mode := cipher.NewCBCDecrypter(block, iv)
+	dest := make([]byte, len(reponse[1:]))
+	mode.CryptBlocks(dest, reponse[1:])
 
-	// log.Printf("datacmac: % X", data)
+	return dest
 
-	res, err := cmac.Sum(data, block, 8)
-	if err != nil {
-		return nil, err
+}
+
+// commandEV1 data field of the command in commMode with the EV1 secure
+// messaging (after AuthenticateISO or AuthenticateAES). The IV of the
+// session is updated: in PLAIN mode the CMAC isn't sent but it is calculated
+// anyway.
+func (d *Desfire) commandEV1(cmd byte, cmdHeader, data []byte, commMode CommMode) ([]byte, error) {
+	if d.block == nil || len(d.iv) != d.block.BlockSize() {
+		return nil, errors.New("EV1 secure messaging without authentication")
 	}
 
-	return res, nil
+	switch commMode {
+	case FULL:
+		cryptograma, err := encryptionOncommandEV1(d.block, int(cmd), cmdHeader, data, d.iv)
+		if err != nil {
+			return nil, err
+		}
+		d.iv = append([]byte{}, cryptograma[len(cryptograma)-d.block.BlockSize():]...)
+		return cryptograma, nil
+	}
+
+	datamac := make([]byte, 0)
+	datamac = append(datamac, cmd)
+	datamac = append(datamac, cmdHeader...)
+	datamac = append(datamac, data...)
+
+	d.iv = cmacEV1(d.block, d.iv, datamac)
+
+	result := make([]byte, 0)
+	result = append(result, data...)
+	if commMode == MAC {
+		result = append(result, d.iv[:8]...)
+	}
+	return result, nil
 }
 
-func getDataOnFullModeResponseEV1(block cipher.Block, iv []byte,
-	reponse []byte) []byte {
+// responseEV1 verify the response in commMode with the EV1 secure messaging
+// and return its data. data is the response of all the frames, without the
+// status bytes, and status is the status of the last frame. In PLAIN and MAC
+// modes the response ends with the CMAC of data || status, in FULL mode it
+// is the cryptogram of data || CRC32(data || status).
+func (d *Desfire) responseEV1(status byte, data []byte, commMode CommMode) ([]byte, error) {
+	if d.block == nil || len(d.iv) != d.block.BlockSize() {
+		return nil, errors.New("EV1 secure messaging without authentication")
+	}
 
-	// log.Printf("IV: [% X]", iv)
+	switch commMode {
+	case FULL:
+		bs := d.block.BlockSize()
+		if len(data) <= 0 || len(data)%bs != 0 {
+			return nil, fmt.Errorf("len cryptogram = %d, %w", len(data), ErrLengthError)
+		}
+		frame := make([]byte, 0)
+		frame = append(frame, status)
+		frame = append(frame, data...)
+		plaindata := getDataOnFullModeResponseEV1(d.block, d.iv, frame)
+		d.iv = append([]byte{}, data[len(data)-bs:]...)
 
-	mode := cipher.NewCBCDecrypter(block, iv)
-	dest := make([]byte, len(reponse[1:]))
-	mode.CryptBlocks(dest, reponse[1:])
+		// the CRC is followed by the zero padding
+		for i := len(plaindata) - 4; i >= 0 && i > len(plaindata)-4-bs; i-- {
+			if i+4 < len(plaindata) && plaindata[i+4] != 0x00 {
+				break
+			}
+			crcData := make([]byte, 0)
+			crcData = append(crcData, plaindata[:i]...)
+			crcData = append(crcData, status)
+			if bytes.Equal(plaindata[i:i+4], crcEV1(crcData)) {
+				return plaindata[:i], nil
+			}
+		}
+		return nil, fmt.Errorf("CRC32 of response, %w", ErrIntegrityError)
+	}
 
-	return dest
+	if len(data) < 8 {
+		return nil, fmt.Errorf("len response = %d without CMAC, %w", len(data), ErrLengthError)
+	}
+	datamac := make([]byte, 0)
+	datamac = append(datamac, data[:len(data)-8]...)
+	datamac = append(datamac, status)
 
+	d.iv = cmacEV1(d.block, d.iv, datamac)
+	if !bytes.Equal(d.iv[:8], data[len(data)-8:]) {
+		return nil, fmt.Errorf("CMAC of response, %w", ErrIntegrityError)
+	}
+
+	return data[:len(data)-8], nil
 }
 
 func changeKeyCryptogramEV1(block cipher.Block,
@@ -135,3 +254,18 @@ func changeKeyCryptogramEV1(block cipher.Block,
 
 	return dest, nil
 }
+
+// responseFramesEV1 verify the response of several frames in PLAIN mode with
+// the EV1 secure messaging (GetVersion, GetDFNames) and return the frames
+// without the CMAC, that is at the end of the last frame.
+func (d *Desfire) responseFramesEV1(frames [][]byte) ([][]byte, error) {
+	if len(frames) <= 0 || len(frames[len(frames)-1]) < 8 {
+		return nil, fmt.Errorf("response without CMAC, %w", ErrLengthError)
+	}
+	if _, err := d.responseEV1(0x00, bytes.Join(frames, nil), PLAIN); err != nil {
+		return nil, err
+	}
+	last := frames[len(frames)-1]
+	frames[len(frames)-1] = last[:len(last)-8]
+	return frames, nil
+}
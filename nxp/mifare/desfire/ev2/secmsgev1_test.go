@@ -0,0 +1,362 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func Test_cmacEV1(t *testing.T) {
+	// RFC 4493 examples, with the zero IV
+	key := mustHex(t, "2B7E151628AED2A6ABF7158809CF4F3C")
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: "BB1D6929E95937287FA37D129B756746",
+		},
+		{
+			name: "one block",
+			data: "6BC1BEE22E409F96E93D7E117393172A",
+			want: "070A16B46B4D4144F79BDD9DD04A287C",
+		},
+		{
+			name: "40 bytes",
+			data: "6BC1BEE22E409F96E93D7E117393172AAE2D8A571E03AC9C9EB76FAC45AF8E5130C81C46A35CE411",
+			want: "DFA66747DE9AE63030CA32611497C827",
+		},
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cmacEV1(block, make([]byte, block.BlockSize()), mustHex(t, tt.data))
+			if !bytes.Equal(got, mustHex(t, tt.want)) {
+				t.Errorf("cmacEV1() = [% X], want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_crcEV1(t *testing.T) {
+	// check value of CRC-32/JAMCRC
+	got := crcEV1([]byte("123456789"))
+	if want := []byte{0xD9, 0xC6, 0x0B, 0x34}; !bytes.Equal(got, want) {
+		t.Errorf("crcEV1() = [% X], want [% X]", got, want)
+	}
+}
+
+func Test_sessionKeyEV1(t *testing.T) {
+	rndA := mustHex(t, "A0A1A2A3A4A5A6A7A8A9AAABACADAEAF")
+	rndB := mustHex(t, "B0B1B2B3B4B5B6B7B8B9BABBBCBDBEBF")
+	tests := []struct {
+		name    string
+		key     []byte
+		rndLen  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "AES",
+			key:    make([]byte, 16),
+			rndLen: 16,
+			want:   "A0A1A2A3B0B1B2B3ACADAEAFBCBDBEBF",
+		},
+		{
+			name:   "2TDEA",
+			key:    mustHex(t, "00112233445566778899AABBCCDDEEFF"),
+			rndLen: 8,
+			want:   "A0A1A2A3B0B1B2B3A4A5A6A7B4B5B6B7",
+		},
+		{
+			name:   "DES",
+			key:    make([]byte, 16),
+			rndLen: 8,
+			want:   "A0A1A2A3B0B1B2B3A0A1A2A3B0B1B2B3",
+		},
+		{
+			name:   "3TDEA",
+			key:    make([]byte, 24),
+			rndLen: 16,
+			want:   "A0A1A2A3B0B1B2B3A6A7A8A9B6B7B8B9ACADAEAFBCBDBEBF",
+		},
+		{
+			name:    "invalid",
+			key:     make([]byte, 8),
+			rndLen:  8,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sessionKeyEV1(tt.key, rndA[:tt.rndLen], rndB[:tt.rndLen])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sessionKeyEV1() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !bytes.Equal(got, mustHex(t, tt.want)) {
+				t.Errorf("sessionKeyEV1() = [% X], want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// ev1Card simulates the PICC side of AuthenticateISO/AuthenticateAES and
+// of the EV1 secure messaging, with a value file (1, FULL) and a standard
+// data file (2, FULL)
+type ev1Card struct {
+	*sim.Card
+	key    []byte
+	rndB   []byte
+	rndBc  []byte
+	block  cipher.Block
+	iv     []byte
+	value  uint32
+	data   []byte
+	frames []byte
+	badMAC bool
+}
+
+func newEV1Card(t *testing.T, authCmd byte, key []byte) *ev1Card {
+	c := &ev1Card{
+		Card: sim.NewCard(nil, nil, nil, 0x20),
+		key:  key,
+		data: make([]byte, 48),
+	}
+	var keyBlock cipher.Block
+	var err error
+	switch {
+	case authCmd == 0xAA:
+		keyBlock, err = aes.NewCipher(key)
+		c.rndB = mustHex(t, "91517B240C7A5E258E9515C1439A027E")
+	case len(key) == 16:
+		keyBlock, err = des.NewTripleDESCipher(append(append([]byte{}, key...), key[:8]...))
+		c.rndB = mustHex(t, "4C6431A8D2B0EF17")
+	default:
+		keyBlock, err = des.NewTripleDESCipher(key)
+		c.rndB = mustHex(t, "4C6431A8D2B0EF1791517B240C7A5E25")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := keyBlock.BlockSize()
+
+	c.Handle([]byte{authCmd}, func(apdu []byte) ([]byte, error) {
+		c.rndBc = make([]byte, len(c.rndB))
+		cipher.NewCBCEncrypter(keyBlock, make([]byte, bs)).CryptBlocks(c.rndBc, c.rndB)
+		return append([]byte{0xAF}, c.rndBc...), nil
+	})
+	c.Handle([]byte{0xAF}, func(apdu []byte) ([]byte, error) {
+		if c.rndBc == nil {
+			// next frame of the response
+			n := len(c.frames)
+			if n > 32 {
+				n = 32
+			}
+			resp := append([]byte{0x00}, c.frames[:n]...)
+			c.frames = c.frames[n:]
+			if len(c.frames) > 0 {
+				resp[0] = 0xAF
+			}
+			return resp, nil
+		}
+		rndD := make([]byte, len(apdu[1:]))
+		cipher.NewCBCDecrypter(keyBlock, c.rndBc[len(c.rndBc)-bs:]).CryptBlocks(rndD, apdu[1:])
+		n := len(c.rndB)
+		rndBr := append(append([]byte{}, c.rndB[1:]...), c.rndB[0])
+		if !bytes.Equal(rndD[n:], rndBr) {
+			return []byte{0xAE}, nil
+		}
+		rndA := rndD[:n]
+		rndAr := append(append([]byte{}, rndA[1:]...), rndA[0])
+		rndArc := make([]byte, n)
+		cipher.NewCBCEncrypter(keyBlock, apdu[len(apdu)-bs:]).CryptBlocks(rndArc, rndAr)
+
+		kses, err := sessionKeyEV1(key, rndA, c.rndB)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case authCmd == 0xAA:
+			c.block, err = aes.NewCipher(kses)
+		case len(kses) == 16:
+			c.block, err = des.NewTripleDESCipher(append(kses, kses[:8]...))
+		default:
+			c.block, err = des.NewTripleDESCipher(kses)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.iv = make([]byte, bs)
+		c.rndBc = nil
+		return append([]byte{0x00}, rndArc...), nil
+	})
+	// GetKeySettings, PLAIN
+	c.Handle([]byte{0x45}, func(apdu []byte) ([]byte, error) {
+		c.iv = cmacEV1(c.block, c.iv, apdu)
+		return c.plain([]byte{0x0F, 0x01}), nil
+	})
+	// GetValue, FULL
+	c.Handle([]byte{0x6C, 0x01}, func(apdu []byte) ([]byte, error) {
+		c.iv = cmacEV1(c.block, c.iv, apdu)
+		value := make([]byte, 4)
+		binary.LittleEndian.PutUint32(value, c.value)
+		return append([]byte{0x00}, c.full(value)...), nil
+	})
+	// Credit, MAC
+	c.Handle([]byte{0x0C, 0x01}, func(apdu []byte) ([]byte, error) {
+		mac := cmacEV1(c.block, c.iv, apdu[:len(apdu)-8])
+		if !bytes.Equal(mac[:8], apdu[len(apdu)-8:]) {
+			return []byte{0x1E}, nil
+		}
+		c.iv = mac
+		c.value += binary.LittleEndian.Uint32(apdu[2:6])
+		return c.plain(nil), nil
+	})
+	// ReadData, FULL
+	c.Handle([]byte{0xBD, 0x02}, func(apdu []byte) ([]byte, error) {
+		c.iv = cmacEV1(c.block, c.iv, apdu)
+		c.frames = c.full(c.data)
+		n := 32
+		resp := append([]byte{0xAF}, c.frames[:n]...)
+		c.frames = c.frames[n:]
+		return resp, nil
+	})
+	// WriteData, FULL
+	c.Handle([]byte{0x3D, 0x02}, func(apdu []byte) ([]byte, error) {
+		cryptogram := apdu[8:]
+		plain := make([]byte, len(cryptogram))
+		cipher.NewCBCDecrypter(c.block, c.iv).CryptBlocks(plain, cryptogram)
+		c.iv = append([]byte{}, cryptogram[len(cryptogram)-bs:]...)
+		length := int(apdu[5])
+		crcData := append(append([]byte{}, apdu[:8]...), plain[:length]...)
+		if !bytes.Equal(plain[length:length+4], crcEV1(crcData)) {
+			return []byte{0x1E}, nil
+		}
+		copy(c.data, plain[:length])
+		return c.plain(nil), nil
+	})
+	return c
+}
+
+// plain response with the CMAC of data || status
+func (c *ev1Card) plain(data []byte) []byte {
+	c.iv = cmacEV1(c.block, c.iv, append(append([]byte{}, data...), 0x00))
+	mac := append([]byte{}, c.iv[:8]...)
+	if c.badMAC {
+		mac[0] ^= 0xFF
+	}
+	return append(append([]byte{0x00}, data...), mac...)
+}
+
+// full cryptogram of data || CRC32(data || status)
+func (c *ev1Card) full(data []byte) []byte {
+	bs := c.block.BlockSize()
+	plain := append(append([]byte{}, data...), crcEV1(append(append([]byte{}, data...), 0x00))...)
+	plain = append(plain, make([]byte, (bs-len(plain)%bs)%bs)...)
+	cryptogram := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(c.block, c.iv).CryptBlocks(cryptogram, plain)
+	c.iv = append([]byte{}, cryptogram[len(cryptogram)-bs:]...)
+	return cryptogram
+}
+
+func TestDesfire_SecureMessagingEV1(t *testing.T) {
+	tests := []struct {
+		name    string
+		authCmd byte
+		key     []byte
+	}{
+		{
+			name:    "AES",
+			authCmd: 0xAA,
+			key:     mustHex(t, "00112233445566778899AABBCCDDEEFF"),
+		},
+		{
+			name:    "2TDEA",
+			authCmd: 0x1A,
+			key:     mustHex(t, "00112233445566778899AABBCCDDEEFF"),
+		},
+		{
+			name:    "3TDEA",
+			authCmd: 0x1A,
+			key:     mustHex(t, "00112233445566778899AABBCCDDEEFF0123456789ABCDEF"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := newEV1Card(t, tt.authCmd, tt.key)
+			d := NewDesfire(card)
+
+			var err error
+			if tt.authCmd == 0xAA {
+				var data []byte
+				data, err = d.AuthenticateAES(TargetPrimaryApp, 0)
+				if err == nil {
+					_, err = d.AuthenticateAESPart2(tt.key, data)
+				}
+			} else {
+				var data []byte
+				data, err = d.AuthenticateISO(TargetPrimaryApp, 0)
+				if err == nil {
+					_, err = d.AuthenticateISOPart2(tt.key, data)
+				}
+			}
+			if err != nil {
+				t.Fatalf("authenticate error = %v", err)
+			}
+			if d.evMode != EV1 {
+				t.Fatalf("evMode = %v, want EV1", d.evMode)
+			}
+
+			settings, err := d.GetKeySettings()
+			if err != nil || !bytes.Equal(settings, []byte{0x0F, 0x01}) {
+				t.Fatalf("GetKeySettings() = [% X], %v", settings, err)
+			}
+			if err := d.Credit(1, TargetPrimaryApp, 100, MAC); err != nil {
+				t.Fatalf("Credit() error = %v", err)
+			}
+			value, err := d.GetValue(1, TargetPrimaryApp, FULL)
+			if err != nil || binary.LittleEndian.Uint32(value) != 100 {
+				t.Fatalf("GetValue() = [% X], %v, want 100", value, err)
+			}
+			data := bytes.Repeat([]byte{0x5A}, 20)
+			if err := d.WriteData(2, TargetPrimaryApp, 0, data, FULL); err != nil {
+				t.Fatalf("WriteData() error = %v", err)
+			}
+			got, err := d.ReadData(2, TargetPrimaryApp, 0, 0, FULL)
+			if err != nil {
+				t.Fatalf("ReadData() error = %v", err)
+			}
+			want := append(append([]byte{}, data...), make([]byte, 28)...)
+			if !bytes.Equal(got, want) {
+				t.Errorf("ReadData() = [% X], want [% X]", got, want)
+			}
+
+			card.badMAC = true
+			if err := d.Credit(1, TargetPrimaryApp, 1, MAC); !errors.Is(err, ErrIntegrityError) {
+				t.Errorf("Credit() error = %v, want %v", err, ErrIntegrityError)
+			}
+		})
+	}
+}
@@ -61,6 +61,27 @@ func calcCommandIVOnFullModeEV2(ksesAuthEnc, ti []byte, cmdCtr uint16) ([]byte,
 	return resp, nil
 }
 
+// truncateMacEV2 MACt, the odd bytes of the CMAC
+func truncateMacEV2(mac []byte) []byte {
+	macT := make([]byte, 0)
+	for i, v := range mac {
+		if i%2 != 0 {
+			macT = append(macT, v)
+		}
+	}
+	return macT
+}
+
+// sessionKeyEV2 AES session key of key with the session vector sv, the
+// CMAC of sv
+func sessionKeyEV2(key, sv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cmac.Sum(sv, block, block.BlockSize())
+}
+
 func calcMacOnCommandEV2(block cipher.Block, ti []byte,
 	cmd byte, cmdCtr uint16, cmdHeader, data []byte) ([]byte, error) {
 	datamac := make([]byte, 0)
@@ -89,12 +110,7 @@ func calcMacOnCommandEV2(block cipher.Block, ti []byte,
 
 	// log.Printf("long cmac: [% X]", result)
 
-	cmacT := make([]byte, 0)
-	for i, v := range result {
-		if i%2 != 0 {
-			cmacT = append(cmacT, v)
-		}
-	}
+	cmacT := truncateMacEV2(result)
 
 	// log.Printf("truncate cmac: [% X]", cmacT)
 
@@ -128,12 +144,7 @@ func calcMacOnResponseEV2(block cipher.Block, ti []byte,
 
 	// log.Printf("long cmac: [% X]", result)
 
-	cmacT := make([]byte, 0)
-	for i, v := range result {
-		if i%2 != 0 {
-			cmacT = append(cmacT, v)
-		}
-	}
+	cmacT := truncateMacEV2(result)
 
 	// log.Printf("truncate cmac: [% X]", cmacT)
 
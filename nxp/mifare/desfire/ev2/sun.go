@@ -0,0 +1,207 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aead/cmac"
+)
+
+// SUNVerifier verifier of the Secure Unique NFC messages (SUN) mirrored by
+// the Secure Dynamic Messaging of DESFire EV3 and NTAG 424 DNA (AES keys).
+// Settings are the SDM settings of the file, MetaReadKey the SDMMetaRead key
+// (to decrypt PICCData) and FileReadKey the SDMFileRead key (SDMMAC and
+// ENCFileData). Offset is the position in the file of the first byte of the
+// message, i.e. of the URL read by the backend.
+type SUNVerifier struct {
+	Settings    SDMSettings
+	MetaReadKey []byte
+	FileReadKey []byte
+	Offset      int
+}
+
+// SUNMessage data of a verified SUN message
+type SUNMessage struct {
+	UID      []byte
+	ReadCtr  int
+	FileData []byte
+}
+
+// field of the message at the offset of the file, hex decoded with the
+// ASCII encoding
+func (v *SUNVerifier) field(message []byte, offset, length int) ([]byte, error) {
+	if v.Settings.Options&SDMASCIIEncoding != 0 {
+		length *= 2
+	}
+	start := offset - v.Offset
+	if start < 0 || start+length > len(message) {
+		return nil, fmt.Errorf("SDM offset %d out of message, %w", offset, ErrLengthError)
+	}
+	data := message[start : start+length]
+	if v.Settings.Options&SDMASCIIEncoding == 0 {
+		return data, nil
+	}
+	result := make([]byte, hex.DecodedLen(len(data)))
+	if _, err := hex.Decode(result, data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sessionVectorSDM session vector of the SDM session keys, prefix || UID ||
+// SDMReadCtr || zero padding
+func sessionVectorSDM(prefix, uid, readCtr []byte) []byte {
+	sv := make([]byte, 0)
+	sv = append(sv, prefix...)
+	sv = append(sv, uid...)
+	sv = append(sv, readCtr...)
+	if len(sv)%aes.BlockSize != 0 {
+		sv = append(sv, make([]byte, aes.BlockSize-len(sv)%aes.BlockSize)...)
+	}
+	return sv
+}
+
+// piccData UID and SDMReadCtr (3 bytes LSB first) of the message, plain or
+// in the encrypted PICCData
+func (v *SUNVerifier) piccData(message []byte) ([]byte, []byte, error) {
+	s := &v.Settings
+
+	var uid, readCtr []byte
+	switch {
+	case s.MetaRead == FREE:
+		if s.Options&SDMUIDMirror != 0 {
+			var err error
+			uid, err = v.field(message, s.UIDOffset, 7)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if s.Options&SDMReadCtr != 0 {
+			ctr, err := v.field(message, s.ReadCtrOffset, 3)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the mirror of SDMReadCtr is MSB first
+			readCtr = []byte{ctr[2], ctr[1], ctr[0]}
+		}
+	case s.MetaRead != NO_ACCESS:
+		encPICCData, err := v.field(message, s.PICCDataOffset, aes.BlockSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		block, err := aes.NewCipher(v.MetaReadKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		plain := make([]byte, len(encPICCData))
+		cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(plain, encPICCData)
+
+		// PICCDataTag || UID || SDMReadCtr || random padding
+		tag := plain[0]
+		data := plain[1:]
+		if tag&0x80 != 0 {
+			uidLen := int(tag & 0x0F)
+			if uidLen != 7 {
+				return nil, nil, fmt.Errorf("PICCDataTag 0x%02X, %w", tag, ErrIntegrityError)
+			}
+			uid = data[:uidLen]
+			data = data[uidLen:]
+		}
+		if tag&0x40 != 0 {
+			readCtr = data[:3]
+		}
+	}
+	return uid, readCtr, nil
+}
+
+// Verify decrypt the PICCData, verify the SDMMAC and decrypt the ENCFileData
+// of the message (the URL of the tap)
+func (v *SUNVerifier) Verify(message []byte) (*SUNMessage, error) {
+	s := &v.Settings
+
+	uid, readCtr, err := v.piccData(message)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SUNMessage{
+		UID: uid,
+	}
+	if readCtr != nil {
+		result.ReadCtr = int(binary.LittleEndian.Uint32(append(append([]byte{}, readCtr...), 0x00)))
+	}
+
+	if s.FileRead == NO_ACCESS {
+		return result, nil
+	}
+
+	if len(v.FileReadKey) != aes.BlockSize {
+		return nil, errors.New("wrong len (not 16) in \"FileReadKey\"")
+	}
+	ksesSDMFileReadENC, err := sessionKeyEV2(v.FileReadKey,
+		sessionVectorSDM([]byte{0xC3, 0x3C, 0x00, 0x01, 0x00, 0x80}, uid, readCtr))
+	if err != nil {
+		return nil, err
+	}
+	ksesSDMFileReadMAC, err := sessionKeyEV2(v.FileReadKey,
+		sessionVectorSDM([]byte{0x3C, 0xC3, 0x00, 0x01, 0x00, 0x80}, uid, readCtr))
+	if err != nil {
+		return nil, err
+	}
+
+	// SDMMAC of the message from SDMMACInputOffset to SDMMACOffset
+	sdmMAC, err := v.field(message, s.MACOffset, 8)
+	if err != nil {
+		return nil, err
+	}
+	start := s.MACInputOffset - v.Offset
+	end := s.MACOffset - v.Offset
+	if start < 0 || start > end {
+		return nil, fmt.Errorf("SDMMACInputOffset %d, %w", s.MACInputOffset, ErrLengthError)
+	}
+	blockMac, err := aes.NewCipher(ksesSDMFileReadMAC)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := cmac.Sum(message[start:end], blockMac, blockMac.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(truncateMacEV2(mac), sdmMAC) {
+		return nil, fmt.Errorf("SDMMAC, %w", ErrIntegrityError)
+	}
+
+	if s.Options&SDMENCFileData == 0 {
+		return result, nil
+	}
+
+	length := s.ENCLength
+	if s.Options&SDMASCIIEncoding != 0 {
+		length /= 2
+	}
+	if length <= 0 || length%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("len ENCFileData = %d, %w", length, ErrLengthError)
+	}
+	encFileData, err := v.field(message, s.ENCOffset, length)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(ksesSDMFileReadENC)
+	if err != nil {
+		return nil, err
+	}
+	// IV = E(KSesSDMFileReadENC, SDMReadCtr || zero padding)
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, readCtr)
+	block.Encrypt(iv, iv)
+
+	result.FileData = make([]byte, len(encFileData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(result.FileData, encFileData)
+
+	return result, nil
+}
@@ -0,0 +1,168 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aead/cmac"
+)
+
+func TestSUNVerifier_Verify(t *testing.T) {
+	// AN12196, SUN with encrypted PICCData and SDMMAC, the NDEF file is
+	// NLEN || D1 01 || len || 55 04 || "choose.url.com/ntag424?e=..."
+	settings := SDMSettings{
+		Options:        SDMUIDMirror | SDMReadCtr | SDMASCIIEncoding,
+		MetaRead:       KeyID_0x02,
+		FileRead:       KeyID_0x01,
+		CtrRet:         KeyID_0x01,
+		PICCDataOffset: 0x20,
+		MACInputOffset: 0x43,
+		MACOffset:      0x43,
+	}
+	message := "choose.url.com/ntag424?e=EF963FF7828658A599F3041510671E88&c=94EED9EE65337086"
+
+	tests := []struct {
+		name     string
+		verifier SUNVerifier
+		message  string
+		wantUID  []byte
+		wantCtr  int
+		wantErr  error
+	}{
+		{
+			name: "AN12196",
+			verifier: SUNVerifier{
+				Settings:    settings,
+				MetaReadKey: make([]byte, 16),
+				FileReadKey: make([]byte, 16),
+				Offset:      7,
+			},
+			message: message,
+			wantUID: mustHex(t, "04DE5F1EACC040"),
+			wantCtr: 0x3D,
+		},
+		{
+			name: "wrong SDMMAC",
+			verifier: SUNVerifier{
+				Settings:    settings,
+				MetaReadKey: make([]byte, 16),
+				FileReadKey: make([]byte, 16),
+				Offset:      7,
+			},
+			message: message[:len(message)-1] + "7",
+			wantErr: ErrIntegrityError,
+		},
+		{
+			name: "wrong SDMFileRead key",
+			verifier: SUNVerifier{
+				Settings:    settings,
+				MetaReadKey: make([]byte, 16),
+				FileReadKey: bytes.Repeat([]byte{0x01}, 16),
+				Offset:      7,
+			},
+			message: message,
+			wantErr: ErrIntegrityError,
+		},
+		{
+			name: "short message",
+			verifier: SUNVerifier{
+				Settings:    settings,
+				MetaReadKey: make([]byte, 16),
+				FileReadKey: make([]byte, 16),
+				Offset:      7,
+			},
+			message: message[:40],
+			wantErr: ErrLengthError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.verifier.Verify([]byte(tt.message))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(got.UID, tt.wantUID) || got.ReadCtr != tt.wantCtr {
+				t.Errorf("Verify() = [% X] %d, want [% X] %d", got.UID, got.ReadCtr, tt.wantUID, tt.wantCtr)
+			}
+		})
+	}
+}
+
+// sunMessage message of the tag with plain UID and SDMReadCtr mirrors and
+// ENCFileData, "uid=<UID>&ctr=<SDMReadCtr>&enc=<ENCFileData>&c=<SDMMAC>"
+func sunMessage(t *testing.T, key, uid []byte, readCtr int, fileData []byte) string {
+	ctr := []byte{byte(readCtr), byte(readCtr >> 8), byte(readCtr >> 16)}
+
+	kses := func(prefix []byte) cipher.Block {
+		k, err := sessionKeyEV2(key, sessionVectorSDM(prefix, uid, ctr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return block
+	}
+	blockEnc := kses([]byte{0xC3, 0x3C, 0x00, 0x01, 0x00, 0x80})
+	blockMac := kses([]byte{0x3C, 0xC3, 0x00, 0x01, 0x00, 0x80})
+
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, ctr)
+	blockEnc.Encrypt(iv, iv)
+	enc := make([]byte, len(fileData))
+	cipher.NewCBCEncrypter(blockEnc, iv).CryptBlocks(enc, fileData)
+
+	message := fmt.Sprintf("uid=%X&ctr=%06X&enc=%X&c=", uid, readCtr, enc)
+	mac, err := cmac.Sum([]byte(message), blockMac, blockMac.BlockSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message + strings.ToUpper(hex.EncodeToString(truncateMacEV2(mac)))
+}
+
+func TestSUNVerifier_VerifyENCFileData(t *testing.T) {
+	key := mustHex(t, "5ACE7E50AB65D5D51FD5BF5A16B8205B")
+	uid := mustHex(t, "04958CAA5C5E80")
+	fileData := []byte("0102030405060708")
+
+	verifier := SUNVerifier{
+		Settings: SDMSettings{
+			Options:        SDMUIDMirror | SDMReadCtr | SDMENCFileData | SDMASCIIEncoding,
+			MetaRead:       FREE,
+			FileRead:       KeyID_0x03,
+			CtrRet:         NO_ACCESS,
+			UIDOffset:      4,
+			ReadCtrOffset:  23,
+			MACInputOffset: 0,
+			ENCOffset:      34,
+			ENCLength:      32,
+			MACOffset:      69,
+		},
+		FileReadKey: key,
+	}
+
+	message := sunMessage(t, key, uid, 0x0102, fileData)
+	got, err := verifier.Verify([]byte(message))
+	if err != nil {
+		t.Fatalf("Verify() error = %v, message: %s", err, message)
+	}
+	if !bytes.Equal(got.UID, uid) || got.ReadCtr != 0x0102 || !bytes.Equal(got.FileData, fileData) {
+		t.Errorf("Verify() = [% X] %d %q", got.UID, got.ReadCtr, got.FileData)
+	}
+
+	// the counter is in the session keys
+	replayed := strings.Replace(message, "ctr=000102", "ctr=000103", 1)
+	if _, err := verifier.Verify([]byte(replayed)); !errors.Is(err, ErrIntegrityError) {
+		t.Errorf("Verify() of modified counter error = %v, want %v", err, ErrIntegrityError)
+	}
+}
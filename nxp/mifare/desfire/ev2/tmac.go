@@ -0,0 +1,166 @@
+package ev2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/aead/cmac"
+)
+
+// TMACVerifier computes off-card the Transaction MAC Value (TMV) of a
+// transaction with the AppTransactionMACKey, replaying the commands of the
+// transaction in the Transaction MAC Input (TMI). The TMV and the TMC are in
+// the response of CommitTransaction(true).
+type TMACVerifier struct {
+	key []byte
+	uid []byte
+	tmi []byte
+}
+
+// NewTMACVerifier create the verifier of the transactions of the card uid
+// (7 bytes) with the AppTransactionMACKey tmKey (AES)
+func NewTMACVerifier(tmKey, uid []byte) (*TMACVerifier, error) {
+	if len(tmKey) != 16 {
+		return nil, errors.New("wrong len (not 16) in \"tmKey\"")
+	}
+	if len(uid) != 7 {
+		return nil, errors.New("wrong len (not 7) in \"uid\"")
+	}
+	return &TMACVerifier{
+		key: tmKey,
+		uid: uid,
+		tmi: make([]byte, 0),
+	}, nil
+}
+
+// padTMI zero padding of TMI to a multiple of the block size
+func (v *TMACVerifier) padTMI() {
+	if len(v.tmi)%aes.BlockSize != 0 {
+		v.tmi = append(v.tmi, make([]byte, aes.BlockSize-len(v.tmi)%aes.BlockSize)...)
+	}
+}
+
+// Command replay a command of the transaction in TMI. data is the plain data
+// of the command (write operations) or of the response (read operations).
+// Only the commands on the files with TMAC (ReadData, WriteData,
+// ReadRecords, WriteRecord, UpdateRecord, ClearRecordFile, GetValue, Credit,
+// Debit, LimitedCredit) and CommitReaderID (the TMRI in cmdHeader) update
+// TMI.
+func (v *TMACVerifier) Command(cmd byte, cmdHeader, data []byte) error {
+	switch cmd {
+	case 0xAD, 0xBD, 0xAB, 0xBB:
+		// ReadData, ReadRecords: Cmd || CmdHeader || ZeroPadding || Data || ZeroPadding
+		v.tmi = append(v.tmi, cmd)
+		v.tmi = append(v.tmi, cmdHeader...)
+		v.padTMI()
+		v.tmi = append(v.tmi, data...)
+	case 0x6C:
+		// GetValue: Cmd || CmdHeader || Value || ZeroPadding
+		v.tmi = append(v.tmi, cmd)
+		v.tmi = append(v.tmi, cmdHeader...)
+		v.tmi = append(v.tmi, data...)
+	case 0x8D, 0x3D, 0x8B, 0x3B, 0xBA, 0xDB, 0x0C, 0xDC, 0x1C:
+		// WriteData, WriteRecord, UpdateRecord, Credit, Debit, LimitedCredit:
+		// Cmd || CmdHeader || Data || ZeroPadding
+		v.tmi = append(v.tmi, cmd)
+		v.tmi = append(v.tmi, cmdHeader...)
+		v.tmi = append(v.tmi, data...)
+	case 0xEB, 0xC8:
+		// ClearRecordFile: Cmd || FileNo || ZeroPadding,
+		// CommitReaderID: Cmd || TMRI || ZeroPadding
+		v.tmi = append(v.tmi, cmd)
+		v.tmi = append(v.tmi, cmdHeader...)
+	default:
+		return fmt.Errorf("command 0x%02X without TMAC", cmd)
+	}
+	v.padTMI()
+	return nil
+}
+
+// Reset start a new transaction
+func (v *TMACVerifier) Reset() {
+	v.tmi = make([]byte, 0)
+}
+
+// sessionKeys SesTMMACKey and SesTMENCKey of the transaction with tmc, the
+// TMC (4 bytes LSB first) in the response of CommitTransaction, already
+// incremented by the transaction: the actTMC+1 of the SV1 (0x5A) and SV2
+// (0xA5) of the datasheet.
+func (v *TMACVerifier) sessionKeys(tmc []byte) ([]byte, []byte, error) {
+	if len(tmc) != 4 {
+		return nil, nil, fmt.Errorf("len TMC = %d, %w", len(tmc), ErrLengthError)
+	}
+	sv1 := []byte{0x5A, 0x00, 0x01, 0x00, 0x80}
+	sv1 = append(sv1, tmc...)
+	sv1 = append(sv1, v.uid...)
+	sv2 := []byte{0xA5, 0x00, 0x01, 0x00, 0x80}
+	sv2 = append(sv2, tmc...)
+	sv2 = append(sv2, v.uid...)
+
+	sesTMMACKey, err := sessionKeyEV2(v.key, sv1)
+	if err != nil {
+		return nil, nil, err
+	}
+	sesTMENCKey, err := sessionKeyEV2(v.key, sv2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sesTMMACKey, sesTMENCKey, nil
+}
+
+// TMV Transaction MAC Value of the replayed commands with the TMC of the
+// transaction
+func (v *TMACVerifier) TMV(tmc []byte) ([]byte, error) {
+	sesTMMACKey, _, err := v.sessionKeys(tmc)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sesTMMACKey)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := cmac.Sum(v.tmi, block, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	return truncateMacEV2(mac), nil
+}
+
+// Verify verify the response of CommitTransaction(true), TMC (4 bytes) ||
+// TMV (8 bytes), with the replayed commands. It returns the TMC.
+func (v *TMACVerifier) Verify(commitResponse []byte) (uint32, error) {
+	if len(commitResponse) != 12 {
+		return 0, fmt.Errorf("len TMC || TMV = %d, %w", len(commitResponse), ErrLengthError)
+	}
+	tmc := commitResponse[:4]
+	tmv, err := v.TMV(tmc)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(tmv, commitResponse[4:]) {
+		return 0, fmt.Errorf("TMV, %w", ErrIntegrityError)
+	}
+	return binary.LittleEndian.Uint32(tmc), nil
+}
+
+// DecryptTMRI previous TMRI of the EncTMRI in the response of CommitReaderID,
+// with the TMC of the transaction
+func (v *TMACVerifier) DecryptTMRI(tmc, encTMRI []byte) ([]byte, error) {
+	if len(encTMRI) != aes.BlockSize {
+		return nil, fmt.Errorf("len EncTMRI = %d, %w", len(encTMRI), ErrLengthError)
+	}
+	_, sesTMENCKey, err := v.sessionKeys(tmc)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sesTMENCKey)
+	if err != nil {
+		return nil, err
+	}
+	tmri := make([]byte, aes.BlockSize)
+	block.Decrypt(tmri, encTMRI)
+	return tmri, nil
+}
@@ -0,0 +1,183 @@
+package ev2
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTMACVerifier_Command(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       byte
+		cmdHeader []byte
+		data      []byte
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "ReadData",
+			cmd:       0xAD,
+			cmdHeader: mustHex(t, "01000000040000"),
+			data:      mustHex(t, "11223344"),
+			want: "AD010000000400000000000000000000" +
+				"11223344000000000000000000000000",
+		},
+		{
+			name:      "GetValue",
+			cmd:       0x6C,
+			cmdHeader: mustHex(t, "01"),
+			data:      mustHex(t, "64000000"),
+			want:      "6C016400000000000000000000000000",
+		},
+		{
+			name:      "Debit",
+			cmd:       0xDC,
+			cmdHeader: mustHex(t, "01"),
+			data:      mustHex(t, "0A000000"),
+			want:      "DC010A00000000000000000000000000",
+		},
+		{
+			name:      "WriteRecord",
+			cmd:       0x3B,
+			cmdHeader: mustHex(t, "02000000140000"),
+			data:      bytes.Repeat([]byte{0xA5}, 20),
+			want: "3B02000000140000A5A5A5A5A5A5A5A5" +
+				"A5A5A5A5A5A5A5A5A5A5A5A500000000",
+		},
+		{
+			name:      "CommitReaderID",
+			cmd:       0xC8,
+			cmdHeader: mustHex(t, "000102030405060708090A0B0C0D0E0F"),
+			want: "C8000102030405060708090A0B0C0D0E" +
+				"0F000000000000000000000000000000",
+		},
+		{
+			name:    "GetFileSettings",
+			cmd:     0xF5,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewTMACVerifier(make([]byte, 16), make([]byte, 7))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Command(tt.cmd, tt.cmdHeader, tt.data); (err != nil) != tt.wantErr {
+				t.Fatalf("Command() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := mustHex(t, tt.want); !bytes.Equal(v.tmi, want) {
+				t.Errorf("TMI = [% X], want [% X]", v.tmi, want)
+			}
+		})
+	}
+}
+
+// TestTMACVerifier_sessionKeys session keys computed with the CMAC of
+// OpenSSL: SV1 = 5A 00 01 00 80 || TMC || UID, SV2 = A5 00 01 00 80 || TMC ||
+// UID, with the TMC (already incremented) of the response of
+// CommitTransaction
+func TestTMACVerifier_sessionKeys(t *testing.T) {
+	v, err := NewTMACVerifier(mustHex(t, "F7D23E0C44AFADE542BFDF2DC5C6AE02"), mustHex(t, "04A1B2C3D4E580"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sesTMMACKey, sesTMENCKey, err := v.sessionKeys(mustHex(t, "07000000"))
+	if err != nil {
+		t.Fatalf("sessionKeys() error = %v", err)
+	}
+	if want := mustHex(t, "31EF6BB787C2EE9DD9302E8A25C6AD38"); !bytes.Equal(sesTMMACKey, want) {
+		t.Errorf("SesTMMACKey = [% X], want [% X]", sesTMMACKey, want)
+	}
+	if want := mustHex(t, "40F41C6F80DA6CF7E4C4230025CB5DE1"); !bytes.Equal(sesTMENCKey, want) {
+		t.Errorf("SesTMENCKey = [% X], want [% X]", sesTMENCKey, want)
+	}
+}
+
+func TestTMACVerifier_Verify(t *testing.T) {
+	tmKey := mustHex(t, "F7D23E0C44AFADE542BFDF2DC5C6AE02")
+	uid := mustHex(t, "04A1B2C3D4E580")
+	tmri := mustHex(t, "000102030405060708090A0B0C0D0E0F")
+
+	// TMC || TMV of the transaction CommitReaderID, GetValue and Debit of
+	// 10, TMV computed with the CMAC of OpenSSL
+	commitResponse := mustHex(t, "07000000"+"34EE00871B240773")
+
+	tests := []struct {
+		name           string
+		debit          []byte
+		commitResponse []byte
+		wantErr        error
+	}{
+		{
+			name:           "debit of 10",
+			debit:          mustHex(t, "0A000000"),
+			commitResponse: commitResponse,
+		},
+		{
+			name:           "debit of 1",
+			debit:          mustHex(t, "01000000"),
+			commitResponse: commitResponse,
+			wantErr:        ErrIntegrityError,
+		},
+		{
+			name:           "other TMC",
+			debit:          mustHex(t, "0A000000"),
+			commitResponse: append(mustHex(t, "08000000"), commitResponse[4:]...),
+			wantErr:        ErrIntegrityError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewTMACVerifier(tmKey, uid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range []struct {
+				cmd             byte
+				cmdHeader, data []byte
+			}{
+				{0xC8, tmri, nil},
+				{0x6C, []byte{0x01}, mustHex(t, "64000000")},
+				{0xDC, []byte{0x01}, tt.debit},
+			} {
+				if err := v.Command(c.cmd, c.cmdHeader, c.data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := v.Verify(tt.commitResponse)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != 7 {
+				t.Errorf("Verify() TMC = %d, want 7", got)
+			}
+		})
+	}
+}
+
+func TestTMACVerifier_DecryptTMRI(t *testing.T) {
+	tmKey := mustHex(t, "F7D23E0C44AFADE542BFDF2DC5C6AE02")
+	uid := mustHex(t, "04A1B2C3D4E580")
+	tmc := mustHex(t, "07000000")
+	prevTMRI := mustHex(t, "0F0E0D0C0B0A09080706050403020100")
+
+	// EncTMRI computed with the AES of OpenSSL
+	encTMRI := mustHex(t, "2A507E64CD7267F8C45CAC4BC4A662C4")
+
+	v, err := NewTMACVerifier(tmKey, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := v.DecryptTMRI(tmc, encTMRI)
+	if err != nil {
+		t.Fatalf("DecryptTMRI() error = %v", err)
+	}
+	if !bytes.Equal(got, prevTMRI) {
+		t.Errorf("DecryptTMRI() = [% X], want [% X]", got, prevTMRI)
+	}
+}
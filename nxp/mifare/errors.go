@@ -0,0 +1,37 @@
+package mifare
+
+import (
+	"errors"
+
+	"github.com/dumacp/smartcard/nxp"
+)
+
+// MIFARE Plus error codes
+var (
+	ErrPlusAuthentication   = errors.New("access conditions not fulfilled, authentication error")
+	ErrPlusCommandOverflow  = errors.New("too many read or write commands in the session or transaction")
+	ErrPlusInvalidMAC       = errors.New("invalid MAC in command or response")
+	ErrPlusBlockNotValid    = errors.New("block number is not valid")
+	ErrPlusBlockNotExist    = errors.New("invalid block number, not existing block number")
+	ErrPlusCommandNotAllow  = errors.New("command code not available at the current card state")
+	ErrPlusLength           = errors.New("length error")
+	ErrPlusGeneralOperation = errors.New("general manipulation error, failure in the operation of the PICC")
+)
+
+// PlusStatus sentinels of the MIFARE Plus error codes, as ISO 7816 status
+// word (0x91XX)
+var PlusStatus = map[uint16]error{
+	0x9106: ErrPlusAuthentication,
+	0x9107: ErrPlusCommandOverflow,
+	0x9108: ErrPlusInvalidMAC,
+	0x9109: ErrPlusBlockNotValid,
+	0x910A: ErrPlusBlockNotExist,
+	0x910B: ErrPlusCommandNotAllow,
+	0x910C: ErrPlusLength,
+	0x910F: ErrPlusGeneralOperation,
+}
+
+// PlusStatusError error of the MIFARE Plus error code
+func PlusStatusError(code byte) error {
+	return nxp.StatusError(0x9100|uint16(code), PlusStatus)
+}
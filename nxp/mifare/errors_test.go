@@ -0,0 +1,101 @@
+package mifare
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard/nxp"
+)
+
+func TestVerifyResponseIso7816(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+		want     error
+		wantErr  bool
+	}{
+		{
+			name:     "ok",
+			response: []byte{0x01, 0x90, 0x00},
+		},
+		{
+			name:     "additional frame",
+			response: []byte{0x90, 0xAF},
+		},
+		{
+			name:     "wrong length",
+			response: []byte{0x67, 0x00},
+			want:     nxp.ErrWrongLengthAPDU,
+			wantErr:  true,
+		},
+		{
+			name:     "key reference invalid",
+			response: []byte{0x6A, 0x82},
+			want:     nxp.ErrReferecNumberKeyInvakid,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown status",
+			response: []byte{0x62, 0x83},
+			want:     nxp.ErrUnknownStatus,
+			wantErr:  true,
+		},
+		{
+			name:     "short response",
+			response: []byte{0x90},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyResponseIso7816(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyResponseIso7816() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("VerifyResponseIso7816() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    error
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			data: []byte{0x90, 0x01},
+		},
+		{
+			name:    "authentication error",
+			data:    []byte{0x06},
+			want:    ErrPlusAuthentication,
+			wantErr: true,
+		},
+		{
+			name:    "invalid MAC",
+			data:    []byte{0x08},
+			want:    ErrPlusInvalidMAC,
+			wantErr: true,
+		},
+		{
+			name:    "nil response",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyResponse(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("verifyResponse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package mifare
 
 import (
 	"fmt"
+
+	"github.com/dumacp/smartcard/nxp"
 )
 
 type INS byte
@@ -20,7 +22,8 @@ func VerifyResponseIso7816(response []byte) error {
 		return fmt.Errorf("error in response: [% X]", response)
 	}
 	if (response[len(response)-1] != byte(0x00) && response[len(response)-1] != byte(0xAF)) || response[len(response)-2] != byte(0x90) {
-		return fmt.Errorf("error in response [%X %X]; response: [% X]", response[len(response)-2], response[len(response)-1], response)
+		sw := uint16(response[len(response)-2])<<8 | uint16(response[len(response)-1])
+		return nxp.StatusError(sw, nxp.SamStatus)
 	}
 	return nil
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/aead/cmac"
 	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/nxp"
 )
 
 //MifarePlus MifarePlus Interface
@@ -35,6 +36,8 @@ type MifarePlus interface {
 	Ti(ti []byte)
 	ReadCounter(counter int)
 	WriteCounter(counter int)
+	ReadSignature() ([]byte, error)
+	ProximityCheck(key []byte, rounds int) (*nxp.ProximityCheck, error)
 }
 
 type mifarePlus struct {
@@ -79,7 +82,7 @@ func verifyResponse(data []byte) error {
 		return fmt.Errorf("null response")
 	}
 	if data[0] != 0x90 {
-		return fmt.Errorf("error in response SC: %X, response [% X]", data[0], data)
+		return PlusStatusError(data[0])
 	}
 	return nil
 }
@@ -110,6 +113,8 @@ func (mplus *mifarePlus) WritePerso(bNr int, key []byte) ([]byte, error) {
 	keyB2 := byte(bNr & 0xFF)
 	aid := []byte{0xA8, keyB2, keyB1}
 	aid = append(aid, key...)
+	// key value
+	smartcard.RedactNext(mplus.ICard, []smartcard.Span{{Offset: 3, Length: -1}}, nil)
 	response, err := mplus.Apdu(aid)
 	if err != nil {
 		return nil, err
@@ -138,6 +143,8 @@ func (mplus *mifarePlus) FirstAuthf1(keyBNr int) ([]byte, error) {
 	keyB1 := byte((keyBNr >> 8) & 0xFF)
 	keyB2 := byte(keyBNr & 0xFF)
 	aid := []byte{0x70, keyB2, keyB1, 0x00}
+	// E(Kx, RndB)
+	smartcard.RedactNext(mplus.ICard, nil, []smartcard.Span{{Offset: 1, Length: -1}})
 	response, err := mplus.Apdu(aid)
 	if err != nil {
 		return nil, err
@@ -153,6 +160,8 @@ func (mplus *mifarePlus) FirstAuthf2(data []byte) ([]byte, error) {
 	aid := make([]byte, 0)
 	aid = append(aid, byte(0x72))
 	aid = append(aid, data...)
+	// E(Kx, RndA || RndB'), E(Kx, TI || RndA' || PICCcap || PCDcap)
+	smartcard.RedactNext(mplus.ICard, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
 	response, err := mplus.Apdu(aid)
 	if err != nil {
 		return nil, err
@@ -168,6 +177,8 @@ func (mplus *mifarePlus) FallowAuthf1(keyBNr int) ([]byte, error) {
 	keyB1 := byte((keyBNr >> 8) & 0xFF)
 	keyB2 := byte(keyBNr & 0xFF)
 	aid := []byte{0x76, keyB2, keyB1, 0x00}
+	// E(Kx, RndB)
+	smartcard.RedactNext(mplus.ICard, nil, []smartcard.Span{{Offset: 1, Length: -1}})
 	response, err := mplus.Apdu(aid)
 	if err != nil {
 		return nil, err
@@ -182,6 +193,8 @@ func (mplus *mifarePlus) FallowAuthf1(keyBNr int) ([]byte, error) {
 func (mplus *mifarePlus) FallowtAuthf2(data []byte) ([]byte, error) {
 	aid := []byte{0x72}
 	aid = append(aid, data...)
+	// E(Kx, RndA || RndB'), E(Kx, RndA')
+	smartcard.RedactNext(mplus.ICard, []smartcard.Span{{Offset: 1, Length: -1}}, []smartcard.Span{{Offset: 1, Length: -1}})
 	response, err := mplus.Apdu(aid)
 	if err != nil {
 		return nil, err
@@ -211,7 +224,6 @@ func (mplus *mifarePlus) FirstAuth(keyBNr int, key []byte) ([]byte, error) {
 
 	rndB := make([]byte, 16)
 	modeD.CryptBlocks(rndB, rndBc)
-	fmt.Printf("rndB: [% X]\n", rndB)
 
 	//rotate rndB
 	rndBr := make([]byte, 16)
@@ -221,7 +233,6 @@ func (mplus *mifarePlus) FirstAuth(keyBNr int, key []byte) ([]byte, error) {
 
 	rndA := make([]byte, 16)
 	rand.Read(rndA)
-	fmt.Printf("rndA: [% X]\n", rndA)
 
 	rndD := make([]byte, 0)
 	rndD = append(rndD, rndA...)
@@ -572,6 +583,41 @@ func (mplus *mifarePlus) TransfMacMac(bNr int) error {
 	return nil
 }
 
+//ReadSignature NXP originality signature of the UID (56 bytes, ECDSA
+//secp224r1), without authentication. Verify it with package originality.
+func (mplus *mifarePlus) ReadSignature() ([]byte, error) {
+	aid := []byte{0x3C, 0x00}
+	response, err := mplus.Apdu(aid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyResponse(response); err != nil {
+		return nil, err
+	}
+	if len(response) < 57 {
+		return nil, fmt.Errorf("signature length error, response: [% X]", response)
+	}
+	return response[1:57], nil
+}
+
+// ProximityCheck run the proximity check (PreparePC, ProximityCheck and
+// VerifyPC) in rounds (1, 2, 4 or 8) of ProximityCheck. The MAC of VerifyPC
+// is calculated with key (the ProximityCheckKey) or with the MAC session key
+// of FirstAuth if key is nil. The result has the round trip times of the
+// ProximityCheck commands to enforce the threshold.
+func (mplus *mifarePlus) ProximityCheck(key []byte, rounds int) (*nxp.ProximityCheck, error) {
+	if key == nil {
+		key = mplus.keyMac
+	}
+	if key == nil {
+		return nil, fmt.Errorf("proximity check without key only after FirstAuth")
+	}
+	mac := func(data []byte) ([]byte, error) {
+		return macCalc(key, data)
+	}
+	return nxp.RunProximityCheck(mplus.ICard, rounds, verifyResponse, mac)
+}
+
 //encCalc calcule encrypted data to request
 func encCalc(readCounter, writeCounter int, key, ti, data []byte) ([]byte, error) {
 
@@ -4,8 +4,11 @@ Implementation to mifare smartcard family (Mifare Plus, Desfire, SamAV2, ...)
 package mifare
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
+
+	"github.com/dumacp/smartcard/sim"
 )
 
 func Test_calcSessionKeyEV0(t *testing.T) {
@@ -86,3 +89,116 @@ func Test_calcSessionKeyEV0(t *testing.T) {
 		})
 	}
 }
+
+func Test_mifarePlus_ReadSignature(t *testing.T) {
+	signature := bytes.Repeat([]byte{0x5A, 0xA5}, 28)
+	tests := []struct {
+		name     string
+		response []byte
+		want     []byte
+		wantErr  bool
+	}{
+		{
+			name:     "signature",
+			response: append([]byte{0x90}, signature...),
+			want:     signature,
+		},
+		{
+			name:     "command not allowed",
+			response: []byte{0x0B},
+			wantErr:  true,
+		},
+		{
+			name:     "short response",
+			response: []byte{0x90, 0x01, 0x02},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := sim.NewCard(nil, nil, nil, 0x20)
+			card.Handle([]byte{0x3C, 0x00}, func(apdu []byte) ([]byte, error) {
+				return tt.response, nil
+			})
+			got, err := Mplus(card).ReadSignature()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadSignature() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mifarePlus_ProximityCheck(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F}
+	rndR := []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	prepare := []byte{0x90, 0x01, 0x01, 0x2C, 0x00}
+
+	tests := []struct {
+		name    string
+		key     []byte
+		keyMac  []byte
+		wantErr bool
+	}{
+		{
+			name: "ProximityCheckKey",
+			key:  key,
+		},
+		{
+			name:   "session key",
+			keyMac: key,
+		},
+		{
+			name:    "without key",
+			wantErr: true,
+		},
+		{
+			name:    "other key",
+			key:     make([]byte, 16),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rndC := make([]byte, 0)
+			card := sim.NewCard(nil, nil, nil, 0x20)
+			card.Handle([]byte{0xF0}, func(apdu []byte) ([]byte, error) {
+				return prepare, nil
+			})
+			card.Handle([]byte{0xF2}, func(apdu []byte) ([]byte, error) {
+				n := int(apdu[1])
+				i := len(rndC)
+				rndC = append(rndC, apdu[2:2+n]...)
+				return append([]byte{0x90}, rndR[i:i+n]...), nil
+			})
+			card.Handle([]byte{0xFD}, func(apdu []byte) ([]byte, error) {
+				data := append([]byte{}, prepare[1:]...)
+				for i := range rndC {
+					data = append(data, rndR[i], rndC[i])
+				}
+				mac, _ := macCalc(key, append([]byte{0xFD}, data...))
+				if !bytes.Equal(apdu[1:], mac) {
+					return []byte{0x06}, nil
+				}
+				mac, _ = macCalc(key, append([]byte{0x90}, data...))
+				return append([]byte{0x90}, mac...), nil
+			})
+
+			mplus := Mplus(card)
+			mplus.KeyMac(tt.keyMac)
+			got, err := mplus.ProximityCheck(tt.key, 8)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProximityCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Times) != 8 || !bytes.Equal(got.RndR, rndR) || !bytes.Equal(got.PPS1, []byte{0x00}) {
+				t.Errorf("ProximityCheck() = %+v", got)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/des"
 	"encoding/binary"
 	"fmt"
-	"log"
 	"math/rand"
 	"time"
 
@@ -57,8 +56,6 @@ func apduChangeKeyEntryAv1(hostMode, keyNbr, proMax, cmdCtr int,
 	//padding
 	payload = append(payload, make([]byte, 2)...)
 
-	log.Printf("payload chankeKeyAv1: [ %X ], len: %v", payload, len(payload))
-
 	rand.Seed(time.Now().UnixNano())
 	iv := make([]byte, 8)
 	block, err := des.NewCipher(kex)
@@ -92,6 +89,8 @@ func (sam *samAv2) ChangeKeyEntryAv1(keyNbr, proMax int,
 		return nil, err
 	}
 
+	// key entry (plain, MACed or enciphered)
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -1}}, nil)
 	response, err := sam.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -158,9 +157,6 @@ func ApduChangeKeyEntryOffline(keyNbr, proMax, changeCtr int,
 		return nil, err
 	}
 
-	log.Printf("payload   : [ %X ]", payload)
-	log.Printf("encPayload: [ %X ]", encPayload)
-
 	apdu = append(apdu, byte(len(encPayload)+len(changeCtrSlice))+8)
 	apdu = append(apdu, changeCtrSlice...)
 	apdu = append(apdu, encPayload...)
@@ -209,7 +205,6 @@ func apduChangeKeyEntry(hostMode, keyNbr, proMax, cmdCtr int,
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("MACt: [ %X ]", macT)
 		apdu = append(apdu, byte(len(payload)+len(macT)))
 		apdu = append(apdu, payload...)
 		apdu = append(apdu, macT...)
@@ -219,12 +214,10 @@ func apduChangeKeyEntry(hostMode, keyNbr, proMax, cmdCtr int,
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("encD: [ %X ]", encD)
 		macT, err := tools.MacFullProtection(cmd, cmdCtr, encD, km)
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("MACt: [ %X ]", macT)
 		apdu = append(apdu, byte(len(encD)+len(macT)))
 		apdu = append(apdu, encD...)
 		apdu = append(apdu, macT...)
@@ -287,6 +280,8 @@ func (sam *samAv2) ChangeKeyEntry(keyNbr, proMax int,
 	sam.CmdCtr++
 	// log.Printf("apud: [ %X ]", apdu)
 
+	// key entry (plain, MACed or enciphered)
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -1}}, nil)
 	response, err := sam.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -312,8 +307,8 @@ func (sam *samAv2) ChangeKeyEntryOffline(keyNbr, proMax, changeCtr int,
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("apud: [ %X ]", apdu)
-
+	// key entry (plain, MACed or enciphered)
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -1}}, nil)
 	response, err := sam.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -372,7 +367,6 @@ func (sam *samAv2) ActivateOfflineKey(keyNo, keyVer int,
 		return nil, err
 	}
 	if err := mifare.VerifyResponseIso7816(response); err != nil {
-		log.Printf("apdu: [ % X ] ", apdu)
 		return response, err
 	}
 
@@ -4,7 +4,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"fmt"
-	"log"
 	"math/rand"
 	"time"
 
@@ -162,6 +161,8 @@ func (sam *samAv2) AuthHostAV1(block cipher.Block, keyNo, keyVer, authMode int)
 
 	aid1 := []byte{0x80, 0xa4, byte(authMode), 0x00, 0x02, byte(keyNo), byte(keyVer), 0x00}
 
+	// E(Kx, RndB)
+	smartcard.RedactNext(sam.ICard, nil, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err := sam.Apdu(aid1)
 	if err != nil {
 		// log.Printf("fail response: [ %X ], apdu: [ %X ]", response, aid1)
@@ -192,15 +193,14 @@ func (sam *samAv2) AuthHostAV1(block cipher.Block, keyNo, keyVer, authMode int)
 	aid2 = append(aid2, rndDc...)
 	aid2 = append(aid2, byte(0x00))
 	//fmt.Printf("aid2: [% X]\n", aid2)
+	// E(Kx, RndA || RndB'), E(Kx, RndA')
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err = sam.Apdu(aid2)
 	if err != nil {
-		log.Printf("fail response: [ %X ], apdu: [ %X ]", response, aid2)
 		return nil, err
 	}
-	log.Printf("aid2 response: [ %X ], apdu: [ %X ]", response, aid2)
 
 	if err := mifare.VerifyResponseIso7816(response); err != nil {
-		log.Printf("fail response: [ %X ], apdu: [ %X ]", response, aid2)
 		return nil, err
 	}
 
@@ -272,6 +272,8 @@ func (sam *samAv2) LockUnlock(key, maxchainBlocks []byte, keyNr, keyVr, unlockKe
 
 	aid1 := ApduLockUnlock(keyNr, keyVr, unlockKeyNo, unlockKeyVer, p1, maxchainBlocks)
 
+	// Rnd2
+	smartcard.RedactNext(sam.ICard, nil, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err := sam.Apdu(aid1)
 	if err != nil {
 		// log.Printf("fail response: [ %X ]", response)
@@ -300,13 +302,6 @@ func (sam *samAv2) LockUnlock(key, maxchainBlocks []byte, keyNr, keyVr, unlockKe
 	rnd1 := make([]byte, 12)
 	rand.Read(rnd1)
 
-	/**
-	fmt.Printf("cmacS: [% X]\n", cmacS)
-	fmt.Printf("cmac2: [% X]\n", cmac2)
-	fmt.Printf("rnd1: [% X]\n", rnd1)
-	fmt.Printf("rnd2: [% X]\n", rnd2)
-	/**/
-
 	// aid2 := []byte{0x80, 0x10, 0x00, 0x00, 0x14}
 	// aid2 = append(aid2, cmac2...)
 	// aid2 = append(aid2, rnd1...)
@@ -314,6 +309,8 @@ func (sam *samAv2) LockUnlock(key, maxchainBlocks []byte, keyNr, keyVr, unlockKe
 
 	aid2 := ApduLockUnlockPart2(cmac2, rnd1)
 	//fmt.Printf("aid2: [% X]\n", aid2)
+	// MAC || Rnd1, MAC || E(Kxe, RndB)
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err = sam.Apdu(aid2)
 	if err != nil {
 		return nil, err
@@ -350,10 +347,6 @@ func (sam *samAv2) LockUnlock(key, maxchainBlocks []byte, keyNr, keyVr, unlockKe
 
 	rndA := make([]byte, len(rndB))
 	rand.Read(rndA)
-	/**
-	fmt.Printf("rndA: [% X]\n", rndA)
-	fmt.Printf("rndB: [% X]\n", rndB)
-	/**/
 
 	//rotate(2)
 	//rotate := 2
@@ -382,6 +375,8 @@ func (sam *samAv2) LockUnlock(key, maxchainBlocks []byte, keyNr, keyVr, unlockKe
 	aid3 = append(aid3, ecipher...)
 	aid3 = append(aid3, byte(0x00))
 
+	// E(Kxe, RndA || RndB''), E(Kxe, RndA'')
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err = sam.Apdu(aid3)
 	if err != nil {
 		return nil, err
@@ -416,6 +411,8 @@ func (sam *samAv2) AuthHostAV2(key []byte, keyNo, keyVer, hostMode int) ([]byte,
 
 	aid1 := []byte{0x80, 0xa4, 0x00, 0x00, 0x03, byte(keyNo), byte(keyVer), byte(hostMode), 0x00}
 
+	// Rnd2
+	smartcard.RedactNext(sam.ICard, nil, []smartcard.Span{{Offset: 0, Length: -3}})
 	response1, err := sam.Apdu(aid1)
 	if err != nil {
 		return nil, err
@@ -446,19 +443,13 @@ func (sam *samAv2) AuthHostAV2(key []byte, keyNo, keyVer, hostMode int) ([]byte,
 	rand.Read(rnd1)
 	// rnd1, _ = hex.DecodeString("A408BEB67688B37328DDBF82")
 
-	/**
-	fmt.Printf("var1: [% X]\n", var1)
-	fmt.Printf("cmacS: [% X]\n", cmacS)
-	fmt.Printf("cmac2: [% X]\n", cmac2)
-	fmt.Printf("rnd1: [% X]\n", rnd1)
-	fmt.Printf("rnd2: [% X]\n", rnd2)
-	/**/
-
 	aid2 := []byte{0x80, 0xa4, 0x00, 0x00, 0x14}
 	aid2 = append(aid2, cmac2...)
 	aid2 = append(aid2, rnd1...)
 	aid2 = append(aid2, byte(0x00))
 	// fmt.Printf("aid2: [% X]\n", aid2)
+	// MAC || Rnd1, MAC || E(Kxe, RndB)
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	response2, err := sam.Apdu(aid2)
 	if err != nil {
 		return nil, err
@@ -499,11 +490,6 @@ func (sam *samAv2) AuthHostAV2(key []byte, keyNo, keyVer, hostMode int) ([]byte,
 	rndA := make([]byte, len(rndB))
 	rand.Read(rndA)
 	// rndA, _ = hex.DecodeString("861799E95701CC49A1A3C18FCDC95D64")
-	/**
-	fmt.Printf("rndBc: [% X]\n", rndBc)
-	fmt.Printf("rndA: [% X]\n", rndA)
-	fmt.Printf("rndB: [% X]\n", rndB)
-	/**/
 
 	//rotate(2)
 	//rotate := 2
@@ -533,6 +519,8 @@ func (sam *samAv2) AuthHostAV2(key []byte, keyNo, keyVer, hostMode int) ([]byte,
 	aid3 = append(aid3, byte(0x00))
 
 	// fmt.Printf("aid3: [% X]\n", aid3)
+	// E(Kxe, RndA || RndB''), E(Kxe, RndA'')
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	response3, err := sam.Apdu(aid3)
 	if err != nil {
 		return nil, err
@@ -658,6 +646,8 @@ func ApduNonXauthMFPf1(first bool, sl, keyNo, keyVer int, data, dataDiv []byte)
 
 // NonXauthMFPf1 SAM_AuthenticationMFP (non-X-mode) first part
 func (sam *samAv2) NonXauthMFPf1(first bool, sl, keyNo, keyVer int, data, dataDiv []byte) ([]byte, error) {
+	// E(Kx, RndB), E(Kx, RndA || RndB')
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 7, Length: 16}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	return sam.Apdu(ApduNonXauthMFPf1(first, sl, keyNo, keyVer, data, dataDiv))
 }
 
@@ -674,6 +664,8 @@ func ApduNonXauthMFPf2(data []byte) []byte {
 
 // NonXauthMFPf2 SAM_AuthenticationMFP (non-X-mode) second part
 func (sam *samAv2) NonXauthMFPf2(data []byte) ([]byte, error) {
+	// E(Kx, TI || RndA' || PICCcap || PCDcap), E(Kx, RndA')
+	smartcard.RedactNext(sam.ICard, []smartcard.Span{{Offset: 5, Length: -2}}, []smartcard.Span{{Offset: 0, Length: -3}})
 	return sam.Apdu(ApduNonXauthMFPf2(data))
 }
 
@@ -709,6 +701,8 @@ func ApduDumpSecretKey(keyNo, keyVer int, divInput []byte) []byte {
 
 // DumpSessionKey SAM_DumpSessionKey (session key of an established authentication with a DESFire or MIFARE Plus PICC)
 func (sam *samAv2) DumpSessionKey() ([]byte, error) {
+	// key
+	smartcard.RedactNext(sam.ICard, nil, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err := sam.Apdu(ApduDumpSessionKey())
 	if err != nil {
 		return nil, err
@@ -721,6 +715,8 @@ func (sam *samAv2) DumpSessionKey() ([]byte, error) {
 
 // DumpSecretKey SAM_DumpSecretKey (allows dumping any of PICC keys or OfflineCrypto keys)
 func (sam *samAv2) DumpSecretKey(keyNo, keyVer int, divInput []byte) ([]byte, error) {
+	// key
+	smartcard.RedactNext(sam.ICard, nil, []smartcard.Span{{Offset: 0, Length: -3}})
 	response, err := sam.Apdu(ApduDumpSecretKey(keyNo, keyVer, divInput))
 	if err != nil {
 		return nil, err
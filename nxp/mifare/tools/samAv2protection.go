@@ -5,7 +5,6 @@ import (
 	"crypto/cipher"
 	"encoding/binary"
 	"fmt"
-	"log"
 
 	"github.com/aead/cmac"
 	"github.com/dumacp/smartcard"
@@ -41,8 +40,6 @@ func EncryptFullProtection(cmdCtr int, data, ke []byte) ([]byte, error) {
 
 	mode := cipher.NewCBCEncrypter(block, ivEnc)
 
-	log.Printf("data    : [ %X ], len: %d", data, len(data))
-
 	if mod := len(data) % lenBlock; mod != 0 {
 		data = append(data, 0x80)
 		data = append(data, make([]byte, lenBlock-mod-1)...)
@@ -52,8 +49,6 @@ func EncryptFullProtection(cmdCtr int, data, ke []byte) ([]byte, error) {
 
 	mode.CryptBlocks(dst, data)
 
-	log.Printf("dataload: [ %X ], len: %d", data, len(data))
-
 	return dst, nil
 }
 
@@ -62,9 +57,6 @@ func MacFullProtection(cmd smartcard.ISO7816cmd, cmdCtr int, data, key []byte) (
 
 	lenBlock := len(key)
 
-	log.Printf("cmdCtr: %d", cmdCtr)
-	log.Printf("km: [ %X ]", key)
-
 	if lenBlock%8 != 0 {
 		return nil, fmt.Errorf("key len is wrong")
 	}
@@ -93,8 +85,6 @@ func MacFullProtection(cmd smartcard.ISO7816cmd, cmdCtr int, data, key []byte) (
 	//	padding := make([]byte, 16-mod)
 	//	dataMac = append(dataMac, padding...)
 	//}
-	log.Printf("cmacload: [ %X ], len: %d", dataMac, len(dataMac))
-	log.Printf("data    : [ %X ], len: %d", data, len(data))
 	//if mod := len(dataMac) % lenBlock; mod != 0 {
 	//	dataMac = append(dataMac, make([]byte, lenBlock-mod)...)
 	//}
@@ -195,13 +185,10 @@ func OfflineChangeKeyEncrypt(data, kc, samUID []byte, changeCtr int) ([]byte, er
 		data = append(data, samUID...)
 	}
 
-	log.Printf("data   : [ %X ]", data)
-
 	if mod := len(data) % lenBlock; mod != 0 {
 		data = append(data, 0x80)
 		data = append(data, make([]byte, lenBlock-mod-1)...)
 	}
-	log.Printf("data   : [ %X ]", data)
 
 	dst := make([]byte, len(data))
 
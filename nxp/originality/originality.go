@@ -0,0 +1,115 @@
+/*
+Package originality verify the NXP originality signature (ReadSig) of the
+cards: the ECDSA secp224r1 signature of the UID with the NXP key of the
+product. The signature is r || s (28 bytes each) and the message is the UID
+without hash.
+
+The keys are the published keys of the products with signatures on
+secp224r1 (MIFARE Ultralight EV1 and NTAG21x sign with secp128r1 and are not
+supported).
+*/
+package originality
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PublicKey originality public key of a NXP product, the uncompressed point
+// (0x04 || X || Y)
+type PublicKey struct {
+	Name  string
+	Point []byte
+}
+
+func mustKey(name, point string) PublicKey {
+	b, err := hex.DecodeString(point)
+	if err != nil {
+		panic(err)
+	}
+	return PublicKey{Name: name, Point: b}
+}
+
+// Published originality keys
+var (
+	DESFireEV2   = mustKey("MIFARE DESFire EV2", "04B304DC4C615F5326FE9383DDEC9AA892DF3A57FA7FFB3276192BC0EAA252ED45A865E3B093A3D0DCE5BE29E92F1392CE7DE321E3E5C52B3A")
+	DESFireEV2XL = mustKey("MIFARE DESFire EV2 XL", "04CD5D45E50B1502F0BA4656FF37669597E7E183251150F9574CC8DA56BF01C7ABE019E29FEA48F9CE22C3EA4029A765E1BC95A89543BAD1BC")
+	DESFireEV3   = mustKey("MIFARE DESFire EV3", "041DB46C145D0A36539C6544BD6D9B0AA62FF91EC48CBC6ABAE36E0089A46F0D08C8A715EA40A63313B92E90DDC1730230E0458A33276FB743")
+	DESFireLight = mustKey("MIFARE DESFire Light", "040E98E117AAA36457F43173DC920A8757267F44CE4EC5ADD3C54075571AEBBF7B942A9774A1D94AD02572427E5AE0A2DD36591B1FB34FCF3D")
+	NTAG424DNA   = mustKey("NTAG 424 DNA", "048A9B380AF2EE1B98DC417FECC263F8449C7625CECE82D9B916C992DA209D68422B81EC20B65A66B5102A61596AF3379200599316A00A1410")
+	PlusEV1      = mustKey("MIFARE Plus EV1", "044409ADC42F91A8394066BA83D872FB1D16803734E911170412DDF8BAD1A4DADFD0416291AFE1C748253925DA39A5F39A1C557FFACD34C62E")
+	PlusEV2      = mustKey("MIFARE Plus EV2", "04BB49AE4447E6B1B6D21C098C1538B594A11A4A1DBF3D5E673DEACDEB3CC512D1C08AFA1A2768CE20A200BACD2DC7804CD7523A0131ABF607")
+)
+
+// Keys all the published keys, Verify tries them without keys
+var Keys = []PublicKey{
+	DESFireEV2,
+	DESFireEV2XL,
+	DESFireEV3,
+	DESFireLight,
+	NTAG424DNA,
+	PlusEV1,
+	PlusEV2,
+}
+
+var (
+	ErrInvalidSignature = errors.New("invalid originality signature")
+	ErrInvalidKey       = errors.New("invalid originality public key")
+)
+
+// SignatureLen length of the secp224r1 signature (r || s)
+const SignatureLen = 56
+
+func (k PublicKey) ecdsa() (*ecdsa.PublicKey, error) {
+	curve := elliptic.P224()
+	size := (curve.Params().BitSize + 7) / 8
+	if len(k.Point) != 1+2*size || k.Point[0] != 0x04 {
+		return nil, fmt.Errorf("%q, %w", k.Name, ErrInvalidKey)
+	}
+	x := new(big.Int).SetBytes(k.Point[1 : 1+size])
+	y := new(big.Int).SetBytes(k.Point[1+size:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("%q, %w", k.Name, ErrInvalidKey)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// Verify verify the originality signature of uid with keys (all the
+// published Keys if keys is empty) and return the key of the signature.
+// Invalid keys are skipped, ErrInvalidKey only if none of the keys is valid.
+func Verify(uid, signature []byte, keys ...PublicKey) (*PublicKey, error) {
+	if len(uid) <= 0 {
+		return nil, errors.New("uid is null")
+	}
+	if len(signature) != SignatureLen {
+		return nil, fmt.Errorf("len signature = %d, %w", len(signature), ErrInvalidSignature)
+	}
+	if len(keys) <= 0 {
+		keys = Keys
+	}
+
+	r := new(big.Int).SetBytes(signature[:SignatureLen/2])
+	s := new(big.Int).SetBytes(signature[SignatureLen/2:])
+
+	var errKey error
+	valid := 0
+	for i := range keys {
+		pub, err := keys[i].ecdsa()
+		if err != nil {
+			errKey = err
+			continue
+		}
+		valid++
+		if ecdsa.Verify(pub, uid, r, s) {
+			return &keys[i], nil
+		}
+	}
+	if valid <= 0 {
+		return nil, errKey
+	}
+	return nil, ErrInvalidSignature
+}
@@ -0,0 +1,129 @@
+package originality
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	for _, k := range Keys {
+		t.Run(k.Name, func(t *testing.T) {
+			if _, err := k.ecdsa(); err != nil {
+				t.Errorf("ecdsa() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := PublicKey{
+		Name:  "test",
+		Point: elliptic.Marshal(elliptic.P224(), priv.X, priv.Y),
+	}
+
+	uid := []byte{0x04, 0x51, 0x6A, 0x12, 0x34, 0x56, 0x80}
+	r, s, err := ecdsa.Sign(rand.Reader, priv, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, SignatureLen)
+	r.FillBytes(signature[:SignatureLen/2])
+	s.FillBytes(signature[SignatureLen/2:])
+
+	badSignature := append([]byte{}, signature...)
+	badSignature[10] ^= 0x01
+
+	tests := []struct {
+		name      string
+		uid       []byte
+		signature []byte
+		keys      []PublicKey
+		wantErr   error
+	}{
+		{
+			name:      "valid",
+			uid:       uid,
+			signature: signature,
+			keys:      []PublicKey{DESFireEV2, key},
+		},
+		{
+			name:      "other uid",
+			uid:       []byte{0x04, 0x51, 0x6A, 0x12, 0x34, 0x56, 0x81},
+			signature: signature,
+			keys:      []PublicKey{key},
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "modified signature",
+			uid:       uid,
+			signature: badSignature,
+			keys:      []PublicKey{key},
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "not NXP",
+			uid:       uid,
+			signature: signature,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "short signature",
+			uid:       uid,
+			signature: signature[:32],
+			keys:      []PublicKey{key},
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "invalid key",
+			uid:       uid,
+			signature: signature,
+			keys:      []PublicKey{{Name: "invalid", Point: key.Point[:29]}},
+			wantErr:   ErrInvalidKey,
+		},
+		{
+			name:      "invalid key before the key",
+			uid:       uid,
+			signature: signature,
+			keys:      []PublicKey{{Name: "invalid", Point: key.Point[:29]}, key},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(tt.uid, tt.signature, tt.keys...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name != key.Name {
+				t.Errorf("Verify() key = %q, want %q", got.Name, key.Name)
+			}
+		})
+	}
+}
+
+// TestVerify_NTAG424DNA signature of the example of AN12196 (NTAG 424 DNA
+// features and hints), raw UID and r || s
+func TestVerify_NTAG424DNA(t *testing.T) {
+	uid, _ := hex.DecodeString("04518DFAA96180")
+	signature, _ := hex.DecodeString("D1940D17CFEDA4BFF80359AB975F9F6514313E8F90C1D3CAAF5941AD744A1CDF9A83F883CAFE0FE95D1939B1B7E47113993324473B785D21")
+
+	got, err := Verify(uid, signature)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.Name != NTAG424DNA.Name {
+		t.Errorf("Verify() key = %q, want %q", got.Name, NTAG424DNA.Name)
+	}
+
+	swapped := append(append([]byte{}, signature[SignatureLen/2:]...), signature[:SignatureLen/2]...)
+	if _, err := Verify(uid, swapped); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() s || r error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,140 @@
+package nxp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+// ErrProximityCheck the card failed the proximity check (wrong RndR or MAC
+// of VerifyPC)
+var ErrProximityCheck = errors.New("proximity check failed")
+
+// ProximityCheck result of the proximity check (PreparePC, ProximityCheck
+// and VerifyPC) of MIFARE Plus EV1 and DESFire EV2. Times are the round trip
+// times of the ProximityCheck commands, measured by the card of the reader
+// (smartcard.ICardTimed) as close to the reader as possible. The application
+// enforces the threshold, e.g. MaxTime() with a margin over PubRespTime.
+type ProximityCheck struct {
+	// OPT options of PreparePC, bit 0: PPS1 present
+	OPT byte
+	// PubRespTime published response time of the card
+	PubRespTime []byte
+	// PPS1 the bit rates of the proximity check (if OPT bit 0)
+	PPS1  []byte
+	RndC  []byte
+	RndR  []byte
+	Times []time.Duration
+}
+
+// MaxTime the longest round trip time of the ProximityCheck commands
+func (pc *ProximityCheck) MaxTime() time.Duration {
+	max := time.Duration(0)
+	for _, v := range pc.Times {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// macInput OPT || pubRespTime || [PPS1] || RndRC, with RndRC = RndR1 ||
+// RndC1 || ... || RndR8 || RndC8
+func (pc *ProximityCheck) macInput() []byte {
+	data := make([]byte, 0)
+	data = append(data, pc.OPT)
+	data = append(data, pc.PubRespTime...)
+	data = append(data, pc.PPS1...)
+	for i := range pc.RndC {
+		data = append(data, pc.RndR[i], pc.RndC[i])
+	}
+	return data
+}
+
+// RunProximityCheck run the proximity check with the card in rounds (1, 2,
+// 4 or 8) of ProximityCheck. verify is the verification of the status of
+// the responses and mac the truncated MAC (8 bytes) of VerifyPC, with the
+// ProximityCheck key or the MAC session key.
+func RunProximityCheck(card smartcard.ICard, rounds int,
+	verify func(resp []byte) error,
+	mac func(data []byte) ([]byte, error)) (*ProximityCheck, error) {
+
+	if rounds <= 0 || rounds > 8 || 8%rounds != 0 {
+		return nil, fmt.Errorf("rounds %d (not 1, 2, 4 or 8)", rounds)
+	}
+
+	// PreparePC
+	resp, err := card.Apdu([]byte{0xF0})
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(resp); err != nil {
+		return nil, err
+	}
+	if len(resp) < 4 {
+		return nil, fmt.Errorf("PreparePC response [% X], %w", resp, ErrProximityCheck)
+	}
+	pc := &ProximityCheck{
+		OPT:         resp[1],
+		PubRespTime: resp[2:4],
+	}
+	if pc.OPT&0x01 != 0 {
+		if len(resp) < 5 {
+			return nil, fmt.Errorf("PreparePC response [% X], %w", resp, ErrProximityCheck)
+		}
+		pc.PPS1 = resp[4:5]
+	}
+
+	pc.RndC = make([]byte, 8)
+	if _, err := rand.Read(pc.RndC); err != nil {
+		return nil, err
+	}
+	pc.RndR = make([]byte, 0, 8)
+	pc.Times = make([]time.Duration, 0, rounds)
+
+	// ProximityCheck
+	n := 8 / rounds
+	for i := 0; i < rounds; i++ {
+		apdu := []byte{0xF2, byte(n)}
+		apdu = append(apdu, pc.RndC[i*n:(i+1)*n]...)
+		resp, elapsed, err := smartcard.ApduTimed(card, apdu)
+		if err != nil {
+			return nil, err
+		}
+		if err := verify(resp); err != nil {
+			return nil, err
+		}
+		if len(resp) != 1+n {
+			return nil, fmt.Errorf("ProximityCheck response [% X], %w", resp, ErrProximityCheck)
+		}
+		pc.RndR = append(pc.RndR, resp[1:]...)
+		pc.Times = append(pc.Times, elapsed)
+	}
+
+	// VerifyPC
+	data := pc.macInput()
+	cmdMac, err := mac(append([]byte{0xFD}, data...))
+	if err != nil {
+		return nil, err
+	}
+	apdu := append([]byte{0xFD}, cmdMac...)
+	resp, err = card.Apdu(apdu)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(resp); err != nil {
+		return nil, err
+	}
+	respMac, err := mac(append([]byte{0x90}, data...))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 1+len(respMac) || !bytes.Equal(resp[1:1+len(respMac)], respMac) {
+		return nil, fmt.Errorf("VerifyPC MAC, %w", ErrProximityCheck)
+	}
+	return pc, nil
+}
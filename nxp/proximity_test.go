@@ -0,0 +1,137 @@
+package nxp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dumacp/smartcard/sim"
+)
+
+// pcCard simulator of the proximity check of the card, the MAC is a keyed
+// hash truncated to 8 bytes
+type pcCard struct {
+	*sim.Card
+	prepare []byte
+	rndR    []byte
+	rndC    []byte
+	delay   time.Duration
+	badMAC  bool
+}
+
+func pcMAC(data []byte) ([]byte, error) {
+	sum := sha256.Sum256(append([]byte("pc-key"), data...))
+	return sum[:8], nil
+}
+
+func newPCCard(prepare []byte) *pcCard {
+	c := &pcCard{
+		Card:    sim.NewCard(nil, nil, nil, 0x20),
+		prepare: prepare,
+		rndR:    []byte{0xA0, 0xA1, 0xA2, 0xA3, 0xA4, 0xA5, 0xA6, 0xA7},
+	}
+	c.Handle([]byte{0xF0}, func(apdu []byte) ([]byte, error) {
+		c.rndC = c.rndC[:0]
+		return c.prepare, nil
+	})
+	c.Handle([]byte{0xF2}, func(apdu []byte) ([]byte, error) {
+		time.Sleep(c.delay)
+		n := int(apdu[1])
+		i := len(c.rndC)
+		c.rndC = append(c.rndC, apdu[2:2+n]...)
+		return append([]byte{0x90}, c.rndR[i:i+n]...), nil
+	})
+	c.Handle([]byte{0xFD}, func(apdu []byte) ([]byte, error) {
+		data := append([]byte{}, c.prepare[1:]...)
+		for i := range c.rndC {
+			data = append(data, c.rndR[i], c.rndC[i])
+		}
+		mac, _ := pcMAC(append([]byte{0xFD}, data...))
+		if !bytes.Equal(apdu[1:], mac) {
+			return []byte{0x06}, nil
+		}
+		mac, _ = pcMAC(append([]byte{0x90}, data...))
+		if c.badMAC {
+			mac[0] ^= 0x01
+		}
+		return append([]byte{0x90}, mac...), nil
+	})
+	return c
+}
+
+func verifyPC(resp []byte) error {
+	if len(resp) <= 0 || resp[0] != 0x90 {
+		return errors.New("status error")
+	}
+	return nil
+}
+
+func TestRunProximityCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare []byte
+		rounds  int
+		delay   time.Duration
+		badMAC  bool
+		wantErr error
+	}{
+		{
+			name:    "8 rounds",
+			prepare: []byte{0x90, 0x00, 0x01, 0x2C},
+			rounds:  8,
+		},
+		{
+			name:    "1 round with PPS1",
+			prepare: []byte{0x90, 0x01, 0x01, 0x2C, 0x00},
+			rounds:  1,
+			delay:   10 * time.Millisecond,
+		},
+		{
+			name:    "wrong MAC of the card",
+			prepare: []byte{0x90, 0x00, 0x01, 0x2C},
+			rounds:  4,
+			badMAC:  true,
+			wantErr: ErrProximityCheck,
+		},
+		{
+			name:    "PPS1 missing",
+			prepare: []byte{0x90, 0x01, 0x01, 0x2C},
+			rounds:  2,
+			wantErr: ErrProximityCheck,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := newPCCard(tt.prepare)
+			card.delay = tt.delay
+			card.badMAC = tt.badMAC
+			got, err := RunProximityCheck(card, tt.rounds, verifyPC, pcMAC)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunProximityCheck() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Times) != tt.rounds {
+				t.Errorf("len(Times) = %d, want %d", len(got.Times), tt.rounds)
+			}
+			if got.MaxTime() < tt.delay {
+				t.Errorf("MaxTime() = %s, want >= %s", got.MaxTime(), tt.delay)
+			}
+			if !bytes.Equal(got.RndR, card.rndR) || !bytes.Equal(got.RndC, card.rndC) {
+				t.Errorf("RndR = [% X], RndC = [% X]", got.RndR, got.RndC)
+			}
+		})
+	}
+}
+
+func TestRunProximityCheck_rounds(t *testing.T) {
+	for _, rounds := range []int{0, 3, 16} {
+		card := newPCCard([]byte{0x90, 0x00, 0x01, 0x2C})
+		if _, err := RunProximityCheck(card, rounds, verifyPC, pcMAC); err == nil {
+			t.Errorf("RunProximityCheck(%d rounds) error = nil", rounds)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package smartcard
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrURI the URI of the reader is malformed or the scheme isn't registered
+var ErrURI = errors.New("bad reader uri")
+
+// URI configuration of a reader in the form scheme://device?option=value.
+// Device is the serial port, SPI bus or PCSC reader name.
+type URI struct {
+	Scheme  string
+	Device  string
+	Options url.Values
+}
+
+// ParseURI parse the configuration of a reader. The device isn't parsed as
+// an URL host, so it can have spaces ("pcsc://ACS ACR122U").
+func ParseURI(uri string) (*URI, error) {
+	idx := strings.Index(uri, "://")
+	if idx <= 0 {
+		return nil, fmt.Errorf("uri %q without scheme, %w", uri, ErrURI)
+	}
+	u := &URI{
+		Scheme:  strings.ToLower(uri[:idx]),
+		Options: url.Values{},
+	}
+	rest := uri[idx+3:]
+	if i := strings.Index(rest, "?"); i >= 0 {
+		options, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("uri %q options err = %s, %w", uri, err, ErrURI)
+		}
+		u.Options = options
+		rest = rest[:i]
+	}
+	device, err := url.PathUnescape(rest)
+	if err != nil {
+		return nil, fmt.Errorf("uri %q device err = %s, %w", uri, err, ErrURI)
+	}
+	u.Device = device
+	return u, nil
+}
+
+// Option value of the option key, def if it is not in the URI
+func (u *URI) Option(key, def string) string {
+	if !u.Options.Has(key) {
+		return def
+	}
+	return u.Options.Get(key)
+}
+
+// Int integer value of the option key, def if it is not in the URI
+func (u *URI) Int(key string, def int) (int, error) {
+	if !u.Options.Has(key) {
+		return def, nil
+	}
+	v, err := strconv.Atoi(u.Options.Get(key))
+	if err != nil {
+		return 0, fmt.Errorf("option %q err = %s, %w", key, err, ErrURI)
+	}
+	return v, nil
+}
+
+// Duration duration value of the option key ("300ms", "1s"), def if it is
+// not in the URI
+func (u *URI) Duration(key string, def time.Duration) (time.Duration, error) {
+	if !u.Options.Has(key) {
+		return def, nil
+	}
+	v, err := time.ParseDuration(u.Options.Get(key))
+	if err != nil {
+		return 0, fmt.Errorf("option %q err = %s, %w", key, err, ErrURI)
+	}
+	return v, nil
+}
+
+// OpenFunc function of a backend to open the reader of the URI
+type OpenFunc func(u *URI) (IReader, error)
+
+var (
+	backendsMux sync.RWMutex
+	backends    = make(map[string]OpenFunc)
+)
+
+// Register make a reader backend available by scheme to Open. The backend
+// packages register themselves in init, so the application only imports
+// them (import _ "github.com/dumacp/smartcard/multiiso").
+// Register panics if it is called twice with the same scheme.
+func Register(scheme string, open OpenFunc) {
+	backendsMux.Lock()
+	defer backendsMux.Unlock()
+	scheme = strings.ToLower(scheme)
+	if open == nil {
+		panic("smartcard: Register open func is nil")
+	}
+	if _, dup := backends[scheme]; dup {
+		panic("smartcard: Register called twice for scheme " + scheme)
+	}
+	backends[scheme] = open
+}
+
+// Schemes sorted list of the registered schemes
+func Schemes() []string {
+	backendsMux.RLock()
+	defer backendsMux.RUnlock()
+	list := make([]string, 0, len(backends))
+	for scheme := range backends {
+		list = append(list, scheme)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Open open the reader of the URI with the registered backend, e.g.:
+//
+//	multiiso:///dev/ttyS1?baud=115200&mode=binary
+//	acr128s:///dev/ttyUSB0?slot=sam
+//	rcr3300:///dev/ttyS2
+//	clrc663://SPI0.0
+//	pcsc://ACS ACR122U
+func Open(uri string) (IReader, error) {
+	u, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	backendsMux.RLock()
+	open, ok := backends[u.Scheme]
+	backendsMux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("scheme %q not registered (forgotten import?), %w", u.Scheme, ErrURI)
+	}
+	return open(u)
+}
@@ -0,0 +1,119 @@
+package smartcard
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		uri        string
+		wantScheme string
+		wantDevice string
+		wantOption map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "serial with options",
+			uri:        "multiiso:///dev/ttyS1?baud=115200&mode=binary",
+			wantScheme: "multiiso",
+			wantDevice: "/dev/ttyS1",
+			wantOption: map[string]string{"baud": "115200", "mode": "binary"},
+		},
+		{
+			name:       "spi",
+			uri:        "clrc663://SPI0.0",
+			wantScheme: "clrc663",
+			wantDevice: "SPI0.0",
+		},
+		{
+			name:       "pcsc name with spaces",
+			uri:        "PCSC://ACS ACR122U",
+			wantScheme: "pcsc",
+			wantDevice: "ACS ACR122U",
+		},
+		{
+			name:       "escaped device",
+			uri:        "pcsc://ACS%20ACR1252%201S?x=1",
+			wantScheme: "pcsc",
+			wantDevice: "ACS ACR1252 1S",
+			wantOption: map[string]string{"x": "1"},
+		},
+		{
+			name:    "without scheme",
+			uri:     "/dev/ttyS1",
+			wantErr: true,
+		},
+		{
+			name:    "bad options",
+			uri:     "rcr3300:///dev/ttyS2?baud=%zz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrURI) {
+					t.Errorf("ParseURI() error = %v, want %v", err, ErrURI)
+				}
+				return
+			}
+			if got.Scheme != tt.wantScheme || got.Device != tt.wantDevice {
+				t.Errorf("ParseURI() = %q %q, want %q %q", got.Scheme, got.Device, tt.wantScheme, tt.wantDevice)
+			}
+			for k, v := range tt.wantOption {
+				if got.Option(k, "") != v {
+					t.Errorf("ParseURI() option %q = %q, want %q", k, got.Option(k, ""), v)
+				}
+			}
+		})
+	}
+}
+
+func TestURI_Options(t *testing.T) {
+	u, err := ParseURI("acr128s:///dev/ttyUSB0?slot=sam&baud=9600&timeout=300ms&bad=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baud, err := u.Int("baud", 115200); err != nil || baud != 9600 {
+		t.Errorf("Int(baud) = %d, %v, want 9600", baud, err)
+	}
+	if idx, err := u.Int("idx", 3); err != nil || idx != 3 {
+		t.Errorf("Int(idx) = %d, %v, want default 3", idx, err)
+	}
+	if timeout, err := u.Duration("timeout", time.Second); err != nil || timeout != 300*time.Millisecond {
+		t.Errorf("Duration(timeout) = %s, %v, want 300ms", timeout, err)
+	}
+	if _, err := u.Int("bad", 0); !errors.Is(err, ErrURI) {
+		t.Errorf("Int(bad) error = %v, want %v", err, ErrURI)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	var got *URI
+	Register("test-open", func(u *URI) (IReader, error) {
+		got = u
+		return &pollReader{}, nil
+	})
+	defer func() {
+		backendsMux.Lock()
+		delete(backends, "test-open")
+		backendsMux.Unlock()
+	}()
+
+	if _, err := Open("test-open:///dev/ttyS9?baud=57600"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got == nil || got.Device != "/dev/ttyS9" || got.Option("baud", "") != "57600" {
+		t.Errorf("Open() uri = %+v", got)
+	}
+	if _, err := Open("unknown:///dev/ttyS9"); !errors.Is(err, ErrURI) {
+		t.Errorf("Open() error = %v, want %v", err, ErrURI)
+	}
+}
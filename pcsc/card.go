@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/tlv"
 	"github.com/ebfe/scard"
 )
 
@@ -43,6 +44,7 @@ type Scard struct {
 	timeout time.Duration
 	sak     byte
 	atr     []byte
+	context *scard.Context
 }
 
 // SetTimeout Set timeout to wait for card response. Default is 3 seconds
@@ -50,6 +52,24 @@ func (c *Scard) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
 }
 
+// BeginExclusive start a transaction (SCardBeginTransaction), the other
+// contexts can't access the card until EndExclusive
+func (c *Scard) BeginExclusive() error {
+	if err := c.Card.BeginTransaction(); err != nil {
+		return smartcard.Error(err)
+	}
+	return nil
+}
+
+// EndExclusive end the transaction with disposition type LeaveCard, the
+// card keeps connected
+func (c *Scard) EndExclusive() error {
+	if err := c.Card.EndTransaction(scard.LeaveCard); err != nil {
+		return smartcard.Error(err)
+	}
+	return nil
+}
+
 // EndTransactionn End transaccion with card with disposition type LeaveCard
 func (c *Scard) EndTransaction() error {
 	c.State = DISCONNECTED
@@ -75,7 +95,7 @@ func (c *Scard) DisconnectCard() error {
 		select {
 		case chErr <- c.Disconnect(scard.LeaveCard):
 		case <-contxt.Done():
-			fmt.Println("Channel is closed, cannot send error")
+			// the caller already returned by timeout
 		}
 	}()
 
@@ -93,86 +113,107 @@ func (c *Scard) DisconnectCard() error {
 // DiconnectResetCard Disconnect card from context with card with disposition type ResetCard
 func (c *Scard) DisconnectResetCard() error {
 	c.State = DISCONNECTED
-	fmt.Println("DisconnectResetCard")
 	return c.Disconnect(scard.ResetCard)
 }
 
 // DisconnectUnpowerCard Disconnect card from context with card with disposition type UnpowerCard
 func (c *Scard) DisconnectUnpowerCard() error {
 	c.State = DISCONNECTED
-	fmt.Println("DisconnectUnpowerCard")
 	return c.Disconnect(scard.UnpowerCard)
 }
 
 // DisconnectEjectCard Disconnect card from context with card with disposition type EjectCard
 func (c *Scard) DisconnectEjectCard() error {
 	c.State = DISCONNECTED
-	fmt.Println("DisconnectEjectCard")
 	return c.Disconnect(scard.EjectCard)
 }
 
 // Apdu Primitive function (SCardTransmit) to send command to card
 func (c *Scard) Apdu(apdu []byte) ([]byte, error) {
+	return c.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext Primitive function (SCardTransmit) to send command to card with
+// the ctx deadline and cancellation (SCardCancel). The timeout of the card
+// is applied too. On cancellation or timeout the card is disconnected
+// (LeaveCard) after SCardTransmit returns, it must be connected again.
+func (c *Scard) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	resp, _, err := c.transmit(ctx, apdu)
+	return resp, err
+}
+
+// ApduTimed Primitive function (SCardTransmit) to send command to card and
+// measure the round trip time of SCardTransmit. On timeout the transmit is
+// canceled and the card is disconnected as in ApduContext.
+func (c *Scard) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	return c.transmit(context.Background(), apdu)
+}
+
+func (c *Scard) transmit(ctx context.Context, apdu []byte) ([]byte, time.Duration, error) {
 	if c.State != CONNECTED {
-		return nil, fmt.Errorf("don't Connect to Card, %w", smartcard.ErrComm)
+		return nil, 0, fmt.Errorf("don't Connect to Card, %w", smartcard.ErrComm)
 	}
 	// fmt.Printf("APDU: [% X], len: %d\n", apdu, len(apdu))
-	ch := make(chan []byte)
-	chErr := make(chan error)
+	type result struct {
+		resp    []byte
+		elapsed time.Duration
+		err     error
+	}
+	ch := make(chan result, 1)
 
-	contxt, cancel := context.WithTimeout(context.Background(), c.timeout)
+	contxt, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	go func() {
-		defer close(ch)
-		defer close(chErr)
-
+		t0 := time.Now()
 		resp, err := c.Transmit(apdu)
-		if err != nil {
-			// chErr <- err
-			select {
-			case chErr <- err:
-			case <-contxt.Done():
-				fmt.Println("Channel is closed, cannot send error")
-			}
-			return
-		}
-		// ch <- resp
-		select {
-		case ch <- resp:
-		case <-contxt.Done():
-			fmt.Println("Channel is closed, cannot send response")
-		}
+		ch <- result{resp, time.Since(t0), err}
 	}()
 
 	select {
-	case resp := <-ch:
-		// fmt.Printf("Response: [% X], len: %d\n", resp, len(resp))
-		result := make([]byte, len(resp))
-		copy(result, resp)
-		return result, nil
-	case err := <-chErr:
-		if err != nil {
-			switch {
-			case errors.Is(err, scard.ErrNoSmartcard):
-			case errors.Is(err, scard.ErrCardUnsupported):
-			case errors.Is(err, scard.ErrRemovedCard):
-			case errors.Is(err, scard.ErrUnsupportedCard):
-			case errors.Is(err, scard.ErrResetCard):
-			case errors.Is(err, scard.ErrNotTransacted):
-			default:
-				return nil, smartcard.Error(fmt.Errorf("%s, %w", err, smartcard.ErrComm))
-			}
-			return nil, smartcard.Error(err)
+	case res := <-ch:
+		if res.err != nil {
+			return nil, 0, transmitError(res.err)
 		}
+		// fmt.Printf("Response: [% X], len: %d\n", resp, len(resp))
+		result := make([]byte, len(res.resp))
+		copy(result, res.resp)
+		return result, res.elapsed, nil
 	case <-contxt.Done():
-		return nil, fmt.Errorf("timeout, %w", smartcard.ErrComm)
+		c.State = DISCONNECTED
+		var errCancel error
+		if c.context != nil {
+			errCancel = c.context.Cancel()
+		}
+		// a late response of SCardTransmit would be read as the response
+		// of the next APDU, the card is released when it returns
+		<-ch
+		if err := c.Disconnect(scard.LeaveCard); err != nil && errCancel == nil {
+			errCancel = err
+		}
+		msg := "timeout"
+		if ctx.Err() != nil {
+			msg = "apdu canceled"
+		}
+		if errCancel != nil {
+			return nil, 0, fmt.Errorf("%s (cancel err = %s), %w, %w", msg, errCancel, contxt.Err(), smartcard.ErrComm)
+		}
+		return nil, 0, fmt.Errorf("%s, %w, %w", msg, contxt.Err(), smartcard.ErrComm)
 	}
-	return nil, fmt.Errorf("timeout, %w", smartcard.ErrComm)
-	// fmt.Printf("Response: [% X], len: %d\n", resp, len(resp))
-	// result := make([]byte, len(resp))
-	// copy(result, resp)
-	// return result, nil
+}
+
+func transmitError(err error) error {
+	switch {
+	case errors.Is(err, scard.ErrNoSmartcard):
+	case errors.Is(err, scard.ErrCardUnsupported):
+	case errors.Is(err, scard.ErrRemovedCard):
+	case errors.Is(err, scard.ErrUnsupportedCard):
+	case errors.Is(err, scard.ErrResetCard):
+	case errors.Is(err, scard.ErrNotTransacted):
+	default:
+		return smartcard.Error(fmt.Errorf("%s, %w", err, smartcard.ErrComm))
+	}
+	return smartcard.Error(err)
 }
 
 // ControlApdu Primitive function (SCardControl) to send command to card
@@ -196,14 +237,14 @@ func (c *Scard) ControlApdu(ioctl uint32, apdu []byte) ([]byte, error) {
 			select {
 			case chErr <- err:
 			case <-contxt.Done():
-				fmt.Println("timeout, cannot send error")
+				// the caller already returned by timeout
 			}
 			return
 		}
 		select {
 		case ch <- resp:
 		case <-contxt.Done():
-			fmt.Println("timeout, cannot send response")
+			// the caller already returned by timeout
 		}
 		// ch <- resp
 	}()
@@ -255,13 +296,13 @@ func (c *Scard) ControlApduA(ioctl uint32, apdu []byte) ([]byte, error) {
 			select {
 			case chErr <- err:
 			case <-contxt.Done():
-				fmt.Println("timeout, cannot send error")
+				// the caller already returned by timeout
 			}
 		}
 		select {
 		case ch <- resp:
 		case <-contxt.Done():
-			fmt.Println("timeout, cannot send response")
+			// the caller already returned by timeout
 		}
 	}()
 
@@ -397,6 +438,26 @@ func (c *Scard) TransparentSessionEnd() ([]byte, error) {
 	return resp, nil
 }
 
+// ParseTransparentResponse decode the data objects of the response to a
+// transparent session command (PC/SC Part 3 Supplement) and verify the
+// generic error status (C0 03 XX SW1 SW2, XX the number of the failed object)
+func ParseTransparentResponse(resp []byte) (tlv.List, error) {
+	if len(resp) < 2 {
+		return nil, smartcard.Error(fmt.Errorf("transparent response [% X]", resp))
+	}
+	if resp[len(resp)-2] != 0x90 || resp[len(resp)-1] != 0x00 {
+		return nil, smartcard.Error(fmt.Errorf("transparent response SW: [% X]", resp[len(resp)-2:]))
+	}
+	objects, err := tlv.Decode(resp[:len(resp)-2])
+	if err != nil {
+		return nil, smartcard.Error(err)
+	}
+	if status := objects.Value(0xC0); len(status) == 3 && (status[1] != 0x90 || status[2] != 0x00) {
+		return objects, smartcard.Error(fmt.Errorf("transparent data object %d, status: [% X]", status[0], status[1:]))
+	}
+	return objects, nil
+}
+
 // Switch1444_4 switch channel reader to send ISO 1444-4 APDU
 func (c *Scard) Switch1444_4() ([]byte, error) {
 	apdu := []byte{0xff, 0xc2, 0x00, 0x02, 0x04, 0x8F, 0x02, 0x00, 0x04}
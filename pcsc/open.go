@@ -0,0 +1,42 @@
+package pcsc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("pcsc", Open)
+}
+
+// Open open the reader of the URI pcsc://<reader name>. The name matches the
+// complete name of the reader or a part of it ("ACS ACR122U" matches
+// "ACS ACR122U PICC Interface 00 00"); without name, the first reader.
+func Open(u *smartcard.URI) (smartcard.IReader, error) {
+	ctx, err := NewContext()
+	if err != nil {
+		return nil, err
+	}
+	readers, err := ListReaders(ctx)
+	if err != nil {
+		ctx.Release()
+		return nil, err
+	}
+	name := ""
+	for _, r := range readers {
+		if r == u.Device {
+			name = r
+			break
+		}
+		if len(name) <= 0 && strings.Contains(r, u.Device) {
+			name = r
+		}
+	}
+	if len(name) <= 0 {
+		ctx.Release()
+		return nil, fmt.Errorf("reader %q not found in %q, %w", u.Device, readers, smartcard.ErrComm)
+	}
+	return NewReader(ctx, name), nil
+}
@@ -12,6 +12,7 @@ projects on which it is based:
 package pcsc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -32,6 +33,7 @@ type Reader interface {
 	ConnectCardPCSC() (Card, error)
 	ConnectCardPCSC_T0() (Card, error)
 	ConnectCardPCSC_Tany() (Card, error)
+	Watch(ctx context.Context) (<-chan smartcard.CardEvent, error)
 }
 
 type reader struct {
@@ -104,6 +106,7 @@ func (r *reader) ConnectCardPCSC() (Card, error) {
 		State:   CONNECTED,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
@@ -125,6 +128,7 @@ func (r *reader) ConnectCardPCSC_T0() (Card, error) {
 		State:   CONNECTED,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
@@ -146,6 +150,7 @@ func (r *reader) ConnectCardPCSC_Tany() (Card, error) {
 		State:   CONNECTED,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
@@ -176,6 +181,7 @@ func (r *reader) ConnectCard() (smartcard.ICard, error) {
 		State:   CONNECTED,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
@@ -207,6 +213,7 @@ func (r *reader) connectCard() (*Scard, error) {
 		State:   CONNECTED,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
@@ -227,12 +234,111 @@ func (r *reader) ConnectDirect() (Card, error) {
 		State:   CONNECTEDDirect,
 		timeout: 3 * time.Second,
 		Card:    c,
+		context: r.Context.Context,
 		sak:     sak,
 		atr:     nil,
 	}
 	return cardS, nil
 }
 
+// Watch emits the insertion and removal of cards in the reader with
+// SCardGetStatusChange until ctx is done. The events use an own context in
+// pcscd, so they don't block the commands to the card.
+func (r *reader) Watch(ctx context.Context) (<-chan smartcard.CardEvent, error) {
+	wctx, err := scard.EstablishContext()
+	if err != nil {
+		return nil, fmt.Errorf("context err = %s, %w", err, smartcard.ErrComm)
+	}
+
+	ch := make(chan smartcard.CardEvent)
+	go func() {
+		defer close(ch)
+		defer wctx.Release()
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				wctx.Cancel()
+			case <-done:
+			}
+		}()
+
+		states := []scard.ReaderState{
+			{
+				Reader:       r.ReaderName,
+				CurrentState: scard.StateUnaware,
+			},
+		}
+		var last *smartcard.CardEvent
+		for {
+			if err := wctx.GetStatusChange(states, -1); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if errors.Is(err, scard.ErrTimeout) || errors.Is(err, scard.ErrCancelled) {
+					continue
+				}
+				// reader unavailable, wait before the next try
+				select {
+				case <-time.After(time.Second):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			state := states[0].EventState
+			states[0].CurrentState = state &^ scard.StateChanged
+
+			present := state&scard.StatePresent != 0 && state&scard.StateMute == 0
+			var ev *smartcard.CardEvent
+			switch {
+			case present && last == nil:
+				atr := make([]byte, len(states[0].Atr))
+				copy(atr, states[0].Atr)
+				last = &smartcard.CardEvent{
+					Type: smartcard.CardPresent,
+					UID:  r.watchUID(wctx),
+					ATR:  atr,
+				}
+				ev = last
+			case !present && last != nil:
+				ev = &smartcard.CardEvent{
+					Type: smartcard.CardRemoved,
+					UID:  last.UID,
+					ATR:  last.ATR,
+				}
+				last = nil
+			default:
+				continue
+			}
+			ev.Time = time.Now()
+			select {
+			case ch <- *ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// watchUID UID of the card with the PC/SC pseudo APDU GET DATA (nil if the
+// card is in use by other application)
+func (r *reader) watchUID(wctx *scard.Context) []byte {
+	c, err := wctx.Connect(r.ReaderName, scard.ShareShared, scard.ProtocolAny)
+	if err != nil {
+		return nil
+	}
+	defer c.Disconnect(scard.LeaveCard)
+	resp, err := c.Transmit([]byte{0xFF, 0xCA, 0x00, 0x00, 0x00})
+	if err != nil || len(resp) < 2 || resp[len(resp)-2] != 0x90 {
+		return nil
+	}
+	return resp[:len(resp)-2]
+}
+
 // Release Context in pcscd
 func (c *Context) Release() error {
 	err := c.Context.Release()
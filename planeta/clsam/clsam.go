@@ -31,6 +31,17 @@ func NewClSam(c smartcard.ICard) *ClSam {
 	return sam
 }
 
+// apduLc short APDU of the SAM, CLA INS P1 P2 Lc Data, with Lc even if data
+// is empty (the SAM is T=0, without extended APDUs)
+func apduLc(cla, ins, p1, p2 byte, data []byte) ([]byte, error) {
+	if len(data) > 0xFF {
+		return nil, fmt.Errorf("len data = %d, a short APDU of the SAM is up to 255 bytes", len(data))
+	}
+	apdu := []byte{cla, ins, p1, p2, byte(len(data))}
+	apdu = append(apdu, data...)
+	return apdu, nil
+}
+
 func (s *ClSam) UID() ([]byte, error) {
 	return s.Serial()
 }
@@ -78,9 +89,10 @@ func (s ClSam) PinVerify(data []byte) error {
 	if len(data) != 8 {
 		return fmt.Errorf("len error in pin (len must equal to 8)")
 	}
-	apdu := []byte{0x00, 0x20, 0x0C, 0x07, 0x08}
-
-	apdu = append(apdu, data...)
+	apdu, err := apduLc(0x00, 0x20, 0x0C, 0x07, data)
+	if err != nil {
+		return err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return err
@@ -93,9 +105,10 @@ func (s ClSam) PinVerify(data []byte) error {
 }
 
 func (s *ClSam) GetKey(keyfile []byte) (int, error) {
-	apdu := []byte{0x00, 0xC4, 0x00, 0x00}
-	apdu = append(apdu, byte(len(keyfile)))
-	apdu = append(apdu, keyfile...)
+	apdu, err := apduLc(0x00, 0xC4, 0x00, 0x00, keyfile)
+	if err != nil {
+		return 0, err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return 0, err
@@ -125,11 +138,10 @@ func (s *ClSam) GetResponse(lenResponse int) ([]byte, error) {
 }
 
 func (s *ClSam) ResetChannel(data []byte) error {
-	cmd := []byte{0x00, 0x72, 0x81, 0x01}
-	apdu := make([]byte, 0)
-	apdu = append(apdu, cmd...)
-	apdu = append(apdu, byte(len(data)))
-	apdu = append(apdu, data...)
+	apdu, err := apduLc(0x00, 0x72, 0x81, 0x01, data)
+	if err != nil {
+		return err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return err
@@ -142,11 +154,10 @@ func (s *ClSam) ResetChannel(data []byte) error {
 }
 
 func (s *ClSam) SelectFile00(fileId []byte) error {
-	cmd := []byte{0x00, 0xA4, 0x00, 0x0C}
-	apdu := make([]byte, 0)
-	apdu = append(apdu, cmd...)
-	apdu = append(apdu, byte(len(fileId)))
-	apdu = append(apdu, fileId...)
+	apdu, err := apduLc(0x00, 0xA4, 0x00, 0x0C, fileId)
+	if err != nil {
+		return err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return err
@@ -159,11 +170,10 @@ func (s *ClSam) SelectFile00(fileId []byte) error {
 }
 
 func (s *ClSam) SelectFile(fileId []byte) error {
-	cmd := []byte{0x03, 0xA4, 0x00, 0x0C}
-	apdu := make([]byte, 0)
-	apdu = append(apdu, cmd...)
-	apdu = append(apdu, byte(len(fileId)))
-	apdu = append(apdu, fileId...)
+	apdu, err := apduLc(0x03, 0xA4, 0x00, 0x0C, fileId)
+	if err != nil {
+		return err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return err
@@ -175,6 +185,8 @@ func (s *ClSam) SelectFile(fileId []byte) error {
 	return nil
 }
 
+// ReadBinary00 READ BINARY (CLA 00) of the selected EF, lenData is Le (0
+// reads 256 bytes)
 func (s *ClSam) ReadBinary00(lenData int) ([]byte, error) {
 	cmd := []byte{0x00, 0xB0, 0x00, 0x00}
 	apdu := make([]byte, 0)
@@ -190,6 +202,8 @@ func (s *ClSam) ReadBinary00(lenData int) ([]byte, error) {
 	return resp[:len(resp)-2], nil
 }
 
+// ReadBinary READ BINARY (CLA 03) of the selected EF, lenData is Le (0 reads
+// 256 bytes)
 func (s *ClSam) ReadBinary(lenData int) ([]byte, error) {
 	cmd := []byte{0x03, 0xB0, 0x00, 0x00}
 	apdu := make([]byte, 0)
@@ -206,12 +220,13 @@ func (s *ClSam) ReadBinary(lenData int) ([]byte, error) {
 }
 
 func (s *ClSam) PutFile(fileId, data []byte) ([]byte, error) {
-	cmd := []byte{0x00, 0xC7}
-	apdu := make([]byte, 0)
-	apdu = append(apdu, cmd...)
-	apdu = append(apdu, fileId...)
-	apdu = append(apdu, byte(len(data)))
-	apdu = append(apdu, data...)
+	if len(fileId) != 2 {
+		return nil, fmt.Errorf("len error in fileId (len must equal to 2)")
+	}
+	apdu, err := apduLc(0x00, 0xC7, fileId[0], fileId[1], data)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := s.Apdu(apdu)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,9 @@
 package rcr3300
 
 import (
+	"context"
+	"time"
+
 	"github.com/dumacp/smartcard"
 )
 
@@ -21,6 +24,19 @@ type Card struct {
 	typeTag TagType
 }
 
+// BeginExclusive lock the serial device to the card, the cards of the other
+// slots of the device wait until EndExclusive
+func (c *Card) BeginExclusive() error {
+	c.reader.dev.lockExclusive(c.reader)
+	return nil
+}
+
+// EndExclusive unlock the serial device
+func (c *Card) EndExclusive() error {
+	c.reader.dev.unlockExclusive(c.reader)
+	return nil
+}
+
 func (c *Card) Apdu(apdu []byte) ([]byte, error) {
 	switch c.typeTag {
 	case TAG_TYPEB:
@@ -32,6 +48,30 @@ func (c *Card) Apdu(apdu []byte) ([]byte, error) {
 	}
 }
 
+func (c *Card) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	switch c.typeTag {
+	case TAG_TYPEB:
+		return c.reader.TransmitBContext(ctx, apdu)
+	case SAM_T1:
+		return c.reader.TransmitSAM_T1Context(ctx, apdu)
+	default:
+		return c.reader.TransmitAContext(ctx, apdu)
+	}
+}
+
+// ApduTimed send apdu to the card and return the round trip time of the
+// frame in the serial device
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	var frame []byte
+	switch c.typeTag {
+	case SAM_T1:
+		frame = BuildFrame_SendSAM(apdu)
+	default:
+		frame = BuildFrame_SendTypeA(apdu)
+	}
+	return c.reader.transmitFrameTimed(context.Background(), frame)
+}
+
 func (c *Card) ATR() ([]byte, error) {
 
 	return c.atr, nil
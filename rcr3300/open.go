@@ -0,0 +1,29 @@
+package rcr3300
+
+import (
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("rcr3300", Open)
+}
+
+// Open open the reader of the URI rcr3300://<port>?baud=115200&timeout=1s
+func Open(u *smartcard.URI) (smartcard.IReader, error) {
+	baud, err := u.Int("baud", 115200)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := u.Duration("timeout", 1*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err := NewDevice(u.Device, baud, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(dev, u.Option("name", u.Device)), nil
+}
@@ -1,6 +1,7 @@
 package rcr3300
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -24,76 +25,61 @@ func NewReader(dev *Device, readerName string) *Reader {
 	return r
 }
 
-func (r *Reader) TransmitA(apdu []byte) ([]byte, error) {
-
-	data := BuildFrame_SendTypeA(apdu)
-
-	timeout := func() time.Duration {
-		if r.dev.timeout <= 100*time.Millisecond {
-			return 100 * time.Millisecond
-		}
-		return r.dev.timeout
-	}
-
-	response, err := r.dev.SendRecv(data, timeout())
-	if err != nil {
-		return nil, err
-	}
-
-	dataResponse, err := VerifyReponse(response)
-	if err != nil {
-		return nil, err
+func (r *Reader) timeout() time.Duration {
+	if r.dev.timeout <= 100*time.Millisecond {
+		return 100 * time.Millisecond
 	}
-
-	return dataResponse, nil
+	return r.dev.timeout
 }
 
-func (r *Reader) TransmitB(apdu []byte) ([]byte, error) {
-
-	data := BuildFrame_SendTypeA(apdu)
+func (r *Reader) transmitFrame(ctx context.Context, data []byte) ([]byte, error) {
+	dataResponse, _, err := r.transmitFrameTimed(ctx, data)
+	return dataResponse, err
+}
 
-	timeout := func() time.Duration {
-		if r.dev.timeout <= 100*time.Millisecond {
-			return 100 * time.Millisecond
-		}
-		return r.dev.timeout
-	}
+// transmitFrameTimed transmitFrame that returns the round trip time of the
+// frame in the serial device
+func (r *Reader) transmitFrameTimed(ctx context.Context, data []byte) ([]byte, time.Duration, error) {
 
-	response, err := r.dev.SendRecv(data, timeout())
+	defer r.dev.waitExclusive(r)()
+	response, elapsed, err := r.dev.sendRecvTimed(ctx, data, r.timeout())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	dataResponse, err := VerifyReponse(response)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return dataResponse, nil
+	return dataResponse, elapsed, nil
 }
 
-func (r *Reader) TransmitSAM_T1(apdu []byte) ([]byte, error) {
+func (r *Reader) TransmitA(apdu []byte) ([]byte, error) {
+	return r.TransmitAContext(context.Background(), apdu)
+}
 
-	data := BuildFrame_SendSAM(apdu)
+// TransmitAContext send apdu to tag type A with ctx cancellation
+func (r *Reader) TransmitAContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	return r.transmitFrame(ctx, BuildFrame_SendTypeA(apdu))
+}
 
-	timeout := func() time.Duration {
-		if r.dev.timeout <= 100*time.Millisecond {
-			return 100 * time.Millisecond
-		}
-		return r.dev.timeout
-	}
+func (r *Reader) TransmitB(apdu []byte) ([]byte, error) {
+	return r.TransmitBContext(context.Background(), apdu)
+}
 
-	response, err := r.dev.SendRecv(data, timeout())
-	if err != nil {
-		return nil, err
-	}
+// TransmitBContext send apdu to tag type B with ctx cancellation
+func (r *Reader) TransmitBContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	return r.transmitFrame(ctx, BuildFrame_SendTypeA(apdu))
+}
 
-	dataResponse, err := VerifyReponse(response)
-	if err != nil {
-		return nil, err
-	}
+func (r *Reader) TransmitSAM_T1(apdu []byte) ([]byte, error) {
+	return r.TransmitSAM_T1Context(context.Background(), apdu)
+}
 
-	return dataResponse, nil
+// TransmitSAM_T1Context send apdu to SAM (T=1) with ctx cancellation
+func (r *Reader) TransmitSAM_T1Context(ctx context.Context, apdu []byte) ([]byte, error) {
+	return r.transmitFrame(ctx, BuildFrame_SendSAM(apdu))
 }
 
 func (r *Reader) RFPower(on bool) ([]byte, error) {
@@ -107,6 +93,7 @@ func (r *Reader) RFPower(on bool) ([]byte, error) {
 		return r.dev.timeout
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, timeout())
 	if err != nil {
 		return nil, err
@@ -130,6 +117,7 @@ func (r *Reader) Request() ([]byte, error) {
 		return r.dev.timeout
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, timeout())
 	if err != nil {
 		return nil, err
@@ -152,6 +140,7 @@ func (r *Reader) Anticoll() ([]byte, error) {
 		return r.dev.timeout
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, timeout())
 	if err != nil {
 		return nil, err
@@ -174,6 +163,7 @@ func (r *Reader) RATS() ([]byte, error) {
 		return r.dev.timeout
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, timeout())
 	if err != nil {
 		return nil, err
@@ -196,6 +186,7 @@ func (r *Reader) ResetSAM() ([]byte, error) {
 		return r.dev.timeout
 	}
 
+	defer r.dev.waitExclusive(r)()
 	response, err := r.dev.SendRecv(data, timeout())
 	if err != nil {
 		return nil, err
@@ -218,6 +209,19 @@ func (r *Reader) ResetSAM() ([]byte, error) {
 	return dataResponse, nil
 }
 
+// Poll detect a typeA card in the field with anticollision, without RATS.
+// It returns the UID of the card.
+func (r *Reader) Poll() ([]byte, error) {
+	uid, err := r.Anticoll()
+	if err != nil {
+		return nil, fmt.Errorf("poll err = %s, %w", err, smartcard.ErrNoSmartcard)
+	}
+	if len(uid) >= 8 {
+		return uid[len(uid)-7:], nil
+	}
+	return uid, nil
+}
+
 // Create New Card typeA interface
 func (r *Reader) ConnectCard() (smartcard.ICard, error) {
 
@@ -233,7 +237,6 @@ func (r *Reader) ConnectCard() (smartcard.ICard, error) {
 	cardS := &Card{
 		uid: func(uid []byte) []byte {
 			if len(uid) >= 8 {
-				fmt.Printf("long UID: % X\n", uid)
 				return uid[len(uid)-7:]
 			}
 			return uid
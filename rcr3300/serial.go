@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dumacp/smartcard"
@@ -17,8 +18,12 @@ import (
 
 // Device struct
 type Device struct {
-	port    *serial.Port
-	mux     sync.Mutex
+	port *serial.Port
+	mux  sync.Mutex
+	// excl exclusive access of a slot to the device (BeginExclusive), owner
+	// is the reader of the slot
+	owner   atomic.Pointer[Reader]
+	excl    sync.Mutex
 	timeout time.Duration
 }
 
@@ -54,6 +59,30 @@ func (dev *Device) Close() bool {
 	return true
 }
 
+// lockExclusive start the exclusive access of the reader r to the device
+func (dev *Device) lockExclusive(r *Reader) {
+	dev.excl.Lock()
+	dev.owner.Store(r)
+}
+
+// unlockExclusive end the exclusive access of the reader r to the device
+func (dev *Device) unlockExclusive(r *Reader) {
+	if dev.owner.CompareAndSwap(r, nil) {
+		dev.excl.Unlock()
+	}
+}
+
+// waitExclusive wait until the exclusive access of the other readers ends
+// (EndExclusive) to send the frames of r, the device is released with the
+// returned function
+func (dev *Device) waitExclusive(r *Reader) func() {
+	if dev.owner.Load() == r {
+		return func() {}
+	}
+	dev.excl.Lock()
+	return dev.excl.Unlock
+}
+
 // Read read serial device with a channel
 func (dev *Device) read(contxt context.Context, waitResponse bool) ([]byte, error) {
 
@@ -93,7 +122,7 @@ func (dev *Device) read(contxt context.Context, waitResponse bool) ([]byte, erro
 
 		select {
 		case <-contxt.Done():
-			return nil, fmt.Errorf("timeout error")
+			return nil, fmt.Errorf("timeout error, %w, %w", contxt.Err(), smartcard.ErrComm)
 		default:
 		}
 		tempb := make([]byte, 2048)
@@ -193,7 +222,6 @@ func (dev *Device) read(contxt context.Context, waitResponse bool) ([]byte, erro
 		// }
 		dest := make([]byte, len(b))
 		copy(dest, b[:])
-		fmt.Printf("recv data: %v, [% X]\n", len(b), b[:])
 		return dest, nil
 
 	}
@@ -213,21 +241,38 @@ func (dev *Device) Send(data []byte) (int, error) {
 
 // SendRecv write daa bytes in serial device and wait by response
 func (dev *Device) SendRecv(data []byte, timeout time.Duration) ([]byte, error) {
+	return dev.SendRecvContext(context.Background(), data, timeout)
+}
+
+// SendRecvContext write data bytes in serial device and wait by response until
+// timeout or ctx is done. The pending read is aborted when ctx is done.
+func (dev *Device) SendRecvContext(contxt context.Context, data []byte, timeout time.Duration) ([]byte, error) {
+	resp, _, err := dev.sendRecvTimed(contxt, data, timeout)
+	return resp, err
+}
+
+// sendRecvTimed SendRecvContext that returns the time from the write of the
+// frame to the read of the response
+func (dev *Device) sendRecvTimed(contxt context.Context, data []byte, timeout time.Duration) ([]byte, time.Duration, error) {
 	dev.mux.Lock()
 	defer dev.mux.Unlock()
 	buff := make([]byte, 0)
 	buff = append(buff, data[:]...)
 
-	fmt.Printf("data send: [% X]\n", data)
+	t0 := time.Now()
 	if n, err := dev.port.Write(buff); err != nil {
-		return nil, fmt.Errorf("dont write in SendRecv command err: %s, %w", err, smartcard.ErrComm)
+		return nil, 0, fmt.Errorf("dont write in SendRecv command err: %s, %w", err, smartcard.ErrComm)
 	} else if n <= 0 {
-		return nil, fmt.Errorf("dont write in SendRecv command, %w", smartcard.ErrComm)
+		return nil, 0, fmt.Errorf("dont write in SendRecv command, %w", smartcard.ErrComm)
 	}
-	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	ctx, cancel := context.WithTimeout(contxt, timeout)
 	defer cancel()
 
-	return dev.read(ctx, true)
+	resp, err := dev.read(ctx, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, time.Since(t0), nil
 }
 
 // Recv read data bytes in serial device
@@ -0,0 +1,44 @@
+package smartcard
+
+// Span range of sensitive bytes in a frame. Offset is the first byte and
+// Length the number of bytes; a negative Length is counted from the end of
+// the frame (-1: until the last byte, -3: until the byte before SW1 SW2).
+type Span struct {
+	Offset int
+	Length int
+}
+
+// Resolve absolute range [start, end) of the span in a frame of size n
+func (s Span) Resolve(n int) (start, end int) {
+	start = s.Offset
+	if start < 0 {
+		start = 0
+	}
+	if s.Length < 0 {
+		end = n + s.Length + 1
+	} else {
+		end = start + s.Length
+	}
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
+
+// Redactor Interface to cards (middlewares) that expose the exchanges, as
+// traces or logs, and hide the sensitive bytes (keys, random numbers,
+// cryptograms) of the next exchange.
+type Redactor interface {
+	RedactNext(command, response []Span)
+}
+
+// RedactNext mark the sensitive bytes of the next exchange with the card. It
+// does nothing if the card doesn't implement Redactor.
+func RedactNext(card ICard, command, response []Span) {
+	if r, ok := card.(Redactor); ok {
+		r.RedactNext(command, response)
+	}
+}
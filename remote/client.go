@@ -0,0 +1,274 @@
+package remote
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+// DefaultTimeout max time of the dial and of each exchange with the server
+const DefaultTimeout = 10 * time.Second
+
+// Reader IReader of the reader exposed by a remote Server. Every connected
+// card is a session with its own connection.
+type Reader struct {
+	network string
+	address string
+	secret  []byte
+	// Timeout max time of the dial and of each exchange with the server
+	Timeout time.Duration
+	// Dial open the connection to the server (e.g. tls.Dialer), net.Dialer if nil
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewReader create the client of the server in address ("tcp",
+// "host:port" or "unix", "/run/smartcard.sock")
+func NewReader(network, address string, secret []byte) *Reader {
+	return &Reader{
+		network: network,
+		address: address,
+		secret:  secret,
+		Timeout: DefaultTimeout,
+	}
+}
+
+// ConnectCard connect the card of the remote reader
+func (r *Reader) ConnectCard() (smartcard.ICard, error) {
+	return r.connect(connectCard)
+}
+
+// ConnectSamCard connect the SAM of the remote reader
+func (r *Reader) ConnectSamCard() (smartcard.ICard, error) {
+	return r.connect(connectSam)
+}
+
+// ConnectSamCard_T0 connect the SAM of the remote reader with protocol T=0
+func (r *Reader) ConnectSamCard_T0() (smartcard.ICard, error) {
+	return r.connect(connectSamT0)
+}
+
+// ConnectSamCard_Tany connect the SAM of the remote reader with protocol T=any
+func (r *Reader) ConnectSamCard_Tany() (smartcard.ICard, error) {
+	return r.connect(connectSamTany)
+}
+
+func (r *Reader) connect(kind byte) (smartcard.ICard, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	dial := r.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, r.network, r.address)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %s, %w", err, smartcard.ErrComm)
+	}
+	c := &Card{
+		conn:    conn,
+		timeout: timeout,
+	}
+	if err := c.handshake(ctx, r.secret); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := c.call(ctx, opConnect, []byte{kind}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Card card connected in a remote reader
+type Card struct {
+	conn    net.Conn
+	timeout time.Duration
+	mux     sync.Mutex
+	// err the connection is out of sync (canceled or failed exchange)
+	err error
+}
+
+func (c *Card) handshake(ctx context.Context, secret []byte) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	_, err := c.exchange(ctx, func() ([]byte, error) {
+		op, payload, err := readFrame(c.conn)
+		if err != nil {
+			return nil, err
+		}
+		if op != opChallenge || len(payload) != 1+nonceLength || payload[0] != version {
+			return nil, fmt.Errorf("challenge [% X], %w", payload, ErrProtocol)
+		}
+		if err := writeFrame(c.conn, opAuth, mac(secret, payload[1:])); err != nil {
+			return nil, err
+		}
+		return c.response()
+	})
+	return err
+}
+
+// call send the request and wait the response of the server
+func (c *Card) call(ctx context.Context, op byte, payload []byte) ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.err != nil {
+		return nil, fmt.Errorf("remote: %s, %w", c.err, smartcard.ErrComm)
+	}
+	return c.exchange(ctx, func() ([]byte, error) {
+		if err := writeFrame(c.conn, op, payload); err != nil {
+			return nil, err
+		}
+		return c.response()
+	})
+}
+
+// exchange run f with the deadline of ctx (or the timeout of the card),
+// the connection is aborted if ctx is canceled
+func (c *Card) exchange(ctx context.Context, f func() ([]byte, error)) ([]byte, error) {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	resp, err := f()
+	if err == nil {
+		return resp, nil
+	}
+	var rerr *remoteError
+	if errors.As(err, &rerr) {
+		return nil, rerr.err
+	}
+	c.err = err
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("apdu canceled, %w, %w", ctxErr, smartcard.ErrComm)
+	}
+	// the timeout of the socket can fire before the timer of ctx
+	var nerr net.Error
+	if d, ok := ctx.Deadline(); ok && errors.As(err, &nerr) && nerr.Timeout() && !time.Now().Before(d) {
+		return nil, fmt.Errorf("apdu canceled, %w, %w", context.DeadlineExceeded, smartcard.ErrComm)
+	}
+	return nil, fmt.Errorf("remote: %s, %w", err, smartcard.ErrComm)
+}
+
+// remoteError error returned by the server, the connection is in sync
+type remoteError struct {
+	err error
+}
+
+func (e *remoteError) Error() string {
+	return e.err.Error()
+}
+
+func (c *Card) response() ([]byte, error) {
+	op, payload, err := readFrame(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case opOK:
+		return payload, nil
+	case opError:
+		return nil, &remoteError{err: decodeError(payload)}
+	}
+	return nil, fmt.Errorf("response op %02X, %w", op, ErrProtocol)
+}
+
+// Apdu send the command to the card
+func (c *Card) Apdu(apdu []byte) ([]byte, error) {
+	return c.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext send the command to the card with the ctx deadline and
+// cancellation. After a cancellation the card must be disconnected.
+func (c *Card) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	return c.call(ctx, opApdu, apdu)
+}
+
+// ApduTimed send the command to the card and return the round trip time of
+// the exchange measured by the server, without the time of the network
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	resp, err := c.call(context.Background(), opApduTimed, apdu)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp) < 8 {
+		return nil, 0, fmt.Errorf("apdu timed response [% X], %w", resp, ErrProtocol)
+	}
+	return resp[8:], time.Duration(binary.BigEndian.Uint64(resp)), nil
+}
+
+// ATR get the ATR of the card
+func (c *Card) ATR() ([]byte, error) {
+	return c.call(context.Background(), opATR, nil)
+}
+
+// UID get the UID of the card
+func (c *Card) UID() ([]byte, error) {
+	return c.call(context.Background(), opUID, nil)
+}
+
+// ATS get the ATS of the card
+func (c *Card) ATS() ([]byte, error) {
+	return c.call(context.Background(), opATS, nil)
+}
+
+// GetData send the get data command with ins
+func (c *Card) GetData(ins byte) ([]byte, error) {
+	return c.call(context.Background(), opGetData, []byte{ins})
+}
+
+// SAK get the SAK of the card (0xFF if it fails)
+func (c *Card) SAK() byte {
+	resp, err := c.call(context.Background(), opSAK, nil)
+	if err != nil || len(resp) != 1 {
+		return 0xFF
+	}
+	return resp[0]
+}
+
+// EndTransactionResetCard end the transaction of the card with reset
+func (c *Card) EndTransactionResetCard() error {
+	_, err := c.call(context.Background(), opEndTransaction, nil)
+	return err
+}
+
+func (c *Card) disconnect(disposition byte) error {
+	defer c.conn.Close()
+	_, err := c.call(context.Background(), opDisconnect, []byte{disposition})
+	return err
+}
+
+// DisconnectCard disconnect the card and close the session
+func (c *Card) DisconnectCard() error {
+	return c.disconnect(disconnectLeave)
+}
+
+// DisconnectResetCard disconnect the card with reset and close the session
+func (c *Card) DisconnectResetCard() error {
+	return c.disconnect(disconnectReset)
+}
+
+// DisconnectUnpowerCard disconnect the card with unpower and close the session
+func (c *Card) DisconnectUnpowerCard() error {
+	return c.disconnect(disconnectUnpower)
+}
+
+// DisconnectEjectCard disconnect the card with eject and close the session
+func (c *Card) DisconnectEjectCard() error {
+	return c.disconnect(disconnectEject)
+}
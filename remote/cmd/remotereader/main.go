@@ -0,0 +1,73 @@
+/*
+this app exposes a local reader to the remote clients (remote.Reader) over
+TCP or a Unix socket.
+
+Usage of ./remotereader:
+
+	-reader string
+	  	URI of the local reader (default "pcsc://")
+	-listen string
+	  	address to listen (default "tcp://0.0.0.0:4343")
+	-secret string
+	  	shared secret with the clients
+
+Example:
+
+	./remotereader -reader "multiiso:///dev/ttyS1?baud=115200" -listen unix:///run/smartcard.sock -secret s3cret
+*/
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/dumacp/smartcard"
+	_ "github.com/dumacp/smartcard/acs/acr128s"
+	_ "github.com/dumacp/smartcard/clrc633"
+	_ "github.com/dumacp/smartcard/multiiso"
+	_ "github.com/dumacp/smartcard/pcsc"
+	_ "github.com/dumacp/smartcard/rcr3300"
+	"github.com/dumacp/smartcard/remote"
+)
+
+var readerURI string
+var listen string
+var secret string
+
+func init() {
+	flag.StringVar(&readerURI, "reader", "pcsc://", "URI of the local reader")
+	flag.StringVar(&listen, "listen", "tcp://0.0.0.0:4343", "address to listen")
+	flag.StringVar(&secret, "secret", "", "shared secret with the clients")
+}
+
+func main() {
+	flag.Parse()
+	if secret == "" {
+		log.Fatal("secret is required")
+	}
+	reader, err := smartcard.Open(readerURI)
+	if err != nil {
+		log.Fatalf("open reader %q err = %s", readerURI, err)
+	}
+
+	network, address, ok := strings.Cut(listen, "://")
+	if !ok {
+		log.Fatalf("listen address %q without network", listen)
+	}
+	if network == "unix" {
+		os.Remove(address)
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		log.Fatalf("listen %q err = %s", listen, err)
+	}
+	defer l.Close()
+
+	log.Printf("serving reader %q on %s", readerURI, listen)
+	if err := remote.NewServer(reader, []byte(secret)).Serve(l); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,26 @@
+package remote
+
+import (
+	"github.com/dumacp/smartcard"
+)
+
+func init() {
+	smartcard.Register("remote", func(u *smartcard.URI) (smartcard.IReader, error) {
+		return openURI("tcp", u)
+	})
+	smartcard.Register("remote+unix", func(u *smartcard.URI) (smartcard.IReader, error) {
+		return openURI("unix", u)
+	})
+}
+
+// openURI open the remote reader of remote://host:port?secret=xxx&timeout=10s
+// or remote+unix:///run/smartcard.sock?secret=xxx
+func openURI(network string, u *smartcard.URI) (smartcard.IReader, error) {
+	timeout, err := u.Duration("timeout", DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(network, u.Device, []byte(u.Option("secret", "")))
+	r.Timeout = timeout
+	return r, nil
+}
@@ -0,0 +1,184 @@
+/*
+package to use a smartcard.IReader attached to another host. Server exposes
+a local reader over any net.Listener (TCP, Unix socket, TLS) and Reader is
+the IReader of the client.
+
+Every card connected by the client is a session over its own connection:
+
+	server -> client: challenge (version, nonce)
+	client -> server: auth (HMAC-SHA256 of the nonce with the shared secret)
+	server -> client: ok | error
+	client -> server: connect (card, SAM T=1, SAM T=0, SAM T=any)
+	client -> server: apdu, apdu timed, atr, uid, ats, sak, getdata ...
+	client -> server: disconnect (leave, reset, unpower, eject)
+
+The reader is owned by the session from connect to disconnect (or to the
+close of the connection), the connect of other sessions fails with ErrBusy.
+
+The response of apdu timed is the round trip time of the exchange in the
+server (8 bytes, nanoseconds, big endian) || response, so the time of the
+network isn't measured.
+
+The frames are: op (1 byte), length (4 bytes, big endian), payload. The
+secret isn't sent, but the APDUs are in clear text; use a TLS listener and
+Reader.Dial over untrusted networks.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package remote
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dumacp/smartcard"
+)
+
+// ErrBusy the reader is owned by another session
+var ErrBusy = errors.New("remote reader busy")
+
+// ErrAuth the authentication with the server failed
+var ErrAuth = errors.New("remote authentication failed")
+
+// ErrProtocol the peer sent an unexpected or malformed frame
+var ErrProtocol = errors.New("remote protocol error")
+
+// version version of the protocol sent in the challenge
+const version byte = 0x01
+
+// nonceLength length of the nonce of the challenge
+const nonceLength = 32
+
+// maxFrameLength max length of the payload of a frame (extended APDU + SW)
+const maxFrameLength = 1 << 17
+
+// ops of the frames
+const (
+	opChallenge byte = 0x01
+	opAuth      byte = 0x02
+
+	opConnect        byte = 0x10
+	opApdu           byte = 0x11
+	opATR            byte = 0x12
+	opUID            byte = 0x13
+	opATS            byte = 0x14
+	opSAK            byte = 0x15
+	opGetData        byte = 0x16
+	opDisconnect     byte = 0x17
+	opEndTransaction byte = 0x18
+	opApduTimed      byte = 0x19
+	opOK             byte = 0x80
+	opError          byte = 0x81
+)
+
+// payload of opConnect
+const (
+	connectCard    byte = 0x00
+	connectSam     byte = 0x01
+	connectSamT0   byte = 0x02
+	connectSamTany byte = 0x03
+)
+
+// payload of opDisconnect
+const (
+	disconnectLeave   byte = 0x00
+	disconnectReset   byte = 0x01
+	disconnectUnpower byte = 0x02
+	disconnectEject   byte = 0x03
+)
+
+// codes of the errors sent in the opError frames
+const (
+	codeComm byte = iota + 1
+	codeNoSmartcard
+	codeTransmit
+	codeSharingViolation
+	codeSecurity
+	codeBusy
+	codeAuth
+)
+
+func writeFrame(w io.Writer, op byte, payload []byte) error {
+	if len(payload) > maxFrameLength {
+		return fmt.Errorf("frame length %d, %w", len(payload), ErrProtocol)
+	}
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = op
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+	_, err := w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxFrameLength {
+		return 0, nil, fmt.Errorf("frame length %d, %w", length, ErrProtocol)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// mac response of the client to the challenge
+func mac(secret, nonce []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(nonce)
+	return h.Sum(nil)
+}
+
+// encodeError payload of the opError frame
+func encodeError(err error) []byte {
+	code := codeComm
+	switch {
+	case errors.Is(err, ErrBusy):
+		code = codeBusy
+	case errors.Is(err, ErrAuth):
+		code = codeAuth
+	case errors.Is(err, smartcard.ErrNoSmartcard):
+		code = codeNoSmartcard
+	case errors.Is(err, smartcard.ErrTransmit):
+		code = codeTransmit
+	case errors.Is(err, smartcard.ErrSharingViolation):
+		code = codeSharingViolation
+	case errors.Is(err, smartcard.ErrSecurity):
+		code = codeSecurity
+	}
+	return append([]byte{code}, err.Error()...)
+}
+
+// decodeError error of the payload of the opError frame, it wraps the
+// sentinel of the code
+func decodeError(payload []byte) error {
+	if len(payload) <= 0 {
+		return fmt.Errorf("empty error, %w", ErrProtocol)
+	}
+	msg := string(payload[1:])
+	switch payload[0] {
+	case codeBusy:
+		return fmt.Errorf("remote: %s, %w, %w", msg, ErrBusy, smartcard.ErrSharingViolation)
+	case codeAuth:
+		return fmt.Errorf("remote: %s, %w", msg, ErrAuth)
+	case codeNoSmartcard:
+		return fmt.Errorf("remote: %s, %w", msg, smartcard.ErrNoSmartcard)
+	case codeTransmit:
+		return fmt.Errorf("remote: %s, %w", msg, smartcard.ErrTransmit)
+	case codeSharingViolation:
+		return fmt.Errorf("remote: %s, %w", msg, smartcard.ErrSharingViolation)
+	case codeSecurity:
+		return fmt.Errorf("remote: %s, %w", msg, smartcard.ErrSecurity)
+	}
+	return fmt.Errorf("remote: %s, %w", msg, smartcard.ErrComm)
+}
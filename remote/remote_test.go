@@ -0,0 +1,172 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/sim"
+)
+
+var secret = []byte("depot-secret")
+
+// serve start a server of reader in a local socket, return the address
+func serve(t *testing.T, reader smartcard.IReader) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go NewServer(reader, secret).Serve(l)
+	return l.Addr().String()
+}
+
+func TestReader_Card(t *testing.T) {
+	uid := []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+	card := sim.NewCard([]byte{0x3B, 0x81, 0x80, 0x01, 0x80, 0x80}, uid, nil, 0x20)
+	card.Expect([]byte{0x90, 0x60, 0x00, 0x00, 0x00}, []byte{0x04, 0x01, 0x91, 0xAF})
+	local := sim.NewReader("local")
+	local.Insert(card)
+
+	r := NewReader("tcp", serve(t, local), secret)
+	c, err := r.ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	if got, err := c.UID(); err != nil || !bytes.Equal(got, uid) {
+		t.Errorf("UID() = [% X], %v, want [% X]", got, err, uid)
+	}
+	if got := c.SAK(); got != 0x20 {
+		t.Errorf("SAK() = %02X, want 20", got)
+	}
+	got, err := c.Apdu([]byte{0x90, 0x60, 0x00, 0x00, 0x00})
+	if err != nil || !bytes.Equal(got, []byte{0x04, 0x01, 0x91, 0xAF}) {
+		t.Errorf("Apdu() = [% X], %v", got, err)
+	}
+	if _, err := c.Apdu([]byte{0x90, 0xAF, 0x00, 0x00, 0x00}); !errors.Is(err, smartcard.ErrComm) {
+		t.Errorf("Apdu() error = %v, want %v", err, smartcard.ErrComm)
+	}
+	if err := c.DisconnectResetCard(); err != nil {
+		t.Fatalf("DisconnectResetCard() error = %v", err)
+	}
+	if card.Disposition() != sim.ResetCard {
+		t.Errorf("Disposition() = %v, want %v", card.Disposition(), sim.ResetCard)
+	}
+}
+
+func TestReader_Connect(t *testing.T) {
+	local := sim.NewReader("local")
+	local.Insert(sim.NewCard(nil, []byte{0x01, 0x02, 0x03, 0x04}, nil, 0x08))
+	address := serve(t, local)
+
+	tests := []struct {
+		name    string
+		reader  *Reader
+		connect func(r *Reader) (smartcard.ICard, error)
+		wantErr error
+	}{
+		{
+			name:    "wrong secret",
+			reader:  NewReader("tcp", address, []byte("other")),
+			connect: (*Reader).ConnectCard,
+			wantErr: ErrAuth,
+		},
+		{
+			name:    "empty SAM slot",
+			reader:  NewReader("tcp", address, secret),
+			connect: (*Reader).ConnectSamCard,
+			wantErr: smartcard.ErrNoSmartcard,
+		},
+		{
+			name:    "server down",
+			reader:  NewReader("tcp", "127.0.0.1:1", secret),
+			connect: (*Reader).ConnectCard,
+			wantErr: smartcard.ErrComm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.connect(tt.reader)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Connect() = %v, error = %v, want %v", c, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReader_Exclusive(t *testing.T) {
+	local := sim.NewReader("local")
+	local.Insert(sim.NewCard(nil, []byte{0x01, 0x02, 0x03, 0x04}, nil, 0x08))
+	address := serve(t, local)
+
+	first, err := NewReader("tcp", address, secret).ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	if _, err := NewReader("tcp", address, secret).ConnectCard(); !errors.Is(err, ErrBusy) {
+		t.Fatalf("ConnectCard() error = %v, want %v", err, ErrBusy)
+	}
+	if err := first.DisconnectCard(); err != nil {
+		t.Fatalf("DisconnectCard() error = %v", err)
+	}
+	second, err := NewReader("tcp", address, secret).ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() after disconnect error = %v", err)
+	}
+	second.DisconnectCard()
+}
+
+func TestCard_ApduContext(t *testing.T) {
+	card := sim.NewCard(nil, []byte{0x01, 0x02, 0x03, 0x04}, nil, 0x20)
+	card.Handle([]byte{0x90}, func(apdu []byte) ([]byte, error) {
+		time.Sleep(200 * time.Millisecond)
+		return []byte{0x91, 0x00}, nil
+	})
+	local := sim.NewReader("local")
+	local.Insert(card)
+
+	c, err := NewReader("tcp", serve(t, local), secret).ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	defer c.DisconnectCard()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := smartcard.ApduContext(ctx, c, []byte{0x90, 0x60, 0x00, 0x00, 0x00}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ApduContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.UID(); !errors.Is(err, smartcard.ErrComm) {
+		t.Errorf("UID() after cancel error = %v, want %v", err, smartcard.ErrComm)
+	}
+}
+
+func TestCard_ApduTimed(t *testing.T) {
+	card := sim.NewCard(nil, []byte{0x01, 0x02, 0x03, 0x04}, nil, 0x20)
+	card.Handle([]byte{0x90}, func(apdu []byte) ([]byte, error) {
+		time.Sleep(30 * time.Millisecond)
+		return []byte{0x91, 0x00}, nil
+	})
+	local := sim.NewReader("local")
+	local.Insert(card)
+
+	c, err := NewReader("tcp", serve(t, local), secret).ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	defer c.DisconnectCard()
+	got, elapsed, err := smartcard.ApduTimed(c, []byte{0x90, 0xF2, 0x00, 0x00, 0x00})
+	if err != nil || !bytes.Equal(got, []byte{0x91, 0x00}) {
+		t.Fatalf("ApduTimed() = [% X], %v", got, err)
+	}
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("ApduTimed() elapsed = %s, want >= 30ms", elapsed)
+	}
+	if _, ok := c.(smartcard.ICardTimed); !ok {
+		t.Errorf("remote card is not a smartcard.ICardTimed")
+	}
+}
@@ -0,0 +1,207 @@
+package remote
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+// DefaultAuthTimeout time to the client to answer the challenge
+const DefaultAuthTimeout = 5 * time.Second
+
+// Server exposes a local reader to the remote clients
+type Server struct {
+	reader smartcard.IReader
+	secret []byte
+	// owner semaphore of the session that owns the reader
+	owner chan struct{}
+	// AuthTimeout time to the client to answer the challenge
+	AuthTimeout time.Duration
+}
+
+// NewServer create the server of reader, the clients must know secret
+func NewServer(reader smartcard.IReader, secret []byte) *Server {
+	return &Server{
+		reader:      reader,
+		secret:      secret,
+		owner:       make(chan struct{}, 1),
+		AuthTimeout: DefaultAuthTimeout,
+	}
+}
+
+// Serve accept the connections of l and serve each one in its own
+// goroutine. It returns the error of Accept (when l is closed).
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serve the session of conn until the client closes it
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+	if err := s.handshake(conn); err != nil {
+		return
+	}
+
+	sess := &session{server: s}
+	defer sess.release()
+	for {
+		op, payload, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		resp, err := sess.handle(op, payload)
+		if err != nil {
+			err = writeFrame(conn, opError, encodeError(err))
+		} else {
+			err = writeFrame(conn, opOK, resp)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handshake send the challenge and verify the response of the client
+func (s *Server) handshake(conn net.Conn) error {
+	if s.AuthTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.AuthTimeout))
+		defer conn.SetDeadline(time.Time{})
+	}
+	nonce := make([]byte, nonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := writeFrame(conn, opChallenge, append([]byte{version}, nonce...)); err != nil {
+		return err
+	}
+	op, payload, err := readFrame(conn)
+	if err != nil {
+		return err
+	}
+	if op != opAuth || !hmac.Equal(payload, mac(s.secret, nonce)) {
+		writeFrame(conn, opError, encodeError(ErrAuth))
+		return ErrAuth
+	}
+	return writeFrame(conn, opOK, nil)
+}
+
+// session state of a connection
+type session struct {
+	server *Server
+	card   smartcard.ICard
+}
+
+// release drop the card and the ownership of the reader
+func (s *session) release() {
+	if s.card == nil {
+		return
+	}
+	s.card.DisconnectCard()
+	s.card = nil
+	<-s.server.owner
+}
+
+func (s *session) handle(op byte, payload []byte) ([]byte, error) {
+	if op == opConnect {
+		return nil, s.connect(payload)
+	}
+	if s.card == nil {
+		return nil, fmt.Errorf("card not connected, %w", smartcard.ErrNoSmartcard)
+	}
+	switch op {
+	case opApdu:
+		return s.card.Apdu(payload)
+	case opApduTimed:
+		resp, elapsed, err := smartcard.ApduTimed(s.card, payload)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]byte, 8, 8+len(resp))
+		binary.BigEndian.PutUint64(result, uint64(elapsed))
+		return append(result, resp...), nil
+	case opATR:
+		return s.card.ATR()
+	case opUID:
+		return s.card.UID()
+	case opATS:
+		return s.card.ATS()
+	case opSAK:
+		return []byte{s.card.SAK()}, nil
+	case opGetData:
+		if len(payload) != 1 {
+			return nil, fmt.Errorf("getdata payload [% X], %w", payload, ErrProtocol)
+		}
+		return s.card.GetData(payload[0])
+	case opEndTransaction:
+		return nil, s.card.EndTransactionResetCard()
+	case opDisconnect:
+		return nil, s.disconnect(payload)
+	}
+	return nil, fmt.Errorf("unknown op %02X, %w", op, ErrProtocol)
+}
+
+func (s *session) connect(payload []byte) error {
+	if len(payload) != 1 {
+		return fmt.Errorf("connect payload [% X], %w", payload, ErrProtocol)
+	}
+	if s.card != nil {
+		return errors.New("card already connected")
+	}
+	select {
+	case s.server.owner <- struct{}{}:
+	default:
+		return ErrBusy
+	}
+
+	var card smartcard.ICard
+	var err error
+	reader := s.server.reader
+	switch payload[0] {
+	case connectCard:
+		card, err = reader.ConnectCard()
+	case connectSam:
+		card, err = reader.ConnectSamCard()
+	case connectSamT0:
+		card, err = reader.ConnectSamCard_T0()
+	case connectSamTany:
+		card, err = reader.ConnectSamCard_Tany()
+	default:
+		err = fmt.Errorf("connect kind %02X, %w", payload[0], ErrProtocol)
+	}
+	if err != nil {
+		<-s.server.owner
+		return err
+	}
+	s.card = card
+	return nil
+}
+
+func (s *session) disconnect(payload []byte) error {
+	if len(payload) != 1 {
+		return fmt.Errorf("disconnect payload [% X], %w", payload, ErrProtocol)
+	}
+	card := s.card
+	s.card = nil
+	defer func() { <-s.server.owner }()
+	switch payload[0] {
+	case disconnectReset:
+		return card.DisconnectResetCard()
+	case disconnectUnpower:
+		return card.DisconnectUnpowerCard()
+	case disconnectEject:
+		return card.DisconnectEjectCard()
+	}
+	return card.DisconnectCard()
+}
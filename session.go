@@ -0,0 +1,233 @@
+package smartcard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ICardExclusive Interface to cards with exclusive access to the reader:
+// PCSC transactions (SCardBeginTransaction) or the mutex of the serial
+// device shared by all the slots of the reader
+type ICardExclusive interface {
+	BeginExclusive() error
+	EndExclusive() error
+}
+
+// BeginExclusive start the exclusive access to card if it implements
+// ICardExclusive
+func BeginExclusive(card ICard) error {
+	if ex, ok := card.(ICardExclusive); ok {
+		return ex.BeginExclusive()
+	}
+	return nil
+}
+
+// EndExclusive end the exclusive access to card if it implements
+// ICardExclusive
+func EndExclusive(card ICard) error {
+	if ex, ok := card.(ICardExclusive); ok {
+		return ex.EndExclusive()
+	}
+	return nil
+}
+
+// Session wraps a card to be used by several goroutines. Every command of
+// the session is exclusive, and the sequences of commands that must not be
+// interleaved (an authenticated session of DESFire) run in a Transaction.
+type Session struct {
+	card ICard
+	mux  sync.Mutex
+}
+
+// NewSession create the session of card
+func NewSession(card ICard) *Session {
+	return &Session{
+		card: card,
+	}
+}
+
+// Begin start an exclusive transaction. The other commands of the session
+// wait until End.
+func (s *Session) Begin() (*Transaction, error) {
+	s.mux.Lock()
+	if err := BeginExclusive(s.card); err != nil {
+		s.mux.Unlock()
+		return nil, err
+	}
+	return &Transaction{
+		ICard:   s.card,
+		session: s,
+	}, nil
+}
+
+// Do run f in a transaction, the card of f is the Transaction
+func (s *Session) Do(f func(card ICard) error) error {
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.End()
+		return err
+	}
+	return tx.End()
+}
+
+// do run f in a transaction of one command
+func (s *Session) do(f func(card ICard) ([]byte, error)) ([]byte, error) {
+	tx, err := s.Begin()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f(tx.ICard)
+	if errEnd := tx.End(); err == nil && errEnd != nil {
+		return nil, errEnd
+	}
+	return resp, err
+}
+
+// Apdu send the command in its own transaction
+func (s *Session) Apdu(apdu []byte) ([]byte, error) {
+	return s.do(func(card ICard) ([]byte, error) {
+		return card.Apdu(apdu)
+	})
+}
+
+// ApduContext send the command with ctx in its own transaction
+func (s *Session) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	return s.do(func(card ICard) ([]byte, error) {
+		return ApduContext(ctx, card, apdu)
+	})
+}
+
+// ApduTimed send the command in its own transaction and return the round
+// trip time measured by the card
+func (s *Session) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	var elapsed time.Duration
+	resp, err := s.do(func(card ICard) ([]byte, error) {
+		var resp []byte
+		var err error
+		resp, elapsed, err = ApduTimed(card, apdu)
+		return resp, err
+	})
+	return resp, elapsed, err
+}
+
+// ATR get the ATR of the card
+func (s *Session) ATR() ([]byte, error) {
+	return s.do(ICard.ATR)
+}
+
+// UID get the UID of the card
+func (s *Session) UID() ([]byte, error) {
+	return s.do(ICard.UID)
+}
+
+// ATS get the ATS of the card
+func (s *Session) ATS() ([]byte, error) {
+	return s.do(ICard.ATS)
+}
+
+// GetData send the get data command with ins
+func (s *Session) GetData(ins byte) ([]byte, error) {
+	return s.do(func(card ICard) ([]byte, error) {
+		return card.GetData(ins)
+	})
+}
+
+// RedactNext forward the sensitive bytes to the card, after the running
+// transaction. The spans are for the next command of any goroutine: the
+// redaction of a command of a sequence is marked in its Transaction.
+func (s *Session) RedactNext(command, response []Span) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	RedactNext(s.card, command, response)
+}
+
+// SAK get the SAK of the card
+func (s *Session) SAK() byte {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.card.SAK()
+}
+
+func (s *Session) disconnect(f func(card ICard) error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return f(s.card)
+}
+
+// DisconnectCard disconnect the card, after the running transaction
+func (s *Session) DisconnectCard() error {
+	return s.disconnect(ICard.DisconnectCard)
+}
+
+// DisconnectResetCard disconnect the card with reset, after the running transaction
+func (s *Session) DisconnectResetCard() error {
+	return s.disconnect(ICard.DisconnectResetCard)
+}
+
+// DisconnectUnpowerCard disconnect the card with unpower, after the running transaction
+func (s *Session) DisconnectUnpowerCard() error {
+	return s.disconnect(ICard.DisconnectUnpowerCard)
+}
+
+// DisconnectEjectCard disconnect the card with eject, after the running transaction
+func (s *Session) DisconnectEjectCard() error {
+	return s.disconnect(ICard.DisconnectEjectCard)
+}
+
+// EndTransactionResetCard end the transaction of the card with reset
+func (s *Session) EndTransactionResetCard() error {
+	return s.disconnect(ICard.EndTransactionResetCard)
+}
+
+// Transaction exclusive access to the card of a Session, from Begin to End.
+// It is not safe for concurrent use.
+type Transaction struct {
+	ICard
+	session *Session
+	ended   bool
+}
+
+// Apdu send the command in the transaction
+func (t *Transaction) Apdu(apdu []byte) ([]byte, error) {
+	if t.ended {
+		return nil, fmt.Errorf("transaction ended, %w", ErrComm)
+	}
+	return t.ICard.Apdu(apdu)
+}
+
+// ApduContext send the command with ctx in the transaction
+func (t *Transaction) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	if t.ended {
+		return nil, fmt.Errorf("transaction ended, %w", ErrComm)
+	}
+	return ApduContext(ctx, t.ICard, apdu)
+}
+
+// ApduTimed send the command in the transaction and return the round trip
+// time measured by the card
+func (t *Transaction) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	if t.ended {
+		return nil, 0, fmt.Errorf("transaction ended, %w", ErrComm)
+	}
+	return ApduTimed(t.ICard, apdu)
+}
+
+// RedactNext forward the sensitive bytes to the card of the session
+func (t *Transaction) RedactNext(command, response []Span) {
+	RedactNext(t.ICard, command, response)
+}
+
+// End finish the transaction and release the card to the session
+func (t *Transaction) End() error {
+	if t.ended {
+		return nil
+	}
+	t.ended = true
+	defer t.session.mux.Unlock()
+	return EndExclusive(t.ICard)
+}
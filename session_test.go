@@ -0,0 +1,160 @@
+package smartcard
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// exclusiveCard card that logs the APDUs and verifies that the exclusive
+// accesses don't overlap
+type exclusiveCard struct {
+	ICard
+	mux     sync.Mutex
+	sent    []byte
+	active  int
+	overlap bool
+	begins  int
+}
+
+func (c *exclusiveCard) Apdu(apdu []byte) ([]byte, error) {
+	time.Sleep(time.Millisecond)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.sent = append(c.sent, apdu[0])
+	return []byte{0x90, 0x00}, nil
+}
+
+func (c *exclusiveCard) BeginExclusive() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.active++
+	c.begins++
+	if c.active > 1 {
+		c.overlap = true
+	}
+	return nil
+}
+
+func (c *exclusiveCard) EndExclusive() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.active--
+	return nil
+}
+
+func TestSession_Do(t *testing.T) {
+	card := &exclusiveCard{}
+	s := NewSession(card)
+
+	const sequence = 4
+	var wg sync.WaitGroup
+	for id := byte(1); id <= 3; id++ {
+		wg.Add(2)
+		go func(id byte) {
+			defer wg.Done()
+			err := s.Do(func(tx ICard) error {
+				for i := 0; i < sequence; i++ {
+					if _, err := tx.Apdu([]byte{id}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}(id)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Apdu([]byte{0xFF}); err != nil {
+				t.Errorf("Apdu() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if card.overlap || card.active != 0 || card.begins != 6 {
+		t.Errorf("exclusive accesses overlap = %v, active = %d, begins = %d", card.overlap, card.active, card.begins)
+	}
+	// the APDUs of a transaction are contiguous
+	for i := 0; i < len(card.sent); {
+		if card.sent[i] == 0xFF {
+			i++
+			continue
+		}
+		for j := 1; j < sequence; j++ {
+			if i+j >= len(card.sent) || card.sent[i+j] != card.sent[i] {
+				t.Fatalf("interleaved transaction: [% X]", card.sent)
+			}
+		}
+		i += sequence
+	}
+}
+
+func TestTransaction_End(t *testing.T) {
+	s := NewSession(&exclusiveCard{})
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := tx.End(); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+	if err := tx.End(); err != nil {
+		t.Fatalf("End() twice error = %v", err)
+	}
+	if _, err := tx.Apdu([]byte{0x01}); !errors.Is(err, ErrComm) {
+		t.Errorf("Apdu() after End error = %v, want %v", err, ErrComm)
+	}
+	if _, err := s.Apdu([]byte{0x01}); err != nil {
+		t.Errorf("Session.Apdu() after End error = %v", err)
+	}
+}
+
+// redactCard card that logs the APDUs and the redactions
+type redactCard struct {
+	exclusiveCard
+	redacted [][]Span
+}
+
+func (c *redactCard) RedactNext(command, response []Span) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.redacted = append(c.redacted, command)
+}
+
+func TestSession_RedactNext(t *testing.T) {
+	card := &redactCard{}
+	s := NewSession(card)
+
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RedactNext([]Span{{Offset: 2, Length: 2}}, nil)
+	}()
+	tx.RedactNext([]Span{{Offset: 1, Length: 1}}, nil)
+	if _, err := tx.Apdu([]byte{0x01, 0x02}); err != nil {
+		t.Fatalf("Apdu() error = %v", err)
+	}
+	select {
+	case <-done:
+		t.Fatalf("Session.RedactNext() in the running transaction")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if err := tx.End(); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+	<-done
+
+	want := [][]Span{{{Offset: 1, Length: 1}}, {{Offset: 2, Length: 2}}}
+	if !reflect.DeepEqual(card.redacted, want) {
+		t.Errorf("redactions = %v, want %v", card.redacted, want)
+	}
+}
@@ -0,0 +1,333 @@
+/*
+package with a programmable virtual smartcard and reader (implementation of
+smartcard.ICard and smartcard.IReader) to run the card packages without hardware.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package sim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dumacp/smartcard"
+)
+
+// Handler function to answer an APDU sent to the simulated card
+type Handler func(apdu []byte) ([]byte, error)
+
+// Exchange expected APDU command and response of a script
+type Exchange struct {
+	Command  []byte
+	Response []byte
+	Err      error
+}
+
+// Disposition action requested to the card on disconnect
+type Disposition int
+
+const (
+	NotDisconnected Disposition = iota
+	LeaveCard
+	ResetCard
+	UnpowerCard
+	EjectCard
+)
+
+// State connect state
+type State int
+
+const (
+	CONNECTED State = iota
+	DISCONNECTED
+	REMOVED
+)
+
+// ErrUnexpectedApdu the APDU doesn't match with the script or any handler
+var ErrUnexpectedApdu = errors.New("unexpected apdu")
+
+type prefixHandler struct {
+	prefix  []byte
+	handler Handler
+}
+
+// Card simulated card
+type Card struct {
+	mux         sync.Mutex
+	atr         []byte
+	uid         []byte
+	ats         []byte
+	sak         byte
+	state       State
+	disposition Disposition
+	errDisc     error
+	script      []Exchange
+	handlers    []prefixHandler
+	fallback    Handler
+	history     [][]byte
+}
+
+// NewCard create a new simulated card, already connected
+func NewCard(atr, uid, ats []byte, sak byte) *Card {
+	c := &Card{
+		atr:   copyBytes(atr),
+		uid:   copyBytes(uid),
+		ats:   copyBytes(ats),
+		sak:   sak,
+		state: CONNECTED,
+	}
+	return c
+}
+
+func copyBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	result := make([]byte, len(data))
+	copy(result, data)
+	return result
+}
+
+// Script append exchanges that the card must receive in order. While the
+// script has pending exchanges, the APDUs are verified against it before
+// any registered handler.
+func (c *Card) Script(exchanges ...Exchange) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, e := range exchanges {
+		c.script = append(c.script, Exchange{
+			Command:  copyBytes(e.Command),
+			Response: copyBytes(e.Response),
+			Err:      e.Err,
+		})
+	}
+}
+
+// Expect append an exchange to the script
+func (c *Card) Expect(command, response []byte) {
+	c.Script(Exchange{Command: command, Response: response})
+}
+
+// Pending return the number of exchanges not yet consumed in the script
+func (c *Card) Pending() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.script)
+}
+
+// Handle register a handler for the APDUs that start with prefix. When several
+// prefixes match, the longest wins.
+func (c *Card) Handle(prefix []byte, h Handler) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.handlers = append(c.handlers, prefixHandler{
+		prefix:  copyBytes(prefix),
+		handler: h,
+	})
+}
+
+// HandleDefault register the handler used when there is no script or prefix match
+func (c *Card) HandleDefault(h Handler) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.fallback = h
+}
+
+// History return the APDUs received by the card
+func (c *Card) History() [][]byte {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	result := make([][]byte, 0, len(c.history))
+	for _, v := range c.history {
+		result = append(result, copyBytes(v))
+	}
+	return result
+}
+
+// Disposition return the last action requested on disconnect
+func (c *Card) Disposition() Disposition {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.disposition
+}
+
+// State return the connect state
+func (c *Card) State() State {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.state
+}
+
+// SetDisconnectError set the error returned by the Disconnect* functions
+func (c *Card) SetDisconnectError(err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.errDisc = err
+}
+
+// Remove simulate the removal of the card from the field
+func (c *Card) Remove() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.state = REMOVED
+}
+
+// Reconnect put the card in connected state again
+func (c *Card) Reconnect() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.state = CONNECTED
+	c.disposition = NotDisconnected
+}
+
+// Apdu send the command to the script or the registered handlers
+func (c *Card) Apdu(apdu []byte) ([]byte, error) {
+	c.mux.Lock()
+	switch c.state {
+	case REMOVED:
+		c.mux.Unlock()
+		return nil, fmt.Errorf("card removed, %w", smartcard.ErrNoSmartcard)
+	case DISCONNECTED:
+		c.mux.Unlock()
+		return nil, fmt.Errorf("don't Connect to Card, %w", smartcard.ErrComm)
+	}
+	c.history = append(c.history, copyBytes(apdu))
+
+	if len(c.script) > 0 {
+		next := c.script[0]
+		c.script = c.script[1:]
+		c.mux.Unlock()
+		if !bytes.Equal(next.Command, apdu) {
+			return nil, fmt.Errorf("%w: [% X], expected: [% X]", ErrUnexpectedApdu, apdu, next.Command)
+		}
+		if next.Err != nil {
+			return nil, next.Err
+		}
+		return copyBytes(next.Response), nil
+	}
+
+	var handler Handler
+	lenPrefix := -1
+	for _, h := range c.handlers {
+		if len(h.prefix) > lenPrefix && bytes.HasPrefix(apdu, h.prefix) {
+			handler = h.handler
+			lenPrefix = len(h.prefix)
+		}
+	}
+	if handler == nil {
+		handler = c.fallback
+	}
+	c.mux.Unlock()
+
+	if handler == nil {
+		return nil, fmt.Errorf("%w: [% X]", ErrUnexpectedApdu, apdu)
+	}
+	resp, err := handler(copyBytes(apdu))
+	if err != nil {
+		return nil, err
+	}
+	return copyBytes(resp), nil
+}
+
+func (c *Card) connected() error {
+	switch c.state {
+	case REMOVED:
+		return fmt.Errorf("card removed, %w", smartcard.ErrNoSmartcard)
+	case DISCONNECTED:
+		return fmt.Errorf("don't Connect to Card, %w", smartcard.ErrComm)
+	}
+	return nil
+}
+
+// ATR Get ATR from Card
+func (c *Card) ATR() ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err := c.connected(); err != nil {
+		return nil, err
+	}
+	return copyBytes(c.atr), nil
+}
+
+// GetData GetData with param INS (0x00: UID, 0x01: ATS)
+func (c *Card) GetData(ins byte) ([]byte, error) {
+	switch ins {
+	case 0x00:
+		return c.UID()
+	case 0x01:
+		return c.ATS()
+	}
+	return nil, fmt.Errorf("GetData %02X not supported, %w", ins, smartcard.ErrComm)
+}
+
+// UID Get UID from Card
+func (c *Card) UID() ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err := c.connected(); err != nil {
+		return nil, err
+	}
+	return copyBytes(c.uid), nil
+}
+
+// ATS Get ATS from Card
+func (c *Card) ATS() ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err := c.connected(); err != nil {
+		return nil, err
+	}
+	return copyBytes(c.ats), nil
+}
+
+// SAK Get SAK from Card
+func (c *Card) SAK() byte {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err := c.connected(); err != nil {
+		return 0xFF
+	}
+	return c.sak
+}
+
+func (c *Card) disconnect(d Disposition) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.errDisc != nil {
+		return c.errDisc
+	}
+	c.disposition = d
+	if c.state != REMOVED {
+		c.state = DISCONNECTED
+	}
+	return nil
+}
+
+// DisconnectCard disconnect card with disposition type LeaveCard
+func (c *Card) DisconnectCard() error {
+	return c.disconnect(LeaveCard)
+}
+
+// DisconnectResetCard disconnect card with disposition type ResetCard
+func (c *Card) DisconnectResetCard() error {
+	return c.disconnect(ResetCard)
+}
+
+// DisconnectUnpowerCard disconnect card with disposition type UnpowerCard
+func (c *Card) DisconnectUnpowerCard() error {
+	return c.disconnect(UnpowerCard)
+}
+
+// DisconnectEjectCard disconnect card with disposition type EjectCard
+func (c *Card) DisconnectEjectCard() error {
+	return c.disconnect(EjectCard)
+}
+
+// EndTransactionResetCard end transaction with disposition type ResetCard
+func (c *Card) EndTransactionResetCard() error {
+	return c.disconnect(ResetCard)
+}
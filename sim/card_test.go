@@ -0,0 +1,114 @@
+package sim
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/nxp/mifare/desfire/ev2"
+)
+
+func TestCard_Script(t *testing.T) {
+	card := NewCard(nil, []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, nil, 0x20)
+	card.Expect([]byte{0x5A, 0x01, 0x00, 0x00}, []byte{0x00})
+	card.Expect([]byte{0x5A, 0x02, 0x00, 0x00}, []byte{0xA0})
+
+	d := ev2.NewDesfire(card)
+	if err := d.SelectApplication([]byte{0x01, 0x00, 0x00}, nil); err != nil {
+		t.Fatalf("SelectApplication() error = %v", err)
+	}
+	err := d.SelectApplication([]byte{0x02, 0x00, 0x00}, nil)
+	if err == nil {
+		t.Fatalf("SelectApplication() want error with response [A0]")
+	}
+	if card.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", card.Pending())
+	}
+
+	card.Expect([]byte{0x60}, []byte{0x00})
+	if _, err := card.Apdu([]byte{0x61}); !errors.Is(err, ErrUnexpectedApdu) {
+		t.Errorf("Apdu() error = %v, want %v", err, ErrUnexpectedApdu)
+	}
+}
+
+func TestCard_Handle(t *testing.T) {
+	card := NewCard(nil, nil, nil, 0x20)
+	card.Handle([]byte{0x90}, func(apdu []byte) ([]byte, error) {
+		return []byte{0x91, 0xAE}, nil
+	})
+	card.Handle([]byte{0x90, 0x60}, func(apdu []byte) ([]byte, error) {
+		return []byte{0x04, 0x01, 0x91, 0x00}, nil
+	})
+
+	tests := []struct {
+		name    string
+		apdu    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "longest prefix",
+			apdu: []byte{0x90, 0x60, 0x00, 0x00, 0x00},
+			want: []byte{0x04, 0x01, 0x91, 0x00},
+		},
+		{
+			name: "short prefix",
+			apdu: []byte{0x90, 0xAA, 0x00, 0x00, 0x00},
+			want: []byte{0x91, 0xAE},
+		},
+		{
+			name:    "without handler",
+			apdu:    []byte{0x00, 0xA4, 0x04, 0x00},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := card.Apdu(tt.apdu)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apdu() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apdu() = [% X], want [% X]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReader_Connect(t *testing.T) {
+	reader := NewReader("sim PICC")
+	if _, err := reader.ConnectCard(); !errors.Is(err, smartcard.ErrNoSmartcard) {
+		t.Fatalf("ConnectCard() error = %v, want %v", err, smartcard.ErrNoSmartcard)
+	}
+
+	card := NewCard(nil, []byte{1, 2, 3, 4}, nil, 0x08)
+	card.HandleDefault(func(apdu []byte) ([]byte, error) {
+		return []byte{0x90, 0x00}, nil
+	})
+	reader.Insert(card)
+
+	c, err := reader.ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	if err := c.DisconnectResetCard(); err != nil {
+		t.Fatalf("DisconnectResetCard() error = %v", err)
+	}
+	if card.Disposition() != ResetCard {
+		t.Errorf("Disposition() = %v, want %v", card.Disposition(), ResetCard)
+	}
+	if _, err := c.Apdu([]byte{0x00}); !errors.Is(err, smartcard.ErrComm) {
+		t.Errorf("Apdu() error = %v, want %v", err, smartcard.ErrComm)
+	}
+
+	c, err = reader.ConnectCard()
+	if err != nil {
+		t.Fatalf("ConnectCard() error = %v", err)
+	}
+	reader.Remove()
+	if _, err := c.Apdu([]byte{0x00}); !errors.Is(err, smartcard.ErrNoSmartcard) {
+		t.Errorf("Apdu() error = %v, want %v", err, smartcard.ErrNoSmartcard)
+	}
+}
@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dumacp/smartcard"
+)
+
+// Reader simulated reader with a contactless slot and a SAM slot
+type Reader struct {
+	mux        sync.Mutex
+	readerName string
+	card       *Card
+	sam        *Card
+}
+
+// NewReader Create New simulated Reader
+func NewReader(readerName string) *Reader {
+	r := &Reader{
+		readerName: readerName,
+	}
+	return r
+}
+
+// Name reader name
+func (r *Reader) Name() string {
+	return r.readerName
+}
+
+// Insert put the card in the contactless field of the reader
+func (r *Reader) Insert(c *Card) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.card = c
+}
+
+// InsertSam put the card in the SAM slot of the reader
+func (r *Reader) InsertSam(c *Card) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.sam = c
+}
+
+// Remove remove the card from the contactless field
+func (r *Reader) Remove() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.card != nil {
+		r.card.Remove()
+	}
+	r.card = nil
+}
+
+// RemoveSam remove the card from the SAM slot
+func (r *Reader) RemoveSam() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.sam != nil {
+		r.sam.Remove()
+	}
+	r.sam = nil
+}
+
+func connect(c *Card) (smartcard.ICard, error) {
+	if c == nil {
+		return nil, fmt.Errorf("connect card err = empty slot, %w", smartcard.ErrNoSmartcard)
+	}
+	c.Reconnect()
+	return c, nil
+}
+
+// ConnectCard connect the card in the contactless field
+func (r *Reader) ConnectCard() (smartcard.ICard, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return connect(r.card)
+}
+
+// ConnectSamCard connect the card in the SAM slot
+func (r *Reader) ConnectSamCard() (smartcard.ICard, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return connect(r.sam)
+}
+
+// ConnectSamCard_T0 connect the card in the SAM slot
+func (r *Reader) ConnectSamCard_T0() (smartcard.ICard, error) {
+	return r.ConnectSamCard()
+}
+
+// ConnectSamCard_Tany connect the card in the SAM slot
+func (r *Reader) ConnectSamCard_Tany() (smartcard.ICard, error) {
+	return r.ConnectSamCard()
+}
@@ -0,0 +1,200 @@
+package sim
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/dumacp/smartcard"
+)
+
+// EventType type of recorded event
+type EventType string
+
+const (
+	EventATR        EventType = "atr"
+	EventUID        EventType = "uid"
+	EventATS        EventType = "ats"
+	EventSAK        EventType = "sak"
+	EventGetData    EventType = "getdata"
+	EventAPDU       EventType = "apdu"
+	EventDisconnect EventType = "disconnect"
+)
+
+// Event line of a recorded session (JSON lines format, bytes in hex)
+type Event struct {
+	Type     EventType `json:"type"`
+	Command  string    `json:"command,omitempty"`
+	Response string    `json:"response,omitempty"`
+	Err      string    `json:"error,omitempty"`
+	// Codes codes of the sentinels wrapped by Err (errCodes), the replay
+	// rebuilds the error with them
+	Codes []string `json:"codes,omitempty"`
+}
+
+// errCodes sentinels of the recorded errors by code
+var errCodes = []struct {
+	code string
+	err  error
+}{
+	{"comm", smartcard.ErrComm},
+	{"security", smartcard.ErrSecurity},
+	{"no_smartcard", smartcard.ErrNoSmartcard},
+	{"transmit", smartcard.ErrTransmit},
+	{"sharing_violation", smartcard.ErrSharingViolation},
+	{"canceled", context.Canceled},
+	{"deadline_exceeded", context.DeadlineExceeded},
+}
+
+// codeError sentinel of the code, nil if the code is unknown
+func codeError(code string) error {
+	for _, v := range errCodes {
+		if v.code == code {
+			return v.err
+		}
+	}
+	return nil
+}
+
+// errorCodes codes of the sentinels wrapped by err
+func errorCodes(err error) []string {
+	var codes []string
+	for _, v := range errCodes {
+		if errors.Is(err, v.err) {
+			codes = append(codes, v.code)
+		}
+	}
+	return codes
+}
+
+// Recorder card decorator that writes every exchange with the wrapped card
+type Recorder struct {
+	smartcard.ICard
+	mux sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder create a recorder of the session with card, the events are
+// written in w as JSON lines. The ATR and the UID of the card are recorded
+// at the start of the session.
+func NewRecorder(card smartcard.ICard, w io.Writer) *Recorder {
+	r := &Recorder{
+		ICard: card,
+		enc:   json.NewEncoder(w),
+	}
+	r.ATR()
+	r.UID()
+	return r
+}
+
+// Err return the first error writing the events
+func (r *Recorder) Err() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(typ EventType, command, response []byte, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	ev := Event{
+		Type:     typ,
+		Command:  hex.EncodeToString(command),
+		Response: hex.EncodeToString(response),
+	}
+	if err != nil {
+		ev.Err = err.Error()
+		ev.Codes = errorCodes(err)
+	}
+	if errW := r.enc.Encode(&ev); errW != nil && r.err == nil {
+		r.err = errW
+	}
+}
+
+// Apdu send the command to the card and record the exchange
+func (r *Recorder) Apdu(apdu []byte) ([]byte, error) {
+	resp, err := r.ICard.Apdu(apdu)
+	r.write(EventAPDU, apdu, resp, err)
+	return resp, err
+}
+
+// ApduContext send the command to the card with ctx and record the exchange
+func (r *Recorder) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	resp, err := smartcard.ApduContext(ctx, r.ICard, apdu)
+	r.write(EventAPDU, apdu, resp, err)
+	return resp, err
+}
+
+// ATR get ATR from the card and record it
+func (r *Recorder) ATR() ([]byte, error) {
+	resp, err := r.ICard.ATR()
+	r.write(EventATR, nil, resp, err)
+	return resp, err
+}
+
+// UID get UID from the card and record it
+func (r *Recorder) UID() ([]byte, error) {
+	resp, err := r.ICard.UID()
+	r.write(EventUID, nil, resp, err)
+	return resp, err
+}
+
+// ATS get ATS from the card and record it
+func (r *Recorder) ATS() ([]byte, error) {
+	resp, err := r.ICard.ATS()
+	r.write(EventATS, nil, resp, err)
+	return resp, err
+}
+
+// GetData GetData from the card and record it
+func (r *Recorder) GetData(ins byte) ([]byte, error) {
+	resp, err := r.ICard.GetData(ins)
+	r.write(EventGetData, []byte{ins}, resp, err)
+	return resp, err
+}
+
+// SAK get SAK from the card and record it
+func (r *Recorder) SAK() byte {
+	sak := r.ICard.SAK()
+	r.write(EventSAK, nil, []byte{sak}, nil)
+	return sak
+}
+
+// DisconnectCard disconnect the card and record it
+func (r *Recorder) DisconnectCard() error {
+	err := r.ICard.DisconnectCard()
+	r.write(EventDisconnect, []byte{byte(LeaveCard)}, nil, err)
+	return err
+}
+
+// DisconnectResetCard disconnect the card and record it
+func (r *Recorder) DisconnectResetCard() error {
+	err := r.ICard.DisconnectResetCard()
+	r.write(EventDisconnect, []byte{byte(ResetCard)}, nil, err)
+	return err
+}
+
+// DisconnectUnpowerCard disconnect the card and record it
+func (r *Recorder) DisconnectUnpowerCard() error {
+	err := r.ICard.DisconnectUnpowerCard()
+	r.write(EventDisconnect, []byte{byte(UnpowerCard)}, nil, err)
+	return err
+}
+
+// DisconnectEjectCard disconnect the card and record it
+func (r *Recorder) DisconnectEjectCard() error {
+	err := r.ICard.DisconnectEjectCard()
+	r.write(EventDisconnect, []byte{byte(EjectCard)}, nil, err)
+	return err
+}
+
+// EndTransactionResetCard end the transaction with reset and record it
+func (r *Recorder) EndTransactionResetCard() error {
+	err := r.ICard.EndTransactionResetCard()
+	r.write(EventDisconnect, []byte{byte(ResetCard)}, nil, err)
+	return err
+}
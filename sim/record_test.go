@@ -0,0 +1,179 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/nxp/mifare/desfire/ev2"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	uid := []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+	card := NewCard([]byte{0x3B, 0x81, 0x80, 0x01, 0x80, 0x80}, uid, nil, 0x20)
+	card.Expect([]byte{0x5A, 0x01, 0x00, 0x00}, []byte{0x00})
+	card.Expect([]byte{0x6D}, []byte{0x00, 0x00, 0x10, 0x00})
+
+	buf := new(bytes.Buffer)
+	rec := NewRecorder(card, buf)
+	if _, err := rec.UID(); err != nil {
+		t.Fatalf("UID() error = %v", err)
+	}
+	if _, err := rec.ATR(); err != nil {
+		t.Fatalf("ATR() error = %v", err)
+	}
+	d := ev2.NewDesfire(rec)
+	if err := d.SelectApplication([]byte{0x01, 0x00, 0x00}, nil); err != nil {
+		t.Fatalf("SelectApplication() error = %v", err)
+	}
+	if _, err := rec.Apdu([]byte{0x6D}); err != nil {
+		t.Fatalf("Apdu() error = %v", err)
+	}
+	if err := rec.DisconnectCard(); err != nil {
+		t.Fatalf("DisconnectCard() error = %v", err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	data := buf.Bytes()
+
+	replay, err := NewReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReplay() error = %v", err)
+	}
+	if got, _ := replay.UID(); !reflect.DeepEqual(got, uid) {
+		t.Errorf("UID() = [% X], want [% X]", got, uid)
+	}
+	d = ev2.NewDesfire(replay)
+	if err := d.SelectApplication([]byte{0x01, 0x00, 0x00}, nil); err != nil {
+		t.Fatalf("SelectApplication() error = %v", err)
+	}
+	got, err := replay.Apdu([]byte{0x6D})
+	if err != nil {
+		t.Fatalf("Apdu() error = %v", err)
+	}
+	if want := []byte{0x00, 0x00, 0x10, 0x00}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Apdu() = [% X], want [% X]", got, want)
+	}
+	if replay.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", replay.Pending())
+	}
+
+	replay, err = NewReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReplay() error = %v", err)
+	}
+	if _, err := replay.Apdu([]byte{0x5A, 0x02, 0x00, 0x00}); !errors.Is(err, ErrDivergence) {
+		t.Fatalf("Apdu() error = %v, want %v", err, ErrDivergence)
+	}
+	if _, err := replay.Apdu([]byte{0x5A, 0x01, 0x00, 0x00}); !errors.Is(err, ErrDivergence) {
+		t.Errorf("Apdu() after divergence error = %v, want %v", err, ErrDivergence)
+	}
+}
+
+func TestRecorder_ReplayErrors(t *testing.T) {
+	card := NewCard(nil, nil, nil, 0x20)
+	card.Script(Exchange{
+		Command: []byte{0x60},
+		Err:     fmt.Errorf("apdu canceled, %w, %w", context.DeadlineExceeded, smartcard.ErrComm),
+	})
+
+	buf := new(bytes.Buffer)
+	rec := NewRecorder(card, buf)
+	_, errTimeout := rec.Apdu([]byte{0x60})
+	card.Remove()
+	_, errRemoved := rec.Apdu([]byte{0x6D})
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	replay, err := NewReplay(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReplay() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		apdu     []byte
+		recorded error
+		want     []error
+	}{
+		{
+			name:     "timeout",
+			apdu:     []byte{0x60},
+			recorded: errTimeout,
+			want:     []error{context.DeadlineExceeded, smartcard.ErrComm},
+		},
+		{
+			name:     "card removed",
+			apdu:     []byte{0x6D},
+			recorded: errRemoved,
+			want:     []error{smartcard.ErrNoSmartcard},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := replay.Apdu(tt.apdu)
+			if err == nil {
+				t.Fatalf("Apdu() error = nil, want %v", tt.recorded)
+			}
+			if err.Error() != tt.recorded.Error() {
+				t.Errorf("Apdu() error = %v, want %v", err, tt.recorded)
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("Apdu() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := NewReplay(strings.NewReader(`{"type":"apdu","command":"60","error":"x","codes":["bad"]}`)); err == nil {
+		t.Errorf("NewReplay() with unknown code error = nil")
+	}
+}
+
+func TestRecorder_Session(t *testing.T) {
+	atr := []byte{0x3B, 0x81, 0x80, 0x01, 0x80, 0x80}
+	uid := []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+	card := NewCard(atr, uid, nil, 0x20)
+	card.Expect([]byte{0x60}, []byte{0x00})
+
+	buf := new(bytes.Buffer)
+	rec := NewRecorder(card, buf)
+	if _, err := rec.Apdu([]byte{0x60}); err != nil {
+		t.Fatalf("Apdu() error = %v", err)
+	}
+	if err := rec.EndTransactionResetCard(); err != nil {
+		t.Fatalf("EndTransactionResetCard() error = %v", err)
+	}
+	if card.Disposition() != ResetCard {
+		t.Errorf("Disposition() = %v, want %v", card.Disposition(), ResetCard)
+	}
+
+	want := []string{
+		`{"type":"atr","response":"3b8180018080"}`,
+		`{"type":"uid","response":"04112233445566"}`,
+		`{"type":"apdu","command":"60","response":"00"}`,
+		`{"type":"disconnect","command":"02"}`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	replay, err := NewReplay(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReplay() error = %v", err)
+	}
+	if got, err := replay.ATR(); err != nil || !reflect.DeepEqual(got, atr) {
+		t.Errorf("ATR() = [% X], %v, want [% X]", got, err, atr)
+	}
+	if got, err := replay.UID(); err != nil || !reflect.DeepEqual(got, uid) {
+		t.Errorf("UID() = [% X], %v, want [% X]", got, err, uid)
+	}
+}
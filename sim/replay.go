@@ -0,0 +1,260 @@
+package sim
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dumacp/smartcard"
+)
+
+// ErrDivergence the APDU sent doesn't match with the recorded session
+var ErrDivergence = errors.New("replay divergence")
+
+type recorded struct {
+	command  []byte
+	response []byte
+	err      error
+}
+
+// Replay card that plays a recorded session. The APDUs must be sent in the
+// same order of the record, ATR, UID, ATS, SAK and GetData answer with the
+// recorded values.
+type Replay struct {
+	mux      sync.Mutex
+	apdus    []recorded
+	idx      int
+	values   map[EventType]recorded
+	getdata  map[byte]recorded
+	state    State
+	diverged error
+}
+
+// NewReplay create a replay card from a session recorded in JSON lines format
+func NewReplay(r io.Reader) (*Replay, error) {
+	c := &Replay{
+		values:  make(map[EventType]recorded),
+		getdata: make(map[byte]recorded),
+		state:   CONNECTED,
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		ev := new(Event)
+		if err := json.Unmarshal(data, ev); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rec, err := decodeEvent(ev)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch ev.Type {
+		case EventAPDU:
+			c.apdus = append(c.apdus, rec)
+		case EventATR, EventUID, EventATS, EventSAK:
+			c.values[ev.Type] = rec
+		case EventGetData:
+			if len(rec.command) != 1 {
+				return nil, fmt.Errorf("line %d: bad getdata param [% X]", line, rec.command)
+			}
+			c.getdata[rec.command[0]] = rec
+		case EventDisconnect:
+		default:
+			return nil, fmt.Errorf("line %d: unknown event type %q", line, ev.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadReplay create a replay card from a recorded session file
+func LoadReplay(filename string) (*Replay, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplay(f)
+}
+
+func decodeEvent(ev *Event) (recorded, error) {
+	rec := recorded{}
+	var err error
+	if rec.command, err = hex.DecodeString(ev.Command); err != nil {
+		return rec, err
+	}
+	if rec.response, err = hex.DecodeString(ev.Response); err != nil {
+		return rec, err
+	}
+	if len(ev.Err) > 0 {
+		replayErr := &ReplayError{Msg: ev.Err}
+		for _, code := range ev.Codes {
+			sentinel := codeError(code)
+			if sentinel == nil {
+				return rec, fmt.Errorf("unknown error code %q", code)
+			}
+			replayErr.Sentinels = append(replayErr.Sentinels, sentinel)
+		}
+		rec.err = replayErr
+	}
+	return rec, nil
+}
+
+// ReplayError recorded error of the session, with the message of the
+// original error and its sentinels (errors.Is works as in the session)
+type ReplayError struct {
+	Msg       string
+	Sentinels []error
+}
+
+func (e *ReplayError) Error() string {
+	return e.Msg
+}
+
+func (e *ReplayError) Unwrap() []error {
+	return e.Sentinels
+}
+
+// Pending return the number of recorded APDUs not yet played
+func (c *Replay) Pending() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.apdus) - c.idx
+}
+
+// Err return the first divergence found in the session
+func (c *Replay) Err() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.diverged
+}
+
+// Apdu answer the next recorded response if the APDU is the recorded command
+func (c *Replay) Apdu(apdu []byte) ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.state == DISCONNECTED {
+		return nil, fmt.Errorf("don't Connect to Card, %w", smartcard.ErrComm)
+	}
+	if c.diverged != nil {
+		return nil, c.diverged
+	}
+	if c.idx >= len(c.apdus) {
+		c.diverged = fmt.Errorf("%w: [% X], end of session", ErrDivergence, apdu)
+		return nil, c.diverged
+	}
+	next := c.apdus[c.idx]
+	if !bytes.Equal(next.command, apdu) {
+		c.diverged = fmt.Errorf("%w: apdu %d [% X], recorded: [% X]",
+			ErrDivergence, c.idx, apdu, next.command)
+		return nil, c.diverged
+	}
+	c.idx++
+	if next.err != nil {
+		return nil, next.err
+	}
+	return copyBytes(next.response), nil
+}
+
+func (c *Replay) value(typ EventType) ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	rec, ok := c.values[typ]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not recorded", ErrDivergence, typ)
+	}
+	if rec.err != nil {
+		return nil, rec.err
+	}
+	return copyBytes(rec.response), nil
+}
+
+// ATR recorded ATR
+func (c *Replay) ATR() ([]byte, error) {
+	return c.value(EventATR)
+}
+
+// UID recorded UID
+func (c *Replay) UID() ([]byte, error) {
+	return c.value(EventUID)
+}
+
+// ATS recorded ATS
+func (c *Replay) ATS() ([]byte, error) {
+	return c.value(EventATS)
+}
+
+// SAK recorded SAK
+func (c *Replay) SAK() byte {
+	resp, err := c.value(EventSAK)
+	if err != nil || len(resp) != 1 {
+		return 0xFF
+	}
+	return resp[0]
+}
+
+// GetData recorded GetData with param INS
+func (c *Replay) GetData(ins byte) ([]byte, error) {
+	c.mux.Lock()
+	rec, ok := c.getdata[ins]
+	c.mux.Unlock()
+	if !ok {
+		switch ins {
+		case 0x00:
+			return c.UID()
+		case 0x01:
+			return c.ATS()
+		}
+		return nil, fmt.Errorf("%w: getdata %02X not recorded", ErrDivergence, ins)
+	}
+	if rec.err != nil {
+		return nil, rec.err
+	}
+	return copyBytes(rec.response), nil
+}
+
+func (c *Replay) disconnect() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.state = DISCONNECTED
+	return nil
+}
+
+// DisconnectCard end the replay
+func (c *Replay) DisconnectCard() error {
+	return c.disconnect()
+}
+
+// DisconnectResetCard end the replay
+func (c *Replay) DisconnectResetCard() error {
+	return c.disconnect()
+}
+
+// DisconnectUnpowerCard end the replay
+func (c *Replay) DisconnectUnpowerCard() error {
+	return c.disconnect()
+}
+
+// DisconnectEjectCard end the replay
+func (c *Replay) DisconnectEjectCard() error {
+	return c.disconnect()
+}
+
+// EndTransactionResetCard end the replay
+func (c *Replay) EndTransactionResetCard() error {
+	return c.disconnect()
+}
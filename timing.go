@@ -0,0 +1,22 @@
+package smartcard
+
+import "time"
+
+// ICardTimed Interface to cards that measure the round trip time of the
+// commands in the transport, as close to the reader as possible (SCardTransmit,
+// the serial frame, the server of a remote reader)
+type ICardTimed interface {
+	ApduTimed(apdu []byte) ([]byte, time.Duration, error)
+}
+
+// ApduTimed send the command to the card and return the round trip time of
+// the exchange. If the card doesn't implement ICardTimed the time is measured
+// around Apdu, with the overhead of the host.
+func ApduTimed(card ICard, apdu []byte) ([]byte, time.Duration, error) {
+	if c, ok := card.(ICardTimed); ok {
+		return c.ApduTimed(apdu)
+	}
+	t0 := time.Now()
+	resp, err := card.Apdu(apdu)
+	return resp, time.Since(t0), err
+}
@@ -0,0 +1,94 @@
+package tlv
+
+import (
+	"fmt"
+)
+
+// Compact-TLV tags of the historical bytes (ISO 7816-4 8.1.1)
+const (
+	CompactCountryCode      byte = 0x1
+	CompactIssuerID         byte = 0x2
+	CompactCardService      byte = 0x3
+	CompactInitialAccess    byte = 0x4
+	CompactIssuerData       byte = 0x5
+	CompactPreIssuing       byte = 0x6
+	CompactCardCapabilities byte = 0x7
+	CompactStatusIndicator  byte = 0x8
+	CompactApplicationID    byte = 0xF
+)
+
+const (
+	maxCompactLength      = 0xF
+	statusIndicatorLength = 3
+	// category indicators of the historical bytes
+	categoryCompact           byte = 0x80
+	categoryCompactWithStatus byte = 0x00
+)
+
+// CompactTLV compact-TLV data object, the tag and the length are encoded in
+// one byte (tag in the high nibble)
+type CompactTLV struct {
+	Tag   byte
+	Value []byte
+}
+
+// Bytes encode the data object
+func (c CompactTLV) Bytes() ([]byte, error) {
+	if c.Tag > 0xF || len(c.Value) > maxCompactLength {
+		return nil, fmt.Errorf("compact tag %X length %d, %w", c.Tag, len(c.Value), ErrTLV)
+	}
+	data := []byte{c.Tag<<4 | byte(len(c.Value))}
+	return append(data, c.Value...), nil
+}
+
+// DecodeCompact decode the sequence of compact-TLV data objects in data
+func DecodeCompact(data []byte) ([]CompactTLV, error) {
+	list := make([]CompactTLV, 0)
+	for len(data) > 0 {
+		tag, length := data[0]>>4, int(data[0]&0x0F)
+		if length > len(data)-1 {
+			return nil, fmt.Errorf("compact tag %X length %d > %d, %w", tag, length, len(data)-1, ErrTLV)
+		}
+		list = append(list, CompactTLV{Tag: tag, Value: data[1 : 1+length]})
+		data = data[1+length:]
+	}
+	return list, nil
+}
+
+// EncodeCompact encode the sequence of compact-TLV data objects
+func EncodeCompact(list ...CompactTLV) ([]byte, error) {
+	data := make([]byte, 0)
+	for _, c := range list {
+		b, err := c.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// DecodeHistorical decode the historical bytes of the ATR with the category
+// indicator 80 (compact-TLV objects) or 00 (compact-TLV objects and the
+// status indicator in the last 3 bytes, returned as an object with tag
+// CompactStatusIndicator). Other categories are proprietary.
+func DecodeHistorical(historical []byte) ([]CompactTLV, error) {
+	if len(historical) <= 0 {
+		return nil, fmt.Errorf("empty historical bytes, %w", ErrTLV)
+	}
+	switch historical[0] {
+	case categoryCompact:
+		return DecodeCompact(historical[1:])
+	case categoryCompactWithStatus:
+		if len(historical) < 1+statusIndicatorLength {
+			return nil, fmt.Errorf("historical bytes without status indicator, %w", ErrTLV)
+		}
+		end := len(historical) - statusIndicatorLength
+		list, err := DecodeCompact(historical[1:end])
+		if err != nil {
+			return nil, err
+		}
+		return append(list, CompactTLV{Tag: CompactStatusIndicator, Value: historical[end:]}), nil
+	}
+	return nil, fmt.Errorf("proprietary category indicator %02X, %w", historical[0], ErrTLV)
+}
@@ -0,0 +1,241 @@
+/*
+package to decode and encode the BER-TLV data objects of ISO 7816-4 (FCI
+templates, ISO responses, PC/SC data objects) and the compact-TLV data
+objects of the historical bytes of the ATR.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package tlv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTLV the encoding of the data objects is wrong
+var ErrTLV = errors.New("bad TLV encoding")
+
+// maxTagLength max number of bytes of a tag
+const maxTagLength = 4
+
+// maxLengthBytes max number of subsequent bytes of a long length (0x84 xx xx xx xx)
+const maxLengthBytes = 4
+
+// Tag BER-TLV tag, the bytes of the tag as a big endian integer (0x9F02)
+type Tag uint32
+
+// Bytes encoding of the tag
+func (t Tag) Bytes() []byte {
+	data := make([]byte, 0, maxTagLength)
+	for shift := 24; shift > 0; shift -= 8 {
+		if b := byte(t >> shift); b != 0 || len(data) > 0 {
+			data = append(data, b)
+		}
+	}
+	return append(data, byte(t))
+}
+
+// first first byte of the tag
+func (t Tag) first() byte {
+	data := t.Bytes()
+	return data[0]
+}
+
+// Constructed the value of the tag is a list of data objects
+func (t Tag) Constructed() bool {
+	return t.first()&0x20 != 0
+}
+
+// Class class of the tag (0x00 universal, 0x40 application,
+// 0x80 context-specific, 0xC0 private)
+func (t Tag) Class() byte {
+	return t.first() & 0xC0
+}
+
+func (t Tag) String() string {
+	return fmt.Sprintf("%X", t.Bytes())
+}
+
+// TLV BER-TLV data object. The value of a constructed object is decoded in
+// Children. To encode a constructed object, Value is ignored if Children is
+// not nil.
+type TLV struct {
+	Tag      Tag
+	Value    []byte
+	Children List
+}
+
+// New create a primitive data object
+func New(tag Tag, value []byte) *TLV {
+	return &TLV{
+		Tag:   tag,
+		Value: value,
+	}
+}
+
+// NewConstructed create a constructed data object with children
+func NewConstructed(tag Tag, children ...*TLV) *TLV {
+	if children == nil {
+		children = List{}
+	}
+	return &TLV{
+		Tag:      tag,
+		Children: children,
+	}
+}
+
+// Bytes encode the data object
+func (t *TLV) Bytes() []byte {
+	value := t.Value
+	if t.Children != nil {
+		value = t.Children.Bytes()
+	}
+	data := append(t.Tag.Bytes(), EncodeLength(len(value))...)
+	return append(data, value...)
+}
+
+// Find first object with tag in the object and its children (depth first)
+func (t *TLV) Find(tag Tag) *TLV {
+	if t.Tag == tag {
+		return t
+	}
+	return t.Children.Find(tag)
+}
+
+// List sequence of data objects
+type List []*TLV
+
+// Bytes encode the sequence of data objects
+func (l List) Bytes() []byte {
+	data := make([]byte, 0)
+	for _, t := range l {
+		data = append(data, t.Bytes()...)
+	}
+	return data
+}
+
+// Find first object with tag in the list and the children (depth first)
+func (l List) Find(tag Tag) *TLV {
+	for _, t := range l {
+		if found := t.Find(tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Value value of the first object with tag (nil if it isn't found)
+func (l List) Value(tag Tag) []byte {
+	if t := l.Find(tag); t != nil {
+		return t.Value
+	}
+	return nil
+}
+
+// Decode decode the sequence of data objects in data. The constructed
+// objects are decoded recursively. The padding bytes 00 and FF between the
+// objects are skipped.
+func Decode(data []byte) (List, error) {
+	list := List{}
+	for len(data) > 0 {
+		if data[0] == 0x00 || data[0] == 0xFF {
+			data = data[1:]
+			continue
+		}
+		t, rest, err := Next(data)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+		data = rest
+	}
+	return list, nil
+}
+
+// Next decode the first data object of data and return the bytes after it
+func Next(data []byte) (*TLV, []byte, error) {
+	tag, i, err := decodeTag(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	length, n, err := DecodeLength(data[i:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("tag %s: %w", tag, err)
+	}
+	i += n
+	if length > len(data)-i {
+		return nil, nil, fmt.Errorf("tag %s length %d > %d, %w", tag, length, len(data)-i, ErrTLV)
+	}
+	t := &TLV{
+		Tag:   tag,
+		Value: data[i : i+length],
+	}
+	if tag.Constructed() {
+		t.Children, err = Decode(t.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tag %s: %w", tag, err)
+		}
+	}
+	return t, data[i+length:], nil
+}
+
+func decodeTag(data []byte) (Tag, int, error) {
+	if len(data) <= 0 {
+		return 0, 0, fmt.Errorf("empty data object, %w", ErrTLV)
+	}
+	tag := Tag(data[0])
+	i := 1
+	if data[0]&0x1F != 0x1F {
+		return tag, i, nil
+	}
+	for {
+		if i >= len(data) || i >= maxTagLength {
+			return 0, 0, fmt.Errorf("truncated tag, %w", ErrTLV)
+		}
+		tag = tag<<8 | Tag(data[i])
+		i++
+		if data[i-1]&0x80 == 0 {
+			return tag, i, nil
+		}
+	}
+}
+
+// DecodeLength decode the BER length at the start of data, return the
+// length and the number of bytes of the encoding
+func DecodeLength(data []byte) (int, int, error) {
+	if len(data) <= 0 {
+		return 0, 0, fmt.Errorf("missing length, %w", ErrTLV)
+	}
+	if data[0] < 0x80 {
+		return int(data[0]), 1, nil
+	}
+	n := int(data[0] & 0x7F)
+	if n == 0 || n > maxLengthBytes || n >= len(data) {
+		return 0, 0, fmt.Errorf("bad length [% X], %w", data[:1], ErrTLV)
+	}
+	length := 0
+	for _, b := range data[1 : 1+n] {
+		length = length<<8 | int(b)
+	}
+	if length < 0 {
+		return 0, 0, fmt.Errorf("bad length [% X], %w", data[:1+n], ErrTLV)
+	}
+	return length, 1 + n, nil
+}
+
+// EncodeLength encode length in the shortest BER form
+func EncodeLength(length int) []byte {
+	switch {
+	case length < 0x80:
+		return []byte{byte(length)}
+	case length <= 0xFF:
+		return []byte{0x81, byte(length)}
+	case length <= 0xFFFF:
+		return []byte{0x82, byte(length >> 8), byte(length)}
+	case length <= 0xFFFFFF:
+		return []byte{0x83, byte(length >> 16), byte(length >> 8), byte(length)}
+	}
+	return []byte{0x84, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)}
+}
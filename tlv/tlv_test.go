@@ -0,0 +1,187 @@
+package tlv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	long := bytes.Repeat([]byte{0xAB}, 300)
+	tests := []struct {
+		name      string
+		data      []byte
+		wantTag   Tag
+		wantValue []byte
+		wantLen   int
+		wantErr   bool
+	}{
+		{
+			name:      "primitive",
+			data:      []byte{0x84, 0x02, 0xD2, 0x76},
+			wantTag:   0x84,
+			wantValue: []byte{0xD2, 0x76},
+			wantLen:   1,
+		},
+		{
+			name:      "multi-byte tag",
+			data:      []byte{0x9F, 0x02, 0x01, 0x11, 0x5F, 0x2D, 0x00},
+			wantTag:   0x9F02,
+			wantValue: []byte{0x11},
+			wantLen:   2,
+		},
+		{
+			name:      "nested constructed",
+			data:      []byte{0x6F, 0x07, 0xA5, 0x05, 0xBF, 0x0C, 0x02, 0x80, 0x00},
+			wantTag:   0x80,
+			wantValue: []byte{},
+			wantLen:   1,
+		},
+		{
+			name:      "long length",
+			data:      append([]byte{0x53, 0x82, 0x01, 0x2C}, long...),
+			wantTag:   0x53,
+			wantValue: long,
+			wantLen:   1,
+		},
+		{
+			name:      "padding",
+			data:      []byte{0x00, 0x80, 0x01, 0x05, 0xFF, 0xFF},
+			wantTag:   0x80,
+			wantValue: []byte{0x05},
+			wantLen:   1,
+		},
+		{
+			name:    "truncated value",
+			data:    []byte{0x80, 0x03, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "truncated tag",
+			data:    []byte{0x9F},
+			wantErr: true,
+		},
+		{
+			name:    "bad child",
+			data:    []byte{0x62, 0x02, 0x82, 0x05},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrTLV) {
+					t.Errorf("Decode() error = %v, want %v", err, ErrTLV)
+				}
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Fatalf("Decode() objects = %d, want %d", len(got), tt.wantLen)
+			}
+			found := got.Find(tt.wantTag)
+			if found == nil || !bytes.Equal(found.Value, tt.wantValue) {
+				t.Errorf("Decode() tag %s = %v, want [% X]", tt.wantTag, found, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestTLV_Bytes(t *testing.T) {
+	long := bytes.Repeat([]byte{0x01}, 200)
+	tests := []struct {
+		name string
+		tlv  *TLV
+		want []byte
+	}{
+		{
+			name: "primitive",
+			tlv:  New(0x83, []byte{0xE1, 0x04}),
+			want: []byte{0x83, 0x02, 0xE1, 0x04},
+		},
+		{
+			name: "multi-byte tag",
+			tlv:  New(0x9F7F, []byte{0x01}),
+			want: []byte{0x9F, 0x7F, 0x01, 0x01},
+		},
+		{
+			name: "long length",
+			tlv:  New(0x53, long),
+			want: append([]byte{0x53, 0x81, 0xC8}, long...),
+		},
+		{
+			name: "constructed",
+			tlv:  NewConstructed(0x62, New(0x82, []byte{0x01}), NewConstructed(0xA5)),
+			want: []byte{0x62, 0x05, 0x82, 0x01, 0x01, 0xA5, 0x00},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.tlv.Bytes()
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Bytes() = [% X], want [% X]", got, tt.want)
+			}
+			decoded, err := Decode(got)
+			if err != nil || len(decoded) != 1 || !bytes.Equal(decoded[0].Bytes(), got) {
+				t.Errorf("Decode(Bytes()) = %v, %v", decoded, err)
+			}
+		})
+	}
+}
+
+func TestDecodeHistorical(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantTags []byte
+		wantErr  bool
+	}{
+		{
+			name:     "compact",
+			data:     []byte{0x80, 0x31, 0x80, 0x73, 0x80, 0x21, 0x40},
+			wantTags: []byte{CompactCardService, CompactCardCapabilities},
+		},
+		{
+			name:     "compact with status",
+			data:     []byte{0x00, 0x31, 0xC0, 0x00, 0x90, 0x00},
+			wantTags: []byte{CompactCardService, CompactStatusIndicator},
+		},
+		{
+			name:    "truncated",
+			data:    []byte{0x80, 0x73, 0x80},
+			wantErr: true,
+		},
+		{
+			name:    "proprietary",
+			data:    []byte{0x4A, 0x43, 0x4F, 0x50},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeHistorical(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeHistorical() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			tags := make([]byte, 0)
+			for _, c := range got {
+				tags = append(tags, c.Tag)
+			}
+			if !bytes.Equal(tags, tt.wantTags) {
+				t.Errorf("DecodeHistorical() tags = [% X], want [% X]", tags, tt.wantTags)
+			}
+			if tt.data[0] == 0x80 {
+				encoded, err := EncodeCompact(got...)
+				if err != nil || !bytes.Equal(encoded, tt.data[1:]) {
+					t.Errorf("EncodeCompact() = [% X], %v, want [% X]", encoded, err, tt.data[1:])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package trace
+
+import (
+	"fmt"
+)
+
+// Decoder decode the name of the commands and the status of the responses
+type Decoder interface {
+	// Name name of the command ("" if unknown)
+	Name(command []byte) string
+	// Status status word of the response (ok is false if the response
+	// doesn't have status)
+	Status(command, response []byte) (sw uint16, ok bool)
+}
+
+var desfireNames = map[byte]string{
+	0x0A: "Authenticate",
+	0x1A: "AuthenticateISO",
+	0xAA: "AuthenticateAES",
+	0x71: "AuthenticateEV2First",
+	0x77: "AuthenticateEV2NonFirst",
+	0xAF: "AdditionalFrame",
+	0x54: "ChangeKeySettings",
+	0x5C: "SetConfiguration",
+	0xC4: "ChangeKey",
+	0xC6: "ChangeKeyEV2",
+	0x55: "InitializeKeySet",
+	0x57: "FinalizeKeySet",
+	0x56: "RollKeySet",
+	0x64: "GetKeyVersion",
+	0x45: "GetKeySettings",
+	0xCA: "CreateApplication",
+	0xDA: "DeleteApplication",
+	0xC9: "CreateDelegatedApplication",
+	0x5A: "SelectApplication",
+	0xFC: "FormatPICC",
+	0x60: "GetVersion",
+	0x6A: "GetApplicationIDs",
+	0x6D: "GetDFNames",
+	0x6E: "FreeMem",
+	0x51: "GetCardUID",
+	0x6F: "GetFileIDs",
+	0x61: "GetISOFileIDs",
+	0xF5: "GetFileSettings",
+	0x5F: "ChangeFileSettings",
+	0xF6: "GetFileCounters",
+	0xCD: "CreateStdDataFile",
+	0xCB: "CreateBackupDataFile",
+	0xCC: "CreateValueFile",
+	0xC1: "CreateLinearRecordFile",
+	0xC0: "CreateCyclicRecordFile",
+	0xCE: "CreateTransactionMACFile",
+	0xDF: "DeleteFile",
+	0xBD: "ReadData",
+	0x3D: "WriteData",
+	0x6C: "GetValue",
+	0x0C: "Credit",
+	0xDC: "Debit",
+	0x1C: "LimitedCredit",
+	0xBB: "ReadRecords",
+	0x3B: "WriteRecord",
+	0xDB: "UpdateRecord",
+	0xEB: "ClearRecordFile",
+	0xC7: "CommitTransaction",
+	0xA7: "AbortTransaction",
+	0xC8: "CommitReaderID",
+	0x3C: "ReadSig",
+	0xF0: "PreparePC",
+	0xF2: "ProximityCheck",
+	0xFD: "VerifyPC",
+}
+
+var plusNames = map[byte]string{
+	0x70: "FirstAuthenticate",
+	0x72: "AuthenticatePart2",
+	0x76: "FollowingAuthenticate",
+	0x78: "ResetAuth",
+	0x30: "ReadEncrypted",
+	0x31: "ReadEncryptedMAC",
+	0x32: "ReadPlain",
+	0x33: "ReadPlainMAC",
+	0x34: "ReadEncryptedUnMACed",
+	0x35: "ReadEncryptedUnMACedMAC",
+	0x36: "ReadPlainUnMACed",
+	0x37: "ReadPlainUnMACedMAC",
+	0xA0: "WriteEncrypted",
+	0xA1: "WriteEncryptedMAC",
+	0xA2: "WritePlain",
+	0xA3: "WritePlainMAC",
+	0xA8: "WritePerso",
+	0xAA: "CommitPerso",
+	0xB0: "Increment",
+	0xB1: "IncrementMAC",
+	0xB2: "Decrement",
+	0xB3: "DecrementMAC",
+	0xB4: "Transfer",
+	0xB5: "TransferMAC",
+	0xB6: "IncrementTransfer",
+	0xB7: "IncrementTransferMAC",
+	0xB8: "DecrementTransfer",
+	0xB9: "DecrementTransferMAC",
+	0xC2: "Restore",
+	0xC3: "RestoreMAC",
+	0x60: "GetVersion",
+	0x3C: "ReadSig",
+	0xF0: "PreparePC",
+	0xF2: "ProximityCheck",
+	0xFD: "VerifyPC",
+	0xAF: "AdditionalFrame",
+}
+
+var samNames = map[byte]string{
+	0xA4: "SAM_AuthenticateHost",
+	0x10: "SAM_LockUnlock",
+	0x60: "SAM_GetVersion",
+	0xAF: "SAM_AdditionalFrame",
+	0xC1: "SAM_ChangeKeyEntry",
+	0x64: "SAM_GetKeyEntry",
+	0x01: "SAM_ActivateOfflineKey",
+	0x71: "SAM_LoadInitVector",
+	0xED: "SAM_EncipherData",
+	0xDD: "SAM_DecipherData",
+	0x0E: "SAM_EncipherOfflineData",
+	0x0D: "SAM_DecipherOfflineData",
+	0x7C: "SAM_GenerateMAC",
+	0x5C: "SAM_VerifyMAC",
+	0xD5: "SAM_DumpSessionKey",
+	0xD6: "SAM_DumpSecretKey",
+	0xA3: "SAM_AuthenticateMFP",
+	0x33: "SAM_CombinedReadMFP",
+	0x34: "SAM_CombinedWriteMFP",
+	0x15: "PKI_GenerateKeyPair",
+	0x18: "PKI_ExportPublicKey",
+	0x19: "PKI_ImportKey",
+	0x1D: "PKI_UpdateKeyEntries",
+}
+
+var isoNames = map[byte]string{
+	0xA4: "SELECT",
+	0xB0: "READ BINARY",
+	0xD6: "UPDATE BINARY",
+	0xB2: "READ RECORD",
+	0xDC: "UPDATE RECORD",
+	0xE2: "APPEND RECORD",
+	0xC0: "GET RESPONSE",
+	0xCA: "GET DATA",
+	0x84: "GET CHALLENGE",
+	0x88: "INTERNAL AUTHENTICATE",
+	0x82: "EXTERNAL AUTHENTICATE",
+	0x20: "VERIFY",
+}
+
+var pcscNames = map[byte]string{
+	0xCA: "PCSC_GetData",
+	0x82: "PCSC_LoadKeys",
+	0x86: "PCSC_GeneralAuthenticate",
+	0x88: "PCSC_Authenticate",
+	0xB0: "PCSC_ReadBinary",
+	0xD6: "PCSC_UpdateBinary",
+	0xD7: "PCSC_ValueBlock",
+	0xC2: "PCSC_TransparentSession",
+}
+
+// DefaultDecoder decode ISO 7816 commands (PC/SC pseudo APDUs, SAM AV2/AV3
+// and DESFire wrapped with CLA 0x90 included) and native DESFire commands.
+var DefaultDecoder Decoder = &tableDecoder{native: desfireNames}
+
+// PlusDecoder decode ISO 7816 commands and native MIFARE Plus commands
+var PlusDecoder Decoder = &tableDecoder{native: plusNames}
+
+type tableDecoder struct {
+	native map[byte]string
+}
+
+// isISO the command is an ISO 7816 APDU (header CLA INS P1 P2)
+func isISO(command []byte) bool {
+	if len(command) < 4 {
+		return false
+	}
+	switch command[0] {
+	// interindustry class (logical channels 0 - 3), SAM, DESFire wrapped, PC/SC
+	case 0x00, 0x01, 0x02, 0x03, 0x80, 0x90, 0xFF:
+		return true
+	}
+	return false
+}
+
+func (d *tableDecoder) Name(command []byte) string {
+	if len(command) <= 0 {
+		return ""
+	}
+	if !isISO(command) {
+		return d.native[command[0]]
+	}
+	ins := command[1]
+	switch command[0] {
+	case 0x80:
+		return samNames[ins]
+	case 0x90:
+		if name, ok := desfireNames[ins]; ok {
+			return name
+		}
+	case 0xFF:
+		return pcscNames[ins]
+	}
+	if name, ok := isoNames[ins]; ok {
+		return name
+	}
+	return fmt.Sprintf("INS %02X", ins)
+}
+
+func (d *tableDecoder) Status(command, response []byte) (uint16, bool) {
+	if len(response) <= 0 {
+		return 0, false
+	}
+	if isISO(command) {
+		if len(response) < 2 {
+			return 0, false
+		}
+		return uint16(response[len(response)-2])<<8 | uint16(response[len(response)-1]), true
+	}
+	// native responses: status in the first byte
+	return uint16(response[0]), true
+}
@@ -0,0 +1,227 @@
+/*
+package with an ICard middleware that emits a structured trace of every
+exchange with the card (direction, bytes, command name, status word and
+latency), hiding the sensitive bytes marked by the card packages with
+smartcard.RedactNext.
+
+projects on which it is based:
+
+	https://github.com/dumacp/smartcard
+*/
+package trace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dumacp/smartcard"
+)
+
+// Direction direction of the frame
+type Direction int
+
+const (
+	// Command frame sent to the card
+	Command Direction = iota
+	// Response frame received from the card
+	Response
+)
+
+func (d Direction) String() string {
+	if d == Response {
+		return "<<"
+	}
+	return ">>"
+}
+
+// Event trace of a frame. Data is a copy of the frame with the sensitive
+// bytes set to zero; Redacted has the ranges [start, end) of them.
+type Event struct {
+	Time      time.Time
+	Direction Direction
+	Name      string
+	Data      []byte
+	Redacted  [][2]int
+	SW        uint16
+	HasSW     bool
+	Latency   time.Duration
+	Err       error
+}
+
+// Hex frame in hexadecimal, the sensitive bytes as "**"
+func (e *Event) Hex() string {
+	sb := new(strings.Builder)
+	for i, b := range e.Data {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		redacted := false
+		for _, r := range e.Redacted {
+			if i >= r[0] && i < r[1] {
+				redacted = true
+				break
+			}
+		}
+		if redacted {
+			sb.WriteString("**")
+		} else {
+			fmt.Fprintf(sb, "%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func (e *Event) String() string {
+	name := e.Name
+	if len(name) <= 0 {
+		name = "-"
+	}
+	switch {
+	case e.Direction == Command:
+		return fmt.Sprintf("%s %s [%s]", e.Direction, name, e.Hex())
+	case e.Err != nil:
+		return fmt.Sprintf("%s %s error: %s (%s)", e.Direction, name, e.Err, e.Latency)
+	case e.HasSW:
+		return fmt.Sprintf("%s %s [%s] SW: %04X (%s)", e.Direction, name, e.Hex(), e.SW, e.Latency)
+	}
+	return fmt.Sprintf("%s %s [%s] (%s)", e.Direction, name, e.Hex(), e.Latency)
+}
+
+// Sink function that receives the events
+type Sink func(e *Event)
+
+// LogSink sink that writes the events in logger (log.Default() if nil)
+func LogSink(logger *log.Logger) Sink {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(e *Event) {
+		logger.Println(e.String())
+	}
+}
+
+// Card ICard middleware that traces the exchanges with the card
+type Card struct {
+	smartcard.ICard
+	mux     sync.Mutex
+	sink    Sink
+	decoder Decoder
+	cmdSpan []smartcard.Span
+	rspSpan []smartcard.Span
+}
+
+// NewCard wrap the card with a tracer that sends the events to sink
+func NewCard(card smartcard.ICard, sink Sink) *Card {
+	c := &Card{
+		ICard:   card,
+		sink:    sink,
+		decoder: DefaultDecoder,
+	}
+	return c
+}
+
+// SetDecoder set the decoder of command names and status words
+// (DefaultDecoder or PlusDecoder)
+func (c *Card) SetDecoder(decoder Decoder) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.decoder = decoder
+}
+
+// RedactNext mark the sensitive bytes of the next exchange
+func (c *Card) RedactNext(command, response []smartcard.Span) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.cmdSpan = append(c.cmdSpan, command...)
+	c.rspSpan = append(c.rspSpan, response...)
+}
+
+// BeginExclusive forward the exclusive access to the wrapped card
+func (c *Card) BeginExclusive() error {
+	return smartcard.BeginExclusive(c.ICard)
+}
+
+// EndExclusive forward the end of the exclusive access to the wrapped card
+func (c *Card) EndExclusive() error {
+	return smartcard.EndExclusive(c.ICard)
+}
+
+// Apdu send the command to the card and trace the exchange
+func (c *Card) Apdu(apdu []byte) ([]byte, error) {
+	return c.ApduContext(context.Background(), apdu)
+}
+
+// ApduContext send the command to the card with ctx and trace the exchange
+func (c *Card) ApduContext(ctx context.Context, apdu []byte) ([]byte, error) {
+	resp, _, err := c.exchange(apdu, func() ([]byte, time.Duration, error) {
+		t0 := time.Now()
+		resp, err := smartcard.ApduContext(ctx, c.ICard, apdu)
+		return resp, time.Since(t0), err
+	})
+	return resp, err
+}
+
+// ApduTimed send the command to the card and trace the exchange, the latency
+// of the event is the round trip time measured by the wrapped card
+func (c *Card) ApduTimed(apdu []byte) ([]byte, time.Duration, error) {
+	return c.exchange(apdu, func() ([]byte, time.Duration, error) {
+		return smartcard.ApduTimed(c.ICard, apdu)
+	})
+}
+
+// exchange trace the command and the response of send
+func (c *Card) exchange(apdu []byte, send func() ([]byte, time.Duration, error)) ([]byte, time.Duration, error) {
+	c.mux.Lock()
+	decoder := c.decoder
+	cmdSpan, rspSpan := c.cmdSpan, c.rspSpan
+	c.cmdSpan, c.rspSpan = nil, nil
+	c.mux.Unlock()
+
+	name := decoder.Name(apdu)
+	c.emit(&Event{
+		Time:      time.Now(),
+		Direction: Command,
+		Name:      name,
+		Data:      apdu,
+	}, cmdSpan)
+
+	resp, elapsed, err := send()
+
+	ev := &Event{
+		Time:      time.Now(),
+		Direction: Response,
+		Name:      name,
+		Data:      resp,
+		Latency:   elapsed,
+		Err:       err,
+	}
+	if err == nil {
+		ev.SW, ev.HasSW = decoder.Status(apdu, resp)
+	}
+	c.emit(ev, rspSpan)
+	return resp, elapsed, err
+}
+
+func (c *Card) emit(ev *Event, spans []smartcard.Span) {
+	if c.sink == nil {
+		return
+	}
+	data := make([]byte, len(ev.Data))
+	copy(data, ev.Data)
+	for _, s := range spans {
+		start, end := s.Resolve(len(data))
+		if start >= end {
+			continue
+		}
+		for i := start; i < end; i++ {
+			data[i] = 0x00
+		}
+		ev.Redacted = append(ev.Redacted, [2]int{start, end})
+	}
+	ev.Data = data
+	c.sink(ev)
+}
@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dumacp/smartcard"
+	"github.com/dumacp/smartcard/sim"
+)
+
+func TestCard_Apdu(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []byte
+		response []byte
+		cmdSpan  []smartcard.Span
+		rspSpan  []smartcard.Span
+		decoder  Decoder
+		wantName string
+		wantSW   uint16
+		wantHex  [2]string
+	}{
+		{
+			name:     "native desfire",
+			command:  []byte{0x60},
+			response: []byte{0xAF, 0x04, 0x01},
+			wantName: "GetVersion",
+			wantSW:   0xAF,
+			wantHex:  [2]string{"60", "AF 04 01"},
+		},
+		{
+			name:     "desfire auth redacted",
+			command:  []byte{0xAF, 0x11, 0x22, 0x33},
+			response: []byte{0x00, 0x44, 0x55},
+			cmdSpan:  []smartcard.Span{{Offset: 1, Length: -1}},
+			rspSpan:  []smartcard.Span{{Offset: 1, Length: -1}},
+			wantName: "AdditionalFrame",
+			wantSW:   0x00,
+			wantHex:  [2]string{"AF ** ** **", "00 ** **"},
+		},
+		{
+			name:     "sam redacted without SW",
+			command:  []byte{0x80, 0xD5, 0x00, 0x00, 0x00},
+			response: []byte{0x01, 0x02, 0x03, 0x90, 0x00},
+			rspSpan:  []smartcard.Span{{Offset: 0, Length: -3}},
+			wantName: "SAM_DumpSessionKey",
+			wantSW:   0x9000,
+			wantHex:  [2]string{"80 D5 00 00 00", "** ** ** 90 00"},
+		},
+		{
+			name:     "plus first authenticate",
+			command:  []byte{0x70, 0x00, 0x40, 0x00},
+			response: []byte{0x90, 0x01},
+			decoder:  PlusDecoder,
+			wantName: "FirstAuthenticate",
+			wantSW:   0x90,
+			wantHex:  [2]string{"70 00 40 00", "90 01"},
+		},
+		{
+			name:     "iso unknown instruction",
+			command:  []byte{0x00, 0x12, 0x00, 0x00},
+			response: []byte{0x6D, 0x00},
+			wantName: "INS 12",
+			wantSW:   0x6D00,
+			wantHex:  [2]string{"00 12 00 00", "6D 00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := sim.NewCard(nil, nil, nil, 0x20)
+			card.Expect(tt.command, tt.response)
+			events := make([]*Event, 0)
+			c := NewCard(card, func(e *Event) { events = append(events, e) })
+			if tt.decoder != nil {
+				c.SetDecoder(tt.decoder)
+			}
+			smartcard.RedactNext(c, tt.cmdSpan, tt.rspSpan)
+			got, err := c.Apdu(tt.command)
+			if err != nil {
+				t.Fatalf("Apdu() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.response) {
+				t.Errorf("Apdu() = [% X], want [% X]", got, tt.response)
+			}
+			if len(events) != 2 {
+				t.Fatalf("events = %d, want 2", len(events))
+			}
+			for i, dir := range []Direction{Command, Response} {
+				if events[i].Direction != dir {
+					t.Errorf("events[%d].Direction = %v, want %v", i, events[i].Direction, dir)
+				}
+				if events[i].Name != tt.wantName {
+					t.Errorf("events[%d].Name = %q, want %q", i, events[i].Name, tt.wantName)
+				}
+				if hex := events[i].Hex(); hex != tt.wantHex[i] {
+					t.Errorf("events[%d].Hex() = %q, want %q", i, hex, tt.wantHex[i])
+				}
+			}
+			if !events[1].HasSW || events[1].SW != tt.wantSW {
+				t.Errorf("SW = %04X (%v), want %04X", events[1].SW, events[1].HasSW, tt.wantSW)
+			}
+			// the redaction only applies to the next exchange
+			card.Expect(tt.command, tt.response)
+			if _, err := c.Apdu(tt.command); err != nil {
+				t.Fatalf("Apdu() error = %v", err)
+			}
+			if len(events[3].Redacted) != 0 {
+				t.Errorf("Redacted = %v, want none", events[3].Redacted)
+			}
+		})
+	}
+}
+
+func TestCard_ApduError(t *testing.T) {
+	card := sim.NewCard(nil, nil, nil, 0x20)
+	events := make([]*Event, 0)
+	c := NewCard(smartcard.NewGetResponseCard(card), func(e *Event) { events = append(events, e) })
+	if _, err := c.Apdu([]byte{0x60}); !errors.Is(err, sim.ErrUnexpectedApdu) {
+		t.Fatalf("Apdu() error = %v, want %v", err, sim.ErrUnexpectedApdu)
+	}
+	if len(events) != 2 || events[1].Err == nil || events[1].HasSW {
+		t.Errorf("events = %v, want command and response with error", events)
+	}
+}
@@ -0,0 +1,165 @@
+package smartcard
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// CardEventType type of card event
+type CardEventType int
+
+const (
+	// CardPresent a card was detected in the reader
+	CardPresent CardEventType = iota
+	// CardRemoved the card was removed from the reader
+	CardRemoved
+)
+
+func (t CardEventType) String() string {
+	switch t {
+	case CardPresent:
+		return "CardPresent"
+	case CardRemoved:
+		return "CardRemoved"
+	}
+	return "unknown"
+}
+
+// CardEvent insertion or removal of a card. UID and ATR are the values of
+// the card detected (removed), nil if the reader doesn't report them.
+type CardEvent struct {
+	Type CardEventType
+	UID  []byte
+	ATR  []byte
+	Time time.Time
+}
+
+// IReaderWatcher Interface to readers with native notification of card
+// insertion and removal (PCSC SCardGetStatusChange). The channel is closed
+// when ctx is done.
+type IReaderWatcher interface {
+	Watch(ctx context.Context) (<-chan CardEvent, error)
+}
+
+// IReaderPoller Interface to readers that detect the card in the field with
+// the minimal exchange (request and anticollision), without the activation
+// of the card. Poll returns the UID of the card or an error if there is not
+// a card in the field.
+type IReaderPoller interface {
+	Poll() ([]byte, error)
+}
+
+// DefaultPollInterval interval between polls of the reader
+const DefaultPollInterval = 200 * time.Millisecond
+
+// pollMisses consecutive failed polls to report the removal of the card.
+// A card activated by the application doesn't answer the first request.
+const pollMisses = 2
+
+// Watcher emits the insertion and removal of cards in a reader. It uses the
+// native notification of the reader (IReaderWatcher) or, else, polls the
+// reader with IReaderPoller or ConnectCard.
+type Watcher struct {
+	reader   IReader
+	interval time.Duration
+	mux      sync.Mutex
+}
+
+// NewWatcher create a watcher to reader, interval is the time between polls
+// (DefaultPollInterval if interval <= 0)
+func NewWatcher(reader IReader, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	w := &Watcher{
+		reader:   reader,
+		interval: interval,
+	}
+	return w
+}
+
+// Lock stop the polling of the reader until Unlock. The application should
+// lock the watcher while it sends commands to the card, because a poll
+// re-selects the card in the field.
+func (w *Watcher) Lock() {
+	w.mux.Lock()
+}
+
+// Unlock resume the polling of the reader
+func (w *Watcher) Unlock() {
+	w.mux.Unlock()
+}
+
+// Watch emits the card events on the channel until ctx is done
+func (w *Watcher) Watch(ctx context.Context) (<-chan CardEvent, error) {
+	if rw, ok := w.reader.(IReaderWatcher); ok {
+		return rw.Watch(ctx)
+	}
+
+	ch := make(chan CardEvent)
+	go func() {
+		defer close(ch)
+		var last *CardEvent
+		misses := 0
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.mux.Lock()
+			uid, atr, err := w.poll()
+			w.mux.Unlock()
+
+			events := make([]CardEvent, 0)
+			switch {
+			case err != nil:
+				misses++
+				if last != nil && misses >= pollMisses {
+					events = append(events, CardEvent{Type: CardRemoved, UID: last.UID, ATR: last.ATR})
+					last = nil
+				}
+			case last != nil && bytes.Equal(last.UID, uid):
+				misses = 0
+			default:
+				misses = 0
+				if last != nil {
+					events = append(events, CardEvent{Type: CardRemoved, UID: last.UID, ATR: last.ATR})
+				}
+				last = &CardEvent{Type: CardPresent, UID: uid, ATR: atr}
+				events = append(events, *last)
+			}
+			for _, e := range events {
+				e.Time = time.Now()
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (w *Watcher) poll() ([]byte, []byte, error) {
+	if p, ok := w.reader.(IReaderPoller); ok {
+		uid, err := p.Poll()
+		return uid, nil, err
+	}
+	card, err := w.reader.ConnectCard()
+	if err != nil {
+		return nil, nil, err
+	}
+	uid, err := card.UID()
+	if err != nil {
+		return nil, nil, err
+	}
+	atr, _ := card.ATR()
+	return uid, atr, nil
+}
@@ -0,0 +1,98 @@
+package smartcard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pollReader reader that answers the polls with a sequence of UIDs (nil:
+// without card), the last one is repeated
+type pollReader struct {
+	mux  sync.Mutex
+	uids [][]byte
+}
+
+func (r *pollReader) Poll() ([]byte, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(r.uids) <= 0 {
+		return nil, ErrNoSmartcard
+	}
+	uid := r.uids[0]
+	if len(r.uids) > 1 {
+		r.uids = r.uids[1:]
+	}
+	if uid == nil {
+		return nil, ErrNoSmartcard
+	}
+	return uid, nil
+}
+
+func (r *pollReader) ConnectCard() (ICard, error)         { return nil, errors.New("not supported") }
+func (r *pollReader) ConnectSamCard() (ICard, error)      { return nil, errors.New("not supported") }
+func (r *pollReader) ConnectSamCard_T0() (ICard, error)   { return nil, errors.New("not supported") }
+func (r *pollReader) ConnectSamCard_Tany() (ICard, error) { return nil, errors.New("not supported") }
+
+func TestWatcher_Watch(t *testing.T) {
+	uid1 := []byte{0x01, 0x02, 0x03, 0x04}
+	uid2 := []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+	tests := []struct {
+		name string
+		uids [][]byte
+		want []CardEvent
+	}{
+		{
+			name: "insert and remove",
+			uids: [][]byte{nil, uid1, uid1, nil},
+			want: []CardEvent{
+				{Type: CardPresent, UID: uid1},
+				{Type: CardRemoved, UID: uid1},
+			},
+		},
+		{
+			name: "single miss is not a removal",
+			uids: [][]byte{uid1, nil, uid1, uid1},
+			want: []CardEvent{
+				{Type: CardPresent, UID: uid1},
+			},
+		},
+		{
+			name: "card swapped",
+			uids: [][]byte{uid1, uid2, uid2},
+			want: []CardEvent{
+				{Type: CardPresent, UID: uid1},
+				{Type: CardRemoved, UID: uid1},
+				{Type: CardPresent, UID: uid2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &pollReader{uids: tt.uids}
+			w := NewWatcher(reader, time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(tt.uids)+20)*time.Millisecond)
+			defer cancel()
+			ch, err := w.Watch(ctx)
+			if err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+			got := make([]CardEvent, 0)
+			for e := range ch {
+				got = append(got, e)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Watch() events = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || !bytes.Equal(got[i].UID, tt.want[i].UID) {
+					t.Errorf("Watch() event[%d] = %v [% X], want %v [% X]",
+						i, got[i].Type, got[i].UID, tt.want[i].Type, tt.want[i].UID)
+				}
+			}
+		})
+	}
+}